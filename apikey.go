@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/joy-currency-conversion-private/config"
+	"github.com/joy-currency-conversion-private/infrastructure/auth"
+)
+
+// runAPIKey handles the `apikey` subcommand
+// Usage: main apikey [create <name> <scope,...> | list | revoke <id>]
+func runAPIKey(args []string) error {
+	cfg, err := config.LoadDatabaseConfig()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+
+	store, err := openStorage(cfg)
+	if err != nil {
+		return fmt.Errorf("db connect: %w", err)
+	}
+	defer store.conn.Close()
+
+	ctx := context.Background()
+	keys := auth.NewKeys(store.apiKeys)
+
+	command := "list"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	switch command {
+	case "create":
+		if len(args) != 3 {
+			return fmt.Errorf("usage: apikey create <name> <scope,...>")
+		}
+		key, plain, err := keys.Issue(ctx, args[1], strings.Split(args[2], ","))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Created API key %s (%s) with scopes %s\n", key.ID, key.Name, strings.Join(key.Scopes, ","))
+		fmt.Printf("Key: %s\n", plain)
+		fmt.Println("Store it now, it can't be shown again")
+	case "list":
+		list, err := keys.List(ctx)
+		if err != nil {
+			return err
+		}
+		for _, key := range list {
+			status := "active"
+			if key.RevokedAt != nil {
+				status = "revoked " + key.RevokedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%s\t%s\t%s...\t%s\t%s\n", key.ID, key.Name, key.Prefix, strings.Join(key.Scopes, ","), status)
+		}
+	case "revoke":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: apikey revoke <id>")
+		}
+		if err := keys.Revoke(ctx, args[1]); err != nil {
+			return err
+		}
+		fmt.Printf("Revoked API key %s\n", args[1])
+	default:
+		return fmt.Errorf("unknown apikey command %q (use create, list or revoke)", command)
+	}
+
+	return nil
+}
@@ -3,57 +3,226 @@ package config
 import (
 	"context"
 	"fmt"
-	"os"
-
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"net/netip"
+	"strconv"
+	"time"
 )
 
+// Profiles select the defaults applied before any other source
 const (
-	name = "/exchange-rate/api-key"
+	// ProfileAWS reads secrets from AWS Parameter Store and connects to MySQL
+	ProfileAWS = "aws"
+
+	// ProfileLocal needs no AWS access: SSM is disabled and storage defaults to SQLite
+	ProfileLocal = "local"
 )
 
 type Config struct {
-	KyeEchangeRateAPI string
+	Profile   string
+	Port      string
+	AWSRegion string
+	SSM       bool
+
+	// ReloadInterval is how often secrets are refreshed, zero disables it
+	ReloadInterval time.Duration
+
+	KyeEchangeRateAPI  string
 	KyeEchangeRatesAPI string
+
+	Log           LogConfig
+	Server        ServerConfig
+	Providers     ProvidersConfig
+	Auth          AuthConfig
+	RateLimit     RateLimitConfig
+	Database      DatabaseConfig
+	Favorites     FavoritesConfig
+	Notifications NotificationsConfig
+	Ingestion     IngestionConfig
+	Tracing       TracingConfig
+}
+
+// LogConfig holds the logger settings
+type LogConfig struct {
+	Level  string // debug, info, warn or error
+	Format string // json or text
+}
+
+// ServerConfig holds the HTTP server timeouts and the proxies in front of it
+type ServerConfig struct {
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+
+	// ShutdownTimeout bounds how long in-flight requests may take to finish on shutdown
+	ShutdownTimeout time.Duration
+
+	// DrainDelay is how long /readyz reports the server draining before it stops
+	// accepting connections, so the load balancer stops routing to it first
+	DrainDelay time.Duration
+
+	// TrustedProxies are the peers whose X-Forwarded-For and X-Real-IP headers
+	// are believed, e.g. the load balancer subnet. Empty trusts none.
+	TrustedProxies []netip.Prefix
+}
+
+// ProvidersConfig holds how the rate providers are called
+type ProvidersConfig struct {
+	ExchangeRateAPI  ProviderConfig
+	ExchangeRatesAPI ProviderConfig
+
+	// MaxRetries is how many times a call failing with a network error, 429 or 5xx is repeated
+	MaxRetries int
+
+	// QuotaReserve is the fraction of each monthly quota kept unused
+	QuotaReserve float64
+}
+
+// ProviderConfig holds the limits of one rate provider
+type ProviderConfig struct {
+	Timeout      time.Duration
+	Rate         float64 // calls per second
+	MonthlyQuota int     // 0 means unlimited
+}
+
+// AuthConfig holds the API authentication settings
+type AuthConfig struct {
+	// Enabled requires an API key or user token with the right scope on every /api/v1 endpoint
+	Enabled bool
+
+	// JWTSecret signs the user tokens, a random one is generated at startup when empty
+	JWTSecret string
+
+	// TokenTTL is how long a user token issued at login stays valid
+	TokenTTL time.Duration
+
+	// AnonymousFavorites lets requests without a principal save and manage every
+	// favorite. Only meant for local development with auth disabled.
+	AnonymousFavorites bool
+}
+
+// RateLimitConfig holds the inbound request limits, in requests per minute.
+// Cheap limits apply to /convert and the other light endpoints, expensive
+// ones to /history and /forecast, the login limit to password logins.
+type RateLimitConfig struct {
+	Enabled bool
+	Store   string // memory (per instance) or sql (shared)
+
+	IPCheap      int
+	IPExpensive  int
+	IPLogin      int
+	KeyCheap     int
+	KeyExpensive int
+}
+
+// DatabaseConfig holds the SQL storage settings
+type DatabaseConfig struct {
+	Driver      string
+	Path        string
+	Host        string
+	Port        string
+	User        string
+	Password    string
+	Name        string
+	AutoMigrate bool
+}
+
+// FavoritesConfig holds the favorites storage settings
+type FavoritesConfig struct {
+	Store               string
+	DynamoDBTable       string
+	DynamoDBEndpoint    string
+	DynamoDBCreateTable bool
+}
+
+// NotificationsConfig holds the notification delivery settings
+type NotificationsConfig struct {
+	// SQSQueueURL is the queue emails are sent through, empty skips its readiness check
+	SQSQueueURL string
+
+	// EmailFrom is the SES verified address emails are sent from, empty sends
+	// no verification emails
+	EmailFrom string
 }
 
+// IngestionConfig holds the daily rate ingestion settings
+type IngestionConfig struct {
+	// Enabled runs the ingestion and forecast precomputation once a day
+	Enabled bool
+
+	// At is the time of day (UTC) the ingestion runs, as an offset from midnight
+	At time.Duration
+}
+
+// TracingConfig holds the OpenTelemetry settings
+type TracingConfig struct {
+	Exporter    string  // none or otlp
+	Endpoint    string  // OTLP/HTTP collector URL, empty uses the exporter default
+	SampleRatio float64 // fraction of new traces recorded, between 0 and 1
+}
+
+// LoadConfig loads and validates the full application configuration.
+//
+// Values are merged from, in increasing precedence:
+//  1. built-in defaults for the selected profile (APP_PROFILE, "aws" by default)
+//  2. the YAML or TOML file named by CONFIG_FILE
+//  3. environment variables
+//  4. AWS Parameter Store, unless disabled (ssm.enabled=false or the local profile)
 func LoadConfig() (*Config, error) {
-	// Get from local
-	// exchangeKey := os.Getenv("EXCHANGE_RATE_API_KEY")
-	// if exchangeKey == "" {
-	// 	return &Config{}, fmt.Errorf("EXCHANGE_RATE_API_KEY is not set")
-	// }
-
-	// Fetching secrets directly from AWS Parameter Store
-	ctx := context.TODO()
-
-    // Load AWS default configuration (uses EC2 IAM role automatically)
-    cfg, err := config.LoadDefaultConfig(ctx)
-    if err != nil {
-        return &Config{}, fmt.Errorf("failed to load AWS config: %w", err)
-    }
-
-    client := ssm.NewFromConfig(cfg)
-    param, err := client.GetParameter(ctx, &ssm.GetParameterInput{
-        Name:           aws.String(name),
-        WithDecryption: aws.Bool(true),
-    })
-    if err != nil {
-        return &Config{}, fmt.Errorf("EXCHANGE_RATE_API_KEY is not set, Error: %w", err)
-    }
-
-    exchangeKey := *param.Parameter.Value
-
-	// Fetching secrets from .env file
-	exchangeRatesKey := os.Getenv("EXCHANGE_RATES_API_KEY")
-	if exchangeRatesKey == "" {
-		return &Config{}, fmt.Errorf("EXCHANGE_RATES_API_KEY is not set")
-	}
-
-	return &Config{
-		KyeEchangeRateAPI: exchangeKey,
-		KyeEchangeRatesAPI: exchangeRatesKey,
-	}, nil
-}
\ No newline at end of file
+	return load(true)
+}
+
+// LoadDatabaseConfig loads the configuration validating only the storage
+// settings, for commands such as migrate that never call the rate providers
+func LoadDatabaseConfig() (*Config, error) {
+	return load(false)
+}
+
+func load(requireProviders bool) (*Config, error) {
+	fileValues, err := readFile()
+	if err != nil {
+		return &Config{}, err
+	}
+	envValues := readEnv()
+
+	// The profile decides the defaults, so it is resolved first
+	profile := ProfileAWS
+	if value, ok := fileValues["profile"]; ok {
+		profile = value
+	}
+	if value, ok := envValues["profile"]; ok {
+		profile = value
+	}
+
+	values, err := defaults(profile)
+	if err != nil {
+		return &Config{}, err
+	}
+	merge(values, fileValues)
+	merge(values, envValues)
+
+	ssmEnabled, err := strconv.ParseBool(values["ssm.enabled"])
+	if err != nil {
+		return &Config{}, fmt.Errorf("invalid ssm.enabled value %q", values["ssm.enabled"])
+	}
+	if ssmEnabled {
+		ssmValues, err := readSSM(context.TODO(), values["aws_region"])
+		if err != nil {
+			return &Config{}, err
+		}
+		merge(values, ssmValues)
+	}
+
+	cfg, err := build(values, requireProviders)
+	if err != nil {
+		return &Config{}, err
+	}
+
+	return cfg, nil
+}
+
+// merge copies every value from src into dst, overriding existing keys
+func merge(dst, src map[string]string) {
+	for key, value := range src {
+		dst[key] = value
+	}
+}
@@ -0,0 +1,157 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Reloader keeps the current configuration and refreshes it on demand,
+// periodically or when signalled. Only secrets and non-structural settings
+// are applied at runtime: the log level, the reload interval, the provider
+// API keys, the JWT secret and the MySQL host, port, user and password. Any
+// other change is reported and requires a restart.
+type Reloader struct {
+	current   atomic.Pointer[Config]
+	mu        sync.Mutex // serializes reloads and listener registration
+	listeners []func(cfg *Config)
+}
+
+// NewReloader creates a Reloader starting from an already loaded configuration
+func NewReloader(initial *Config) *Reloader {
+	r := &Reloader{}
+	r.current.Store(initial)
+	return r
+}
+
+// Current returns the configuration in use
+func (r *Reloader) Current() *Config {
+	return r.current.Load()
+}
+
+// OnReload registers a function called with the new configuration after every successful reload
+func (r *Reloader) OnReload(fn func(cfg *Config)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.listeners = append(r.listeners, fn)
+}
+
+// Reload loads the configuration again from every source. On error the
+// current configuration is kept.
+func (r *Reloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	loaded, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	current := r.current.Load()
+	if changed := structuralChanges(current, loaded); len(changed) > 0 {
+		slog.Warn("config reload: ignoring changes that need a restart", "settings", changed)
+	}
+
+	// Start from the running configuration and take only what can change at runtime
+	next := *current
+	next.ReloadInterval = loaded.ReloadInterval
+	next.Log.Level = loaded.Log.Level
+	next.KyeEchangeRateAPI = loaded.KyeEchangeRateAPI
+	next.KyeEchangeRatesAPI = loaded.KyeEchangeRatesAPI
+	next.Auth.JWTSecret = loaded.Auth.JWTSecret
+	if next.Database.Driver == loaded.Database.Driver {
+		next.Database.Host = loaded.Database.Host
+		next.Database.Port = loaded.Database.Port
+		next.Database.User = loaded.Database.User
+		next.Database.Password = loaded.Database.Password
+	}
+
+	r.current.Store(&next)
+	for _, fn := range r.listeners {
+		fn(&next)
+	}
+
+	return nil
+}
+
+// Run reloads the configuration every ReloadInterval and whenever a value is
+// received on signals, until ctx is done
+func (r *Reloader) Run(ctx context.Context, signals <-chan os.Signal) {
+	var ticker *time.Ticker
+	var tick <-chan time.Time
+	interval := time.Duration(0)
+	resetTicker := func(next time.Duration) {
+		if ticker != nil {
+			ticker.Stop()
+			ticker, tick = nil, nil
+		}
+		interval = next
+		if interval > 0 {
+			ticker = time.NewTicker(interval)
+			tick = ticker.C
+		}
+	}
+	resetTicker(r.Current().ReloadInterval)
+	defer resetTicker(0)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick:
+		case sig := <-signals:
+			slog.Info("config reload requested", "signal", sig.String())
+		}
+
+		if err := r.Reload(); err != nil {
+			slog.Error("config reload failed, keeping current configuration", "error", err)
+			continue
+		}
+		slog.Info("config reloaded")
+
+		// Follow interval changes made by the reload
+		if next := r.Current().ReloadInterval; next != interval {
+			resetTicker(next)
+		}
+	}
+}
+
+// structuralChanges lists the settings that differ but can't change without a restart
+func structuralChanges(current, loaded *Config) []string {
+	current, loaded = withoutRotatable(current), withoutRotatable(loaded)
+
+	var changed []string
+	check := func(name string, a, b any) {
+		if !reflect.DeepEqual(a, b) {
+			changed = append(changed, name)
+		}
+	}
+	check("profile", current.Profile, loaded.Profile)
+	check("port", current.Port, loaded.Port)
+	check("log.format", current.Log.Format, loaded.Log.Format)
+	check("server", current.Server, loaded.Server)
+	check("providers", current.Providers, loaded.Providers)
+	check("auth", current.Auth, loaded.Auth)
+	check("rate_limit", current.RateLimit, loaded.RateLimit)
+	check("aws_region", current.AWSRegion, loaded.AWSRegion)
+	check("ssm.enabled", current.SSM, loaded.SSM)
+	check("db", current.Database, loaded.Database)
+	check("favorites", current.Favorites, loaded.Favorites)
+	check("notifications", current.Notifications, loaded.Notifications)
+	check("ingestion", current.Ingestion, loaded.Ingestion)
+	check("tracing", current.Tracing, loaded.Tracing)
+	return changed
+}
+
+// withoutRotatable returns a copy of cfg without the secrets Reload applies,
+// so they aren't reported along with the structural settings
+func withoutRotatable(cfg *Config) *Config {
+	c := *cfg
+	c.Auth.JWTSecret = ""
+	c.Database.Host, c.Database.Port, c.Database.User, c.Database.Password = "", "", "", ""
+	return &c
+}
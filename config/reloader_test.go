@@ -0,0 +1,64 @@
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+// setEnv sets the environment of a local profile configuration on MySQL
+func setEnv(t *testing.T, values map[string]string) {
+	t.Helper()
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("APP_PROFILE", ProfileLocal)
+	t.Setenv("EXCHANGE_RATE_API_KEY", "a")
+	t.Setenv("EXCHANGE_RATES_API_KEY", "b")
+	t.Setenv("DB_DRIVER", "mysql")
+	t.Setenv("DB_HOST", "localhost")
+	t.Setenv("DB_PORT", "3306")
+	for key, value := range values {
+		t.Setenv(key, value)
+	}
+}
+
+func TestReloadAppliesSecrets(t *testing.T) {
+	setEnv(t, map[string]string{"JWT_SECRET": "one", "DB_USER": "app", "DB_PASSWORD": "first", "DB_NAME": "exchange_db", "PORT": "8080"})
+	initial, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	reloader := NewReloader(initial)
+
+	var notified *Config
+	reloader.OnReload(func(cfg *Config) { notified = cfg })
+
+	setEnv(t, map[string]string{
+		"EXCHANGE_RATE_API_KEY": "rotated",
+		"JWT_SECRET":            "two",
+		"DB_USER":               "app2",
+		"DB_PASSWORD":           "second",
+		"DB_NAME":               "other_db",
+		"PORT":                  "9999",
+	})
+	loaded, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if changed := structuralChanges(initial, loaded); !slices.Equal(changed, []string{"port", "db"}) {
+		t.Errorf("structural changes = %v, want [port db] without the rotated secrets", changed)
+	}
+
+	if err := reloader.Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	current := reloader.Current()
+	if notified != current {
+		t.Error("listener not called with the reloaded configuration")
+	}
+	if current.KyeEchangeRateAPI != "rotated" || current.Auth.JWTSecret != "two" || current.Database.User != "app2" || current.Database.Password != "second" {
+		t.Errorf("secrets not applied: api key %q, jwt secret %q, db user %q, db password %q",
+			current.KyeEchangeRateAPI, current.Auth.JWTSecret, current.Database.User, current.Database.Password)
+	}
+	if current.Database.Name != "exchange_db" || current.Port != "8080" {
+		t.Errorf("db name %q, port %q; want the settings needing a restart unchanged", current.Database.Name, current.Port)
+	}
+}
@@ -0,0 +1,243 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"gopkg.in/yaml.v3"
+)
+
+// setting describes one configuration key and where it can come from
+type setting struct {
+	key string // file key, nested sections are joined with dots
+	env string // environment variable
+	ssm string // Parameter Store name, empty when not stored in SSM
+}
+
+var settings = []setting{
+	{key: "profile", env: "APP_PROFILE"},
+	{key: "port", env: "PORT"},
+	{key: "aws_region", env: "AWS_REGION"},
+	{key: "ssm.enabled", env: "SSM_ENABLED"},
+	{key: "reload_interval", env: "CONFIG_RELOAD_INTERVAL"},
+	{key: "log.level", env: "LOG_LEVEL"},
+	{key: "log.format", env: "LOG_FORMAT"},
+	{key: "server.read_timeout", env: "SERVER_READ_TIMEOUT"},
+	{key: "server.write_timeout", env: "SERVER_WRITE_TIMEOUT"},
+	{key: "server.idle_timeout", env: "SERVER_IDLE_TIMEOUT"},
+	{key: "server.shutdown_timeout", env: "SERVER_SHUTDOWN_TIMEOUT"},
+	{key: "server.trusted_proxies", env: "TRUSTED_PROXIES"},
+	{key: "server.drain_delay", env: "SERVER_DRAIN_DELAY"},
+	{key: "providers.exchange_rate_api_key", env: "EXCHANGE_RATE_API_KEY", ssm: "/exchange-rate/api-key"},
+	{key: "providers.exchange_rates_api_key", env: "EXCHANGE_RATES_API_KEY"},
+	{key: "providers.exchange_rate_api_timeout", env: "EXCHANGE_RATE_API_TIMEOUT"},
+	{key: "providers.exchange_rates_api_timeout", env: "EXCHANGE_RATES_API_TIMEOUT"},
+	{key: "providers.exchange_rate_api_rate", env: "EXCHANGE_RATE_API_RATE"},
+	{key: "providers.exchange_rates_api_rate", env: "EXCHANGE_RATES_API_RATE"},
+	{key: "providers.exchange_rate_api_monthly_quota", env: "EXCHANGE_RATE_API_MONTHLY_QUOTA"},
+	{key: "providers.exchange_rates_api_monthly_quota", env: "EXCHANGE_RATES_API_MONTHLY_QUOTA"},
+	{key: "providers.max_retries", env: "PROVIDER_MAX_RETRIES"},
+	{key: "providers.quota_reserve", env: "PROVIDER_QUOTA_RESERVE"},
+	{key: "auth.enabled", env: "AUTH_ENABLED"},
+	{key: "auth.jwt_secret", env: "JWT_SECRET", ssm: "/exchange-rate/jwt-secret"},
+	{key: "auth.token_ttl", env: "AUTH_TOKEN_TTL"},
+	{key: "auth.anonymous_favorites", env: "AUTH_ANONYMOUS_FAVORITES"},
+	{key: "rate_limit.enabled", env: "RATE_LIMIT_ENABLED"},
+	{key: "rate_limit.store", env: "RATE_LIMIT_STORE"},
+	{key: "rate_limit.ip_cheap", env: "RATE_LIMIT_IP_CHEAP"},
+	{key: "rate_limit.ip_expensive", env: "RATE_LIMIT_IP_EXPENSIVE"},
+	{key: "rate_limit.ip_login", env: "RATE_LIMIT_IP_LOGIN"},
+	{key: "rate_limit.key_cheap", env: "RATE_LIMIT_KEY_CHEAP"},
+	{key: "rate_limit.key_expensive", env: "RATE_LIMIT_KEY_EXPENSIVE"},
+	{key: "db.driver", env: "DB_DRIVER"},
+	{key: "db.path", env: "DB_PATH"},
+	{key: "db.host", env: "DB_HOST", ssm: "/exchange-rate/mysql/host"},
+	{key: "db.port", env: "DB_PORT", ssm: "/exchange-rate/mysql/port"},
+	{key: "db.user", env: "DB_USER", ssm: "/exchange-rate/mysql/user"},
+	{key: "db.password", env: "DB_PASSWORD", ssm: "/exchange-rate/mysql/password"},
+	{key: "db.name", env: "DB_NAME"},
+	{key: "db.auto_migrate", env: "DB_AUTO_MIGRATE"},
+	{key: "favorites.store", env: "FAVORITES_STORE"},
+	{key: "favorites.dynamodb_table", env: "DYNAMODB_FAVORITES_TABLE"},
+	{key: "favorites.dynamodb_endpoint", env: "DYNAMODB_ENDPOINT"},
+	{key: "favorites.dynamodb_create_table", env: "DYNAMODB_CREATE_TABLE"},
+	{key: "notifications.sqs_queue_url", env: "SQS_QUEUE_URL"},
+	{key: "notifications.email_from", env: "EMAIL_FROM"},
+	{key: "ingestion.enabled", env: "INGESTION_ENABLED"},
+	{key: "ingestion.time", env: "INGESTION_TIME"},
+	{key: "tracing.exporter", env: "TRACING_EXPORTER"},
+	{key: "tracing.endpoint", env: "OTEL_EXPORTER_OTLP_ENDPOINT"},
+	{key: "tracing.sample_ratio", env: "TRACING_SAMPLE_RATIO"},
+}
+
+// defaults returns the built-in values for a profile
+func defaults(profile string) (map[string]string, error) {
+	values := map[string]string{
+		"profile":                                    profile,
+		"port":                                       "8080",
+		"aws_region":                                 "us-east-1",
+		"ssm.enabled":                                "true",
+		"reload_interval":                            "15m",
+		"log.level":                                  "info",
+		"log.format":                                 "json",
+		"server.read_timeout":                        "15s",
+		"server.write_timeout":                       "60s",
+		"server.idle_timeout":                        "120s",
+		"server.shutdown_timeout":                    "30s",
+		"server.drain_delay":                         "10s",
+		"providers.exchange_rate_api_timeout":        "5s",
+		"providers.exchange_rates_api_timeout":       "10s",
+		"providers.exchange_rate_api_rate":           "5",
+		"providers.exchange_rates_api_rate":          "1",
+		"providers.exchange_rate_api_monthly_quota":  "1500",
+		"providers.exchange_rates_api_monthly_quota": "100",
+		"providers.max_retries":                      "2",
+		"providers.quota_reserve":                    "0.05",
+		"auth.enabled":                               "true",
+		"auth.token_ttl":                             "24h",
+		"auth.anonymous_favorites":                   "false",
+		"rate_limit.enabled":                         "true",
+		"rate_limit.store":                           "memory",
+		"rate_limit.ip_cheap":                        "60",
+		"rate_limit.ip_expensive":                    "10",
+		"rate_limit.ip_login":                        "10",
+		"rate_limit.key_cheap":                       "600",
+		"rate_limit.key_expensive":                   "60",
+		"db.driver":                                  "mysql",
+		"db.path":                                    "joy.db",
+		"db.port":                                    "3306",
+		"db.name":                                    "exchange_db",
+		"db.auto_migrate":                            "true",
+		"favorites.store":                            "sql",
+		"favorites.dynamodb_table":                   "favorites",
+		"favorites.dynamodb_create_table":            "false",
+		"ingestion.enabled":                          "true",
+		"ingestion.time":                             "06:00",
+		"tracing.exporter":                           "none",
+		"tracing.sample_ratio":                       "1",
+	}
+
+	switch profile {
+	case ProfileAWS:
+	case ProfileLocal:
+		values["ssm.enabled"] = "false"
+		values["db.driver"] = "sqlite"
+		values["log.format"] = "text"
+		values["auth.enabled"] = "false"
+		values["auth.anonymous_favorites"] = "true"
+		values["server.drain_delay"] = "0s"
+		values["ingestion.enabled"] = "false"
+	default:
+		return nil, fmt.Errorf("unknown profile %q (use %s or %s)", profile, ProfileAWS, ProfileLocal)
+	}
+
+	return values, nil
+}
+
+// readFile loads the YAML or TOML file named by CONFIG_FILE, if any
+func readFile() (map[string]string, error) {
+	path := os.Getenv("CONFIG_FILE")
+	if path == "" {
+		return map[string]string{}, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config file: %w", err)
+	}
+
+	raw := map[string]any{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &raw)
+	case ".toml":
+		err = toml.Unmarshal(content, &raw)
+	default:
+		return nil, fmt.Errorf("unsupported config file %s (use .yaml, .yml or .toml)", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+
+	values := map[string]string{}
+	flatten("", raw, values)
+
+	known := map[string]bool{}
+	for _, s := range settings {
+		known[s.key] = true
+	}
+	for key := range values {
+		if !known[key] {
+			return nil, fmt.Errorf("unknown key %q in config file %s", key, path)
+		}
+	}
+
+	return values, nil
+}
+
+// flatten turns nested sections into dotted keys
+func flatten(prefix string, raw map[string]any, values map[string]string) {
+	for key, value := range raw {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if nested, ok := value.(map[string]any); ok {
+			flatten(key, nested, values)
+			continue
+		}
+		values[key] = fmt.Sprint(value)
+	}
+}
+
+// readEnv collects the settings present in the environment
+func readEnv() map[string]string {
+	values := map[string]string{}
+	for _, s := range settings {
+		if value, ok := os.LookupEnv(s.env); ok && value != "" {
+			values[s.key] = value
+		}
+	}
+	return values
+}
+
+// readSSM fetches the secret settings from AWS Parameter Store
+func readSSM(ctx context.Context, region string) (map[string]string, error) {
+	// Load AWS default configuration (uses EC2 IAM role automatically)
+	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	keysByName := map[string]string{}
+	var names []string
+	for _, s := range settings {
+		if s.ssm != "" {
+			keysByName[s.ssm] = s.key
+			names = append(names, s.ssm)
+		}
+	}
+
+	client := ssm.NewFromConfig(cfg)
+	out, err := client.GetParameters(ctx, &ssm.GetParametersInput{
+		Names:          names,
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fetching parameters from SSM: %w", err)
+	}
+
+	// Parameters missing from SSM are reported by validation like any other missing key
+	values := map[string]string{}
+	for _, p := range out.Parameters {
+		values[keysByName[*p.Name]] = *p.Value
+	}
+
+	return values, nil
+}
@@ -0,0 +1,259 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ValidationError lists every problem found in the merged configuration
+type ValidationError struct {
+	Missing []string
+	Invalid []string
+}
+
+func (e *ValidationError) Error() string {
+	var parts []string
+	if len(e.Missing) > 0 {
+		parts = append(parts, "missing: "+strings.Join(e.Missing, ", "))
+	}
+	if len(e.Invalid) > 0 {
+		parts = append(parts, "invalid: "+strings.Join(e.Invalid, ", "))
+	}
+	return "invalid configuration: " + strings.Join(parts, "; ")
+}
+
+// validator accumulates problems while reading values
+type validator struct {
+	values map[string]string
+	err    ValidationError
+}
+
+// describe names a key together with its environment variable
+func describe(key string) string {
+	for _, s := range settings {
+		if s.key == key && s.env != "" {
+			return fmt.Sprintf("%s (%s)", key, s.env)
+		}
+	}
+	return key
+}
+
+func (v *validator) required(key string) string {
+	value := v.values[key]
+	if value == "" {
+		v.err.Missing = append(v.err.Missing, describe(key))
+	}
+	return value
+}
+
+func (v *validator) oneOf(key string, allowed ...string) string {
+	value := v.values[key]
+	for _, a := range allowed {
+		if value == a {
+			return value
+		}
+	}
+	v.err.Invalid = append(v.err.Invalid, fmt.Sprintf("%s=%q must be one of %s", describe(key), value, strings.Join(allowed, ", ")))
+	return value
+}
+
+func (v *validator) boolean(key string) bool {
+	value, err := strconv.ParseBool(v.values[key])
+	if err != nil {
+		v.err.Invalid = append(v.err.Invalid, fmt.Sprintf("%s=%q must be true or false", describe(key), v.values[key]))
+	}
+	return value
+}
+
+func (v *validator) duration(key string) time.Duration {
+	value, err := time.ParseDuration(v.values[key])
+	if err != nil || value < 0 {
+		v.err.Invalid = append(v.err.Invalid, fmt.Sprintf("%s=%q must be a duration such as 30s or 15m", describe(key), v.values[key]))
+	}
+	return value
+}
+
+func (v *validator) timeOfDay(key string) time.Duration {
+	value, err := time.Parse("15:04", v.values[key])
+	if err != nil {
+		v.err.Invalid = append(v.err.Invalid, fmt.Sprintf("%s=%q must be a time of day such as 06:00", describe(key), v.values[key]))
+	}
+	return time.Duration(value.Hour())*time.Hour + time.Duration(value.Minute())*time.Minute
+}
+
+// prefixes reads a comma separated list of CIDRs or single addresses
+func (v *validator) prefixes(key string) []netip.Prefix {
+	var prefixes []netip.Prefix
+	for _, value := range strings.Split(v.values[key], ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(value)
+			if addrErr != nil {
+				v.err.Invalid = append(v.err.Invalid, fmt.Sprintf("%s=%q must be a list of CIDRs or addresses", describe(key), value))
+				continue
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes
+}
+
+func (v *validator) positive(key string) float64 {
+	value, err := strconv.ParseFloat(v.values[key], 64)
+	if err != nil || value <= 0 {
+		v.err.Invalid = append(v.err.Invalid, fmt.Sprintf("%s=%q must be a number greater than 0", describe(key), v.values[key]))
+	}
+	return value
+}
+
+func (v *validator) ratio(key string) float64 {
+	value, err := strconv.ParseFloat(v.values[key], 64)
+	if err != nil || value < 0 || value > 1 {
+		v.err.Invalid = append(v.err.Invalid, fmt.Sprintf("%s=%q must be a number between 0 and 1", describe(key), v.values[key]))
+	}
+	return value
+}
+
+func (v *validator) integer(key string, min, max int) int {
+	value, err := strconv.Atoi(v.values[key])
+	if err != nil || value < min || value > max {
+		v.err.Invalid = append(v.err.Invalid, fmt.Sprintf("%s=%q must be a number between %d and %d", describe(key), v.values[key], min, max))
+	}
+	return value
+}
+
+func (v *validator) port(key string) string {
+	value := v.required(key)
+	if value == "" {
+		return value
+	}
+	if n, err := strconv.Atoi(value); err != nil || n <= 0 || n > 65535 {
+		v.err.Invalid = append(v.err.Invalid, fmt.Sprintf("%s=%q must be a port number", describe(key), value))
+	}
+	return value
+}
+
+// build converts the merged values into a Config, reporting every missing or invalid key at once
+func build(values map[string]string, requireProviders bool) (*Config, error) {
+	v := &validator{values: values}
+
+	cfg := &Config{
+		Profile:   values["profile"],
+		Port:      v.port("port"),
+		AWSRegion: v.required("aws_region"),
+		SSM:       v.boolean("ssm.enabled"),
+
+		ReloadInterval: v.duration("reload_interval"),
+	}
+
+	if requireProviders {
+		cfg.KyeEchangeRateAPI = v.required("providers.exchange_rate_api_key")
+		cfg.KyeEchangeRatesAPI = v.required("providers.exchange_rates_api_key")
+	}
+
+	cfg.Log = LogConfig{
+		Level:  v.oneOf("log.level", "debug", "info", "warn", "error"),
+		Format: v.oneOf("log.format", "json", "text"),
+	}
+
+	cfg.Server = ServerConfig{
+		ReadTimeout:     v.duration("server.read_timeout"),
+		WriteTimeout:    v.duration("server.write_timeout"),
+		IdleTimeout:     v.duration("server.idle_timeout"),
+		ShutdownTimeout: v.duration("server.shutdown_timeout"),
+		DrainDelay:      v.duration("server.drain_delay"),
+		TrustedProxies:  v.prefixes("server.trusted_proxies"),
+	}
+
+	cfg.Providers = ProvidersConfig{
+		ExchangeRateAPI: ProviderConfig{
+			Timeout:      v.duration("providers.exchange_rate_api_timeout"),
+			Rate:         v.positive("providers.exchange_rate_api_rate"),
+			MonthlyQuota: v.integer("providers.exchange_rate_api_monthly_quota", 0, math.MaxInt32),
+		},
+		ExchangeRatesAPI: ProviderConfig{
+			Timeout:      v.duration("providers.exchange_rates_api_timeout"),
+			Rate:         v.positive("providers.exchange_rates_api_rate"),
+			MonthlyQuota: v.integer("providers.exchange_rates_api_monthly_quota", 0, math.MaxInt32),
+		},
+		MaxRetries:   v.integer("providers.max_retries", 0, 10),
+		QuotaReserve: v.ratio("providers.quota_reserve"),
+	}
+
+	cfg.Auth = AuthConfig{
+		Enabled:   v.boolean("auth.enabled"),
+		JWTSecret: values["auth.jwt_secret"],
+		TokenTTL:  v.duration("auth.token_ttl"),
+
+		AnonymousFavorites: v.boolean("auth.anonymous_favorites"),
+	}
+	// Tokens signed with a per-process secret stop working on restart and
+	// aren't shared between instances, so a real secret is required with auth
+	if requireProviders && cfg.Auth.Enabled {
+		cfg.Auth.JWTSecret = v.required("auth.jwt_secret")
+	}
+
+	cfg.RateLimit = RateLimitConfig{
+		Enabled:      v.boolean("rate_limit.enabled"),
+		Store:        v.oneOf("rate_limit.store", "memory", "sql"),
+		IPCheap:      v.integer("rate_limit.ip_cheap", 0, math.MaxInt32),
+		IPExpensive:  v.integer("rate_limit.ip_expensive", 0, math.MaxInt32),
+		IPLogin:      v.integer("rate_limit.ip_login", 0, math.MaxInt32),
+		KeyCheap:     v.integer("rate_limit.key_cheap", 0, math.MaxInt32),
+		KeyExpensive: v.integer("rate_limit.key_expensive", 0, math.MaxInt32),
+	}
+
+	cfg.Database = DatabaseConfig{
+		Driver:      v.oneOf("db.driver", "mysql", "sqlite"),
+		AutoMigrate: v.boolean("db.auto_migrate"),
+	}
+	switch cfg.Database.Driver {
+	case "mysql":
+		cfg.Database.Host = v.required("db.host")
+		cfg.Database.Port = v.port("db.port")
+		cfg.Database.User = v.required("db.user")
+		cfg.Database.Password = v.required("db.password")
+		cfg.Database.Name = v.required("db.name")
+	case "sqlite":
+		cfg.Database.Path = v.required("db.path")
+	}
+
+	cfg.Favorites = FavoritesConfig{
+		Store:               v.oneOf("favorites.store", "sql", "dynamodb"),
+		DynamoDBEndpoint:    values["favorites.dynamodb_endpoint"],
+		DynamoDBCreateTable: v.boolean("favorites.dynamodb_create_table"),
+	}
+	if cfg.Favorites.Store == "dynamodb" {
+		cfg.Favorites.DynamoDBTable = v.required("favorites.dynamodb_table")
+	}
+
+	cfg.Notifications = NotificationsConfig{
+		SQSQueueURL: values["notifications.sqs_queue_url"],
+		EmailFrom:   values["notifications.email_from"],
+	}
+
+	cfg.Ingestion = IngestionConfig{
+		Enabled: v.boolean("ingestion.enabled"),
+		At:      v.timeOfDay("ingestion.time"),
+	}
+
+	cfg.Tracing = TracingConfig{
+		Exporter:    v.oneOf("tracing.exporter", "none", "otlp"),
+		Endpoint:    values["tracing.endpoint"],
+		SampleRatio: v.ratio("tracing.sample_ratio"),
+	}
+
+	if len(v.err.Missing) > 0 || len(v.err.Invalid) > 0 {
+		return nil, &v.err
+	}
+	return cfg, nil
+}
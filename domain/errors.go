@@ -0,0 +1,17 @@
+package domain
+
+import "errors"
+
+var (
+	// ErrNotFound is returned when a requested entity does not exist
+	ErrNotFound = errors.New("not found")
+
+	// ErrInvalidInput is returned when a request fails validation, its message is safe to show
+	ErrInvalidInput = errors.New("invalid input")
+
+	// ErrAlreadyExists is returned when an entity conflicts with an existing one
+	ErrAlreadyExists = errors.New("already exists")
+
+	// ErrQuotaExhausted is returned when a provider's monthly quota is used up
+	ErrQuotaExhausted = errors.New("provider quota exhausted")
+)
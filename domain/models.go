@@ -28,12 +28,25 @@ type ConversionResponse struct {
 	RatesSource     string    `json:"rates_source"`
 }
 
+// History intervals and gap filling methods
+const (
+	IntervalDay   = "day"
+	IntervalWeek  = "week"
+	IntervalMonth = "month"
+
+	FillNone        = "none"
+	FillPrevious    = "previous"
+	FillInterpolate = "interpolate"
+)
+
 // HistoryRequest represents the request for historical data
 type HistoryRequest struct {
-	Origin     string `json:"origin" binding:"required"`
-	Destination string `json:"destination" binding:"required"`
-	StartDate  string `json:"start_date" binding:"required"`
-	EndDate    string `json:"end_date" binding:"required"`
+	Origin      string    `json:"origin" binding:"required"`
+	Destination string    `json:"destination" binding:"required"`
+	StartDate   time.Time `json:"start_date" binding:"required"`
+	EndDate     time.Time `json:"end_date" binding:"required"`
+	Interval    string    `json:"interval"` // empty means IntervalDay
+	Fill        string    `json:"fill"`     // empty means FillNone
 }
 
 // HistoryRate represents a single historical rate entry
@@ -44,36 +57,221 @@ type HistoryRate struct {
 
 // HistoryResponse represents the response for historical data
 type HistoryResponse struct {
-	Origin      Currency      `json:"origin"`
-	Destination Currency      `json:"destination"`
-	StartDate   string        `json:"start_date"`
-	EndDate     string        `json:"end_date"`
-	Rates       []HistoryRate `json:"rates"`
-	Timestamp   time.Time     `json:"timestamp"`
-	RatesSource string        `json:"rates_source"`
-	Message string `json:"temp_message"`
+	Origin      Currency       `json:"origin"`
+	Destination Currency       `json:"destination"`
+	StartDate   string         `json:"start_date"`
+	EndDate     string         `json:"end_date"`
+	Interval    string         `json:"interval"`
+	Fill        string         `json:"fill"`
+	Rates       []HistoryPoint `json:"rates"`
+	Timestamp   time.Time      `json:"timestamp"`
+	RatesSource string         `json:"rates_source"`
+	Message     string         `json:"temp_message"`
+}
+
+// HistoryPoint is one row of a history: a day, or the OHLC of a week or month.
+// Weeks start on Monday and are labelled, like months, with their first day.
+type HistoryPoint struct {
+	Date    string   `json:"date"`
+	Rate    *float64 `json:"rate"` // the close for weeks and months, null when missing
+	Open    *float64 `json:"open,omitempty"`
+	High    *float64 `json:"high,omitempty"`
+	Low     *float64 `json:"low,omitempty"`
+	Close   *float64 `json:"close,omitempty"`
+	Days    int      `json:"days,omitempty"`    // days of a week or month with a published rate
+	Missing bool     `json:"missing,omitempty"` // no rate was published, nor filled in
+	Filled  bool     `json:"filled,omitempty"`  // the rate, or one of the period, was filled in
 }
 
 // ForecastRequest represents the request for forecast
 type ForecastRequest struct {
 	Origin      string `json:"origin" binding:"required"`
 	Destination string `json:"destination" binding:"required"`
+	Model       string  `json:"model"`     // empty uses the default model
+	Threshold   float64 `json:"threshold"` // zero skips the exceedance probability
+	Horizon     int     `json:"horizon"`   // publishing days ahead, zero means 1
+	Lookback    int     `json:"lookback"`  // publishing days fitted on, zero uses the default
 }
 
 // ForecastResponse represents the response for forecast
 type ForecastResponse struct {
-	Origin        Currency  `json:"origin"`
-	Destination   Currency  `json:"destination"`
-	PredictedDate string    `json:"predicted_date"`
-	PredictedRate float64   `json:"predicted_rate"`
-	Confidence    float64   `json:"confidence"`
-	Last30Days    struct {
-		Average float64 `json:"average"`
-	} `json:"last_30_days"`
+	Origin        Currency `json:"origin"`
+	Destination   Currency `json:"destination"`
+	PredictedDate string   `json:"predicted_date"`
+	PredictedRate float64  `json:"predicted_rate"`
+	Model         string   `json:"model"`
+
+	// Parameters are the values the model estimated from the history, e.g. slope or alpha
+	Parameters map[string]float64 `json:"parameters"`
+
+	// StdError is the standard deviation of the prediction error, estimated from the
+	// model residuals or the log return volatility as told by IntervalMethod
+	StdError       float64              `json:"std_error"`
+	IntervalMethod string               `json:"interval_method"`
+	Intervals      []PredictionInterval `json:"intervals"`
+	Exceedance     *Exceedance          `json:"exceedance,omitempty"`
+
+	// Points holds one prediction per day up to Horizon, the fields above repeat the first
+	Horizon int             `json:"horizon"`
+	Points  []ForecastPoint `json:"points"`
+
+	// Window describes the historical rates the model was fitted on
+	Window WindowStats `json:"window"`
+
+	Timestamp   time.Time `json:"timestamp"`
+	RatesSource string    `json:"rates_source"`
+}
+
+// BacktestRequest represents the request to replay a forecast model over stored history
+type BacktestRequest struct {
+	Origin      string    `json:"origin" binding:"required"`
+	Destination string    `json:"destination" binding:"required"`
+	Model       string    `json:"model"` // empty uses the default model
+	From        time.Time `json:"from" binding:"required"`
+	To          time.Time `json:"to" binding:"required"`
+	Window      int       `json:"window"` // days each prediction is fitted on, zero uses the forecast default
+}
+
+// BacktestResponse represents the accuracy of a model over a date range
+type BacktestResponse struct {
+	Origin      Currency `json:"origin"`
+	Destination Currency `json:"destination"`
+	Model       string   `json:"model"`
+	From        string   `json:"from"`
+	To          string   `json:"to"`
+	Window      int      `json:"window"`
+	Forecasts   int      `json:"forecasts"`
+
+	// Metrics of the model and of the naive "tomorrow = today" forecast
+	Metrics  ForecastMetrics `json:"metrics"`
+	Baseline ForecastMetrics `json:"baseline"`
+
+	// Skill is 1 - RMSE/baseline RMSE: positive when the model beats the naive forecast
+	Skill *float64 `json:"skill,omitempty"`
+
 	Timestamp   time.Time `json:"timestamp"`
 	RatesSource string    `json:"rates_source"`
 }
 
+// ForecastMetrics measures one-day-ahead predictions against the actual rates
+type ForecastMetrics struct {
+	MAE                 float64            `json:"mae"`
+	RMSE                float64            `json:"rmse"`
+	MAPE                float64            `json:"mape"`
+	DirectionalAccuracy *float64           `json:"directional_accuracy,omitempty"`
+	Coverage            []IntervalCoverage `json:"coverage,omitempty"`
+}
+
+// IntervalCoverage is the share of actual rates that fell inside the prediction interval of Level
+type IntervalCoverage struct {
+	Level    float64 `json:"level"`
+	Coverage float64 `json:"coverage"`
+}
+
+// AnalyticsRequest represents the request for indicators computed over stored rates
+type AnalyticsRequest struct {
+	Origin      string
+	Destination string
+	StartDate   time.Time
+	EndDate     time.Time
+	Window      int // publishing days of the moving averages and volatility, zero uses the default
+}
+
+// AnalyticsResponse represents the indicators of a currency pair over a date range
+type AnalyticsResponse struct {
+	Origin      Currency         `json:"origin"`
+	Destination Currency         `json:"destination"`
+	StartDate   string           `json:"start_date"`
+	EndDate     string           `json:"end_date"`
+	Window      int              `json:"window"`
+	Summary     AnalyticsSummary `json:"summary"`
+	Points      []AnalyticsPoint `json:"points"`
+	Timestamp   time.Time        `json:"timestamp"`
+	RatesSource string           `json:"rates_source"`
+}
+
+// AnalyticsSummary describes the whole range. PercentChange and MaxDrawdown
+// are percentages, Volatility is the standard deviation of the daily log returns.
+type AnalyticsSummary struct {
+	Count         int         `json:"count"`
+	StartRate     float64     `json:"start_rate"`
+	EndRate       float64     `json:"end_rate"`
+	PercentChange float64     `json:"percent_change"`
+	Min           HistoryRate `json:"min"`
+	Max           HistoryRate `json:"max"`
+	MaxDrawdown   Drawdown    `json:"max_drawdown"`
+	Volatility    float64     `json:"volatility"`
+}
+
+// Drawdown is the largest fall from a peak to a later trough
+type Drawdown struct {
+	Percent    float64 `json:"percent"`
+	PeakDate   string  `json:"peak_date,omitempty"`
+	TroughDate string  `json:"trough_date,omitempty"`
+}
+
+// AnalyticsPoint holds the indicators of one publishing day, null until
+// enough days precede it
+type AnalyticsPoint struct {
+	Date       string   `json:"date"`
+	Rate       float64  `json:"rate"`
+	LogReturn  *float64 `json:"log_return"`
+	Volatility *float64 `json:"volatility"`
+	SMA        *float64 `json:"sma"`
+	EMA        *float64 `json:"ema"`
+}
+
+// WindowStats describes the rates of a lookback window. Count may be lower
+// than Lookback when the rate store misses days.
+type WindowStats struct {
+	Lookback int     `json:"lookback"`
+	Start    string  `json:"start_date"`
+	End      string  `json:"end_date"`
+	Count    int     `json:"count"`
+	Mean     float64 `json:"mean"`
+	StdDev   float64 `json:"stddev"`
+	Min      float64 `json:"min"`
+	Max      float64 `json:"max"`
+}
+
+// ForecastPoint is the prediction for one day of a multi-day forecast
+type ForecastPoint struct {
+	Date          string               `json:"date"`
+	PredictedRate float64              `json:"predicted_rate"`
+	StdError      float64              `json:"std_error"`
+	Intervals     []PredictionInterval `json:"intervals"`
+	Exceedance    *Exceedance          `json:"exceedance,omitempty"`
+}
+
+// PredictionInterval bounds the predicted rate with probability Level
+type PredictionInterval struct {
+	Level float64 `json:"level"`
+	Lower float64 `json:"lower"`
+	Upper float64 `json:"upper"`
+}
+
+// Exceedance is the probability that the predicted rate is above Threshold
+type Exceedance struct {
+	Threshold   float64 `json:"threshold"`
+	Probability float64 `json:"probability"`
+}
+
+// StoredForecast is a forecast kept in the forecast store, keyed by pair,
+// model, horizon and lookback. It is fresh while the model version, the last
+// day of history and the hash of the input rates still match.
+type StoredForecast struct {
+	Origin       string
+	Destination  string
+	Model        string
+	Horizon      int
+	Lookback     int
+	ModelVersion string
+	InputsHash   string
+	EndDate      string // last day of history the forecast was computed for
+	Response     ForecastResponse
+	ComputedAt   time.Time
+}
+
 // DestinationsResponse represents the response for available destinations
 type DestinationsResponse struct {
 	Origin      Currency   `json:"origin"`
@@ -88,6 +286,9 @@ type FavoriteRequest struct {
 	Destination string  `json:"destination" binding:"required"`
 	Threshold   float64 `json:"threshold" binding:"required,gt=0"`
 	NotifyEmail string  `json:"notify_email" binding:"required,email"`
+
+	// OwnerID is the user saving the favorite, set from the session rather than the body
+	OwnerID string `json:"-"`
 }
 
 // Favorite represents a saved favorite conversion
@@ -97,9 +298,86 @@ type Favorite struct {
 	Destination Currency  `json:"destination"`
 	Threshold   float64   `json:"threshold"`
 	NotifyEmail string    `json:"notify_email"`
+	OwnerID     string    `json:"owner_id,omitempty"`
 	CreatedAt   time.Time `json:"created_at"`
 }
 
+// UniqueKey identifies duplicate favorites: the same pair for the same owner,
+// or for the same notify_email when the favorite has no owner
+func (f *Favorite) UniqueKey() string {
+	who := "owner:" + f.OwnerID
+	if f.OwnerID == "" {
+		who = "email:" + f.NotifyEmail
+	}
+	return who + "|" + f.Origin.Code + "|" + f.Destination.Code
+}
+
+// User roles, admins see and manage every favorite
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// User represents a registered account owning favorites
+type User struct {
+	ID           string    `json:"id"`
+	Email        string    `json:"email"`
+	PasswordHash string    `json:"-"`
+	Role         string    `json:"role"`
+	CreatedAt    time.Time `json:"created_at"`
+
+	// EmailVerifiedAt is when the user followed the verification link, nil until then
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+}
+
+// AlertState represents the last known threshold state of a favorite
+type AlertState struct {
+	FavoriteID     string     `json:"favorite_id"`
+	Exceeded       bool       `json:"exceeded"`
+	LastRate       float64    `json:"last_rate"`
+	LastCheckedAt  time.Time  `json:"last_checked_at"`
+	LastNotifiedAt *time.Time `json:"last_notified_at,omitempty"`
+}
+
+// API key scopes, ScopeAdmin grants every other scope
+const (
+	ScopeRatesRead         = "rates:read"
+	ScopeFavorites         = "favorites"
+	ScopeNotificationsSend = "notifications:send"
+	ScopeAdmin             = "admin"
+)
+
+// Scopes lists every valid API key scope
+var Scopes = []string{ScopeRatesRead, ScopeFavorites, ScopeNotificationsSend, ScopeAdmin}
+
+// APIKey represents a credential for calling the API. Only the hash of the
+// key is stored, the key itself is shown once when it is created.
+type APIKey struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Prefix    string     `json:"prefix"`
+	Hash      string     `json:"-"`
+	Scopes    []string   `json:"scopes"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// HasScope reports whether the key grants scope
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// RateLimitBucket is the token bucket of one client of the API
+type RateLimitBucket struct {
+	Tokens    float64
+	UpdatedAt time.Time
+}
+
 // FavoriteCheckResult represents the result of checking a favorite
 type FavoriteCheckResult struct {
 	FavoriteID        string    `json:"favorite_id"`
@@ -119,6 +397,21 @@ type FavoriteCheckResponse struct {
 	Timestamp time.Time             `json:"timestamp"`
 }
 
+// IngestionResult represents the ingestion of one currency pair
+type IngestionResult struct {
+	Origin      string `json:"origin"`
+	Destination string `json:"destination"`
+	Rates       int    `json:"rates"`     // days of rates fetched or already stored
+	Forecasts   int    `json:"forecasts"` // models with a fresh forecast stored
+	Error       string `json:"error,omitempty"`
+}
+
+// IngestionResponse represents the response of a rate ingestion run
+type IngestionResponse struct {
+	Results   []IngestionResult `json:"results"`
+	Timestamp time.Time         `json:"timestamp"`
+}
+
 // NotificationRequest represents the request to send a notification
 type NotificationRequest struct {
 	FavoriteID  string    `json:"favorite_id" binding:"required"`
@@ -0,0 +1,110 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// FavoriteRepository defines the storage operations for favorites
+type FavoriteRepository interface {
+	// Create stores a new favorite, returning ErrAlreadyExists if the ID or the
+	// UniqueKey of the favorite is taken, checked atomically with the write
+	Create(ctx context.Context, favorite *Favorite) error
+
+	// Get returns the favorite with the given ID, or ErrNotFound
+	Get(ctx context.Context, id string) (*Favorite, error)
+
+	// List returns all stored favorites ordered by creation time
+	List(ctx context.Context) ([]Favorite, error)
+
+	// ListByEmail returns the favorites notifying the given email ordered by creation time
+	ListByEmail(ctx context.Context, email string) ([]Favorite, error)
+
+	// ListByOwner returns the favorites of the given user ordered by creation time
+	ListByOwner(ctx context.Context, ownerID string) ([]Favorite, error)
+
+	// Delete removes the favorite with the given ID, or returns ErrNotFound
+	Delete(ctx context.Context, id string) error
+}
+
+// RateRepository defines the storage operations for daily exchange rates
+type RateRepository interface {
+	// SaveRates stores daily rates for a currency pair, replacing any existing rate for the same date
+	SaveRates(ctx context.Context, origin, destination, source string, rates []HistoryRate) error
+
+	// GetRates returns the stored daily rates for a currency pair within a date range, ordered by date
+	GetRates(ctx context.Context, origin, destination string, startDate, endDate time.Time) ([]HistoryRate, error)
+}
+
+// ForecastRepository defines the storage operations for precomputed forecasts
+type ForecastRepository interface {
+	// Get returns the stored forecast for a pair, model, horizon and lookback, or ErrNotFound
+	Get(ctx context.Context, origin, destination, model string, horizon, lookback int) (*StoredForecast, error)
+
+	// Save stores a forecast, replacing any existing one with the same pair, model, horizon and lookback
+	Save(ctx context.Context, forecast *StoredForecast) error
+}
+
+// AlertStateRepository defines the storage operations for favorite alert states
+type AlertStateRepository interface {
+	// Get returns the alert state of a favorite, or ErrNotFound if it was never checked
+	Get(ctx context.Context, favoriteID string) (*AlertState, error)
+
+	// Save stores the alert state of a favorite, replacing any previous state
+	Save(ctx context.Context, state *AlertState) error
+
+	// Delete removes the alert state of a favorite, if it was ever checked
+	Delete(ctx context.Context, favoriteID string) error
+}
+
+// ProviderUsageRepository defines the storage operations for provider call counters
+type ProviderUsageRepository interface {
+	// GetCalls returns the calls made to a provider in a period (YYYY-MM), zero if none were recorded
+	GetCalls(ctx context.Context, provider, period string) (int, error)
+
+	// AddCalls adds calls to the counter of a provider in a period and returns the new total
+	AddCalls(ctx context.Context, provider, period string, calls int) (int, error)
+}
+
+// RateLimitRepository defines the storage of the inbound rate limit buckets
+type RateLimitRepository interface {
+	// Update atomically replaces the bucket stored under key with the one returned by fn,
+	// which receives nil when there is no bucket yet
+	Update(ctx context.Context, key string, fn func(bucket *RateLimitBucket) RateLimitBucket) error
+
+	// DeleteBefore removes the buckets not updated since t, returning how many were removed
+	DeleteBefore(ctx context.Context, t time.Time) (int64, error)
+}
+
+// APIKeyRepository defines the storage operations for API keys
+type APIKeyRepository interface {
+	// Create stores a new API key, returning ErrAlreadyExists if the ID or hash is taken
+	Create(ctx context.Context, key *APIKey) error
+
+	// GetByHash returns the API key with the given hash, or ErrNotFound
+	GetByHash(ctx context.Context, hash string) (*APIKey, error)
+
+	// List returns all API keys, revoked ones included, ordered by creation time
+	List(ctx context.Context) ([]APIKey, error)
+
+	// Revoke marks the API key with the given ID as revoked, or returns ErrNotFound
+	Revoke(ctx context.Context, id string, at time.Time) error
+}
+
+// UserRepository defines the storage operations for user accounts
+type UserRepository interface {
+	// Create stores a new user, returning ErrAlreadyExists if the ID or email is taken
+	Create(ctx context.Context, user *User) error
+
+	// Get returns the user with the given ID, or ErrNotFound
+	Get(ctx context.Context, id string) (*User, error)
+
+	// GetByEmail returns the user with the given email, or ErrNotFound
+	GetByEmail(ctx context.Context, email string) (*User, error)
+
+	// SetRole changes the role of the user with the given ID, or returns ErrNotFound
+	SetRole(ctx context.Context, id, role string) error
+
+	// SetEmailVerified records when the user with the given ID verified their email, or returns ErrNotFound
+	SetEmailVerified(ctx context.Context, id string, at time.Time) error
+}
@@ -17,9 +17,19 @@ type CurrencyService interface {
 	
 	// GetHistoricalRates returns historical exchange rates for a date range
 	GetHistoricalRates(ctx context.Context, origin, destination string, startDate, endDate time.Time) ([]HistoryRate, string, error)
+
+	// GetHistory returns one row per day of a date range with the missing days flagged or
+	// filled in, or the OHLC of every week or month
+	GetHistory(ctx context.Context, req *HistoryRequest) (*HistoryResponse, error)
+	
+	// GetForecast returns a forecast for the next day's exchange rate using the requested model
+	GetForecast(ctx context.Context, req *ForecastRequest) (*ForecastResponse, error)
+
+	// BacktestForecast replays a forecast model over the stored rates of a date range
+	BacktestForecast(ctx context.Context, req *BacktestRequest) (*BacktestResponse, error)
 	
-	// GetForecast returns a forecast for the next day's exchange rate
-	GetForecast(ctx context.Context, origin, destination string) (*ForecastResponse, error)
+	// GetAnalytics computes returns, volatility and moving averages over the stored rates of a date range
+	GetAnalytics(ctx context.Context, req *AnalyticsRequest) (*AnalyticsResponse, error)
 	
 	// GetSupportedDestinations returns supported destination currencies for an origin
 	GetSupportedDestinations(ctx context.Context, origin string) ([]Currency, string, error)
@@ -35,13 +45,33 @@ type FavoriteService interface {
 	
 	// GetAllFavorites returns all saved favorites
 	GetAllFavorites(ctx context.Context) ([]Favorite, error)
+
+	// ListFavorites returns the favorites of a user, or all of them when ownerID is empty
+	ListFavorites(ctx context.Context, ownerID string) ([]Favorite, error)
+
+	// GetFavorite returns a favorite, or ErrNotFound when it doesn't exist or belongs to
+	// another user. An empty ownerID matches any favorite.
+	GetFavorite(ctx context.Context, id, ownerID string) (*Favorite, error)
+
+	// DeleteFavorite removes a favorite with the same ownership rules as GetFavorite
+	DeleteFavorite(ctx context.Context, id, ownerID string) error
 	
 	// CheckFavorites checks all favorites against current rates
 	CheckFavorites(ctx context.Context) (*FavoriteCheckResponse, error)
 }
 
+// IngestionService defines the interface for the daily rate ingestion
+type IngestionService interface {
+	// IngestRates stores the latest rates of the pairs referenced by favorites
+	// and precomputes their forecasts
+	IngestRates(ctx context.Context) (*IngestionResponse, error)
+}
+
 // NotificationService defines the interface for notification operations
 type NotificationService interface {
 	// SendEmailNotification sends an email notification
 	SendEmailNotification(ctx context.Context, req *NotificationRequest) (*NotificationResponse, error)
+
+	// SendVerificationEmail sends the token verifying the email of a new account
+	SendVerificationEmail(ctx context.Context, email, token string) error
 }
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/joy-currency-conversion-private/domain"
+	"github.com/joy-currency-conversion-private/infrastructure/auth"
+	"github.com/joy-currency-conversion-private/infrastructure/upstream"
+)
+
+// AdminHandler serves operational endpoints for administrators
+type AdminHandler struct {
+	upstream *upstream.Client
+	keys     *auth.Keys
+}
+
+// NewAdminHandler creates a new AdminHandler
+func NewAdminHandler(upstreamClient *upstream.Client, keys *auth.Keys) *AdminHandler {
+	return &AdminHandler{
+		upstream: upstreamClient,
+		keys:     keys,
+	}
+}
+
+// CreateAPIKeyRequest represents the request body to create an API key
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+// CreateAPIKey issues a new API key, the only response that contains the key itself
+// POST /api/v1/admin/api-keys
+func (h *AdminHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req CreateAPIKeyRequest
+	if err := BindJSON(r, &req); err != nil {
+		JSONError(w, http.StatusBadRequest, "Invalid request body", "INVALID_REQUEST")
+		return
+	}
+
+	key, plain, err := h.keys.Issue(r.Context(), req.Name, req.Scopes)
+	if err != nil {
+		JSONError(w, http.StatusBadRequest, fmt.Sprintf("Unable to create API key: %s", err.Error()), "INVALID_API_KEY")
+		return
+	}
+
+	JSONResponse(w, http.StatusCreated, map[string]interface{}{
+		"api_key": key,
+		"key":     plain,
+	})
+}
+
+// ListAPIKeys lists every API key without the keys themselves
+// GET /api/v1/admin/api-keys
+func (h *AdminHandler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.keys.List(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "listing api keys failed", "error", err)
+		JSONError(w, http.StatusInternalServerError, "Failed to list API keys", "LIST_FAILED")
+		return
+	}
+
+	JSONResponse(w, http.StatusOK, map[string]interface{}{
+		"api_keys": keys,
+	})
+}
+
+// RevokeAPIKey revokes an API key, requests using it are rejected from then on
+// DELETE /api/v1/admin/api-keys/{id}
+func (h *AdminHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	err := h.keys.Revoke(r.Context(), chi.URLParam(r, "id"))
+	if errors.Is(err, domain.ErrNotFound) {
+		JSONError(w, http.StatusNotFound, "API key not found or already revoked", "API_KEY_NOT_FOUND")
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "revoking api key failed", "error", err)
+		JSONError(w, http.StatusInternalServerError, "Failed to revoke API key", "REVOKE_FAILED")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Providers reports the monthly quota usage and rate limits of the rate providers
+// GET /api/v1/admin/providers
+func (h *AdminHandler) Providers(w http.ResponseWriter, r *http.Request) {
+	usages, err := h.upstream.Usage(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "reading provider usage failed", "error", err)
+		JSONError(w, http.StatusInternalServerError, "Failed to read provider usage", "USAGE_UNAVAILABLE")
+		return
+	}
+
+	JSONResponse(w, http.StatusOK, map[string]interface{}{
+		"providers": usages,
+		"timestamp": time.Now().UTC(),
+	})
+}
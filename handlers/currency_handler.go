@@ -1,25 +1,35 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/joy-currency-conversion-private/domain"
 	"github.com/joy-currency-conversion-private/infrastructure"
+	"github.com/joy-currency-conversion-private/infrastructure/analytics"
+	"github.com/joy-currency-conversion-private/infrastructure/auth"
+	"github.com/joy-currency-conversion-private/infrastructure/forecast"
 )
 
 // CurrencyHandler handles all currency-related HTTP requests
 type CurrencyHandler struct {
 	awsServices *infrastructure.AWSServices
+
+	// anonymousFavorites lets requests without a principal manage every favorite
+	anonymousFavorites bool
 }
 
 // NewCurrencyHandler creates a new CurrencyHandler
-func NewCurrencyHandler(awsServices *infrastructure.AWSServices) *CurrencyHandler {
+func NewCurrencyHandler(awsServices *infrastructure.AWSServices, anonymousFavorites bool) *CurrencyHandler {
 	return &CurrencyHandler{
-		awsServices: awsServices,
+		awsServices:        awsServices,
+		anonymousFavorites: anonymousFavorites,
 	}
 }
 
@@ -43,6 +53,10 @@ func (h *CurrencyHandler) Convert(w http.ResponseWriter, r *http.Request) {
 
 	// Get exchange rate
 	rateResponse, err := h.awsServices.CurrencyService.GetExchangeRateGivenAmount(r.Context(), origin, destination, amount)
+	if errors.Is(err, domain.ErrQuotaExhausted) {
+		JSONError(w, http.StatusServiceUnavailable, "Rate provider quota exhausted", "QUOTA_EXHAUSTED")
+		return
+	}
 	if err != nil {
 		JSONError(w, http.StatusUnprocessableEntity, "Unable to get exchange rate", "RATE_UNAVAILABLE")
 		return
@@ -74,13 +88,24 @@ func (h *CurrencyHandler) Convert(w http.ResponseWriter, r *http.Request) {
 	JSONResponse(w, http.StatusOK, response)
 }
 
+// maxHistoryDays bounds the range of a history, ranges over 5 days are read from the rate store
+const maxHistoryDays = 731
+
 // History handles historical exchange rate requests
-// GET /api/v1/history?origin={ORIGIN}&destination={DEST}&start_date={YYYY-MM-DD}&end_date={YYYY-MM-DD}
+// GET /api/v1/history?origin={ORIGIN}&destination={DEST}&start_date={YYYY-MM-DD}&end_date={YYYY-MM-DD}&interval={INTERVAL}&fill={FILL}&format={FORMAT}
 func (h *CurrencyHandler) History(w http.ResponseWriter, r *http.Request) {
-	origin := r.URL.Query().Get("origin")
-	destination := r.URL.Query().Get("destination")
-	startDateStr := r.URL.Query().Get("start_date")
-	endDateStr := r.URL.Query().Get("end_date")
+	w.Header().Add("Vary", "Accept")
+	format, ok := exportFormat(r)
+	if !ok {
+		JSONError(w, http.StatusNotAcceptable, "Unsupported format, must be json, csv or ndjson", "NOT_ACCEPTABLE")
+		return
+	}
+
+	query := r.URL.Query()
+	origin := query.Get("origin")
+	destination := query.Get("destination")
+	startDateStr := query.Get("start_date")
+	endDateStr := query.Get("end_date")
 
 	if origin == "" || destination == "" || startDateStr == "" || endDateStr == "" {
 		JSONError(w, http.StatusBadRequest, "Missing required parameters: origin, destination, start_date, end_date", "MISSING_PARAMETERS")
@@ -99,45 +124,85 @@ func (h *CurrencyHandler) History(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if startDate.After(endDate) || endDate.Sub(startDate).Hours()/24 > maxHistoryDays {
+		JSONError(w, http.StatusBadRequest, fmt.Sprintf("start_date must not be after end_date, and the range must not exceed %d days", maxHistoryDays), "INVALID_DATE_RANGE")
+		return
+	}
+
+	interval := query.Get("interval")
+	if interval != "" && interval != domain.IntervalDay && interval != domain.IntervalWeek && interval != domain.IntervalMonth {
+		JSONError(w, http.StatusBadRequest, "Invalid interval, must be day, week or month", "INVALID_INTERVAL")
+		return
+	}
+
+	fill := query.Get("fill")
+	if fill != "" && fill != domain.FillNone && fill != domain.FillPrevious && fill != domain.FillInterpolate {
+		JSONError(w, http.StatusBadRequest, "Invalid fill, must be none, previous or interpolate", "INVALID_FILL")
+		return
+	}
+
 	// Get currency information
-	originCurrency, err := h.awsServices.CurrencyService.GetCurrencyInfo(r.Context(), origin)
-	if err != nil {
+	if _, err := h.awsServices.CurrencyService.GetCurrencyInfo(r.Context(), origin); err != nil {
 		JSONError(w, http.StatusBadRequest, "Invalid origin currency", "INVALID_ORIGIN")
 		return
 	}
-
-	destCurrency, err := h.awsServices.CurrencyService.GetCurrencyInfo(r.Context(), destination)
-	if err != nil {
+	if _, err := h.awsServices.CurrencyService.GetCurrencyInfo(r.Context(), destination); err != nil {
 		JSONError(w, http.StatusBadRequest, "Invalid destination currency", "INVALID_DESTINATION")
 		return
 	}
 
 	// Get historical rates
-	rates, source, err := h.awsServices.CurrencyService.GetHistoricalRates(r.Context(), origin, destination, startDate, endDate)
+	response, err := h.awsServices.CurrencyService.GetHistory(r.Context(), &domain.HistoryRequest{
+		Origin:      origin,
+		Destination: destination,
+		StartDate:   startDate,
+		EndDate:     endDate,
+		Interval:    interval,
+		Fill:        fill,
+	})
+	if errors.Is(err, domain.ErrQuotaExhausted) {
+		JSONError(w, http.StatusServiceUnavailable, "Rate provider quota exhausted", "QUOTA_EXHAUSTED")
+		return
+	}
 	if err != nil {
 		JSONError(w, http.StatusUnprocessableEntity, fmt.Sprintf("No historical data available %s", err.Error()), "NO_DATA_AVAILABLE")
 		return
 	}
-	if origin != "EUR" {
-		originCurrency, _ = h.awsServices.CurrencyService.GetCurrencyInfo(r.Context(), "EUR")
-	}
 
-	response := domain.HistoryResponse{
-		Origin:      *originCurrency,
-		Destination: *destCurrency,
-		StartDate:   startDateStr,
-		EndDate:     endDateStr,
-		Rates:       rates,
-		Timestamp:   time.Now().UTC(),
-		RatesSource: source,
-		Message: "I'm sorry, for now the origin must alway be the EUR code",
+	filename := exportFilename("history", origin, destination, response.StartDate, response.EndDate, format)
+	switch format {
+	case formatCSV:
+		header, record := historyCSV(response.Interval)
+		CSVResponse(w, r, filename, header, len(response.Rates), func(i int) []string {
+			return record(response.Rates[i])
+		})
+	case formatNDJSON:
+		NDJSONResponse(w, r, filename, len(response.Rates), func(i int) interface{} {
+			return response.Rates[i]
+		})
+	default:
+		JSONResponse(w, http.StatusOK, response)
 	}
+}
 
-	JSONResponse(w, http.StatusOK, response)
+// historyCSV returns the CSV columns of a history and the formatting of its rows:
+// the rate of every day, or the OHLC of every week or month
+func historyCSV(interval string) ([]string, func(domain.HistoryPoint) []string) {
+	if interval == "" || interval == domain.IntervalDay {
+		return []string{"date", "rate", "missing", "filled"}, func(p domain.HistoryPoint) []string {
+			return []string{p.Date, formatFloat(p.Rate), strconv.FormatBool(p.Missing), strconv.FormatBool(p.Filled)}
+		}
+	}
+	return []string{"date", "open", "high", "low", "close", "days", "missing", "filled"}, func(p domain.HistoryPoint) []string {
+		return []string{
+			p.Date, formatFloat(p.Open), formatFloat(p.High), formatFloat(p.Low), formatFloat(p.Close),
+			strconv.Itoa(p.Days), strconv.FormatBool(p.Missing), strconv.FormatBool(p.Filled),
+		}
+	}
 }
 
 // Forecast handles forecast requests
-// GET /api/v1/forecast?origin={ORIGIN}&destination={DEST}
+// GET /api/v1/forecast?origin={ORIGIN}&destination={DEST}&model={MODEL}&threshold={THRESHOLD}&horizon={DAYS}&lookback={DAYS}
 func (h *CurrencyHandler) Forecast(w http.ResponseWriter, r *http.Request) {
 	origin := r.URL.Query().Get("origin")
 	destination := r.URL.Query().Get("destination")
@@ -147,13 +212,231 @@ func (h *CurrencyHandler) Forecast(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	forecast, err := h.awsServices.CurrencyService.GetForecast(r.Context(), origin, destination)
+	var threshold float64
+	if value := r.URL.Query().Get("threshold"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed <= 0 {
+			JSONError(w, http.StatusBadRequest, "Invalid threshold, must be a number greater than 0", "INVALID_THRESHOLD")
+			return
+		}
+		threshold = parsed
+	}
+
+	horizon := 1
+	if value := r.URL.Query().Get("horizon"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > forecast.MaxHorizon {
+			JSONError(w, http.StatusBadRequest, fmt.Sprintf("Invalid horizon, must be between 1 and %d days", forecast.MaxHorizon), "INVALID_HORIZON")
+			return
+		}
+		horizon = parsed
+	}
+
+	var lookback int
+	if value := r.URL.Query().Get("lookback"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > forecast.MaxWindow {
+			JSONError(w, http.StatusBadRequest, fmt.Sprintf("Invalid lookback, must be between 1 and %d days", forecast.MaxWindow), "INVALID_LOOKBACK")
+			return
+		}
+		lookback = parsed
+	}
+
+	response, err := h.awsServices.CurrencyService.GetForecast(r.Context(), &domain.ForecastRequest{
+		Origin:      origin,
+		Destination: destination,
+		Model:       r.URL.Query().Get("model"),
+		Threshold:   threshold,
+		Horizon:     horizon,
+		Lookback:    lookback,
+	})
+	if errors.Is(err, forecast.ErrUnknownModel) {
+		JSONError(w, http.StatusBadRequest, fmt.Sprintf("Invalid model: %s", err.Error()), "INVALID_MODEL")
+		return
+	}
+	if errors.Is(err, domain.ErrQuotaExhausted) {
+		JSONError(w, http.StatusServiceUnavailable, "Rate provider quota exhausted", "QUOTA_EXHAUSTED")
+		return
+	}
 	if err != nil {
 		JSONError(w, http.StatusUnprocessableEntity, "Insufficient data for forecast", "INSUFFICIENT_DATA")
 		return
 	}
 
-	JSONResponse(w, http.StatusOK, forecast)
+	JSONResponse(w, http.StatusOK, response)
+}
+
+// maxBacktestDays bounds the range replayed by a backtest, each day refits the model
+const maxBacktestDays = 731
+
+// Backtest replays a forecast model over the stored rates of a date range
+// GET /api/v1/forecast/backtest?origin={ORIGIN}&destination={DEST}&model={MODEL}&from={YYYY-MM-DD}&to={YYYY-MM-DD}&window={DAYS}
+func (h *CurrencyHandler) Backtest(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	origin := query.Get("origin")
+	destination := query.Get("destination")
+	fromStr := query.Get("from")
+	toStr := query.Get("to")
+
+	if origin == "" || destination == "" || fromStr == "" || toStr == "" {
+		JSONError(w, http.StatusBadRequest, "Missing required parameters: origin, destination, from, to", "MISSING_PARAMETERS")
+		return
+	}
+
+	from, err := time.Parse("2006-01-02", fromStr)
+	if err != nil {
+		JSONError(w, http.StatusBadRequest, "Invalid from format. Use YYYY-MM-DD", "INVALID_DATE_FORMAT")
+		return
+	}
+
+	to, err := time.Parse("2006-01-02", toStr)
+	if err != nil {
+		JSONError(w, http.StatusBadRequest, "Invalid to format. Use YYYY-MM-DD", "INVALID_DATE_FORMAT")
+		return
+	}
+
+	if from.After(to) || to.Sub(from).Hours()/24 > maxBacktestDays {
+		JSONError(w, http.StatusBadRequest, fmt.Sprintf("from must not be after to, and the range must not exceed %d days", maxBacktestDays), "INVALID_DATE_RANGE")
+		return
+	}
+
+	if _, err := h.awsServices.CurrencyService.GetCurrencyInfo(r.Context(), origin); err != nil {
+		JSONError(w, http.StatusBadRequest, "Invalid origin currency", "INVALID_ORIGIN")
+		return
+	}
+	if _, err := h.awsServices.CurrencyService.GetCurrencyInfo(r.Context(), destination); err != nil {
+		JSONError(w, http.StatusBadRequest, "Invalid destination currency", "INVALID_DESTINATION")
+		return
+	}
+
+	var window int
+	if value := query.Get("window"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > forecast.MaxWindow {
+			JSONError(w, http.StatusBadRequest, fmt.Sprintf("Invalid window, must be between 1 and %d days", forecast.MaxWindow), "INVALID_WINDOW")
+			return
+		}
+		window = parsed
+	}
+
+	response, err := h.awsServices.CurrencyService.BacktestForecast(r.Context(), &domain.BacktestRequest{
+		Origin:      origin,
+		Destination: destination,
+		Model:       query.Get("model"),
+		From:        from,
+		To:          to,
+		Window:      window,
+	})
+	if errors.Is(err, forecast.ErrUnknownModel) {
+		JSONError(w, http.StatusBadRequest, fmt.Sprintf("Invalid model: %s", err.Error()), "INVALID_MODEL")
+		return
+	}
+	if errors.Is(err, forecast.ErrInsufficientData) {
+		JSONError(w, http.StatusUnprocessableEntity, fmt.Sprintf("Insufficient stored data for backtest: %s", err.Error()), "INSUFFICIENT_DATA")
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "backtest failed", "error", err)
+		JSONError(w, http.StatusInternalServerError, "Failed to backtest forecast", "BACKTEST_FAILED")
+		return
+	}
+
+	JSONResponse(w, http.StatusOK, response)
+}
+
+// maxAnalyticsDays bounds the range of an analytics request, every day is returned
+const maxAnalyticsDays = 731
+
+// Analytics computes returns, volatility and moving averages over the stored rates of a date range
+// GET /api/v1/analytics?origin={ORIGIN}&destination={DEST}&start_date={YYYY-MM-DD}&end_date={YYYY-MM-DD}&window={DAYS}&format={FORMAT}
+func (h *CurrencyHandler) Analytics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Vary", "Accept")
+	format, ok := exportFormat(r)
+	if !ok {
+		JSONError(w, http.StatusNotAcceptable, "Unsupported format, must be json, csv or ndjson", "NOT_ACCEPTABLE")
+		return
+	}
+
+	query := r.URL.Query()
+	origin := query.Get("origin")
+	destination := query.Get("destination")
+	startDateStr := query.Get("start_date")
+	endDateStr := query.Get("end_date")
+
+	if origin == "" || destination == "" || startDateStr == "" || endDateStr == "" {
+		JSONError(w, http.StatusBadRequest, "Missing required parameters: origin, destination, start_date, end_date", "MISSING_PARAMETERS")
+		return
+	}
+
+	startDate, err := time.Parse("2006-01-02", startDateStr)
+	if err != nil {
+		JSONError(w, http.StatusBadRequest, "Invalid start_date format. Use YYYY-MM-DD", "INVALID_DATE_FORMAT")
+		return
+	}
+
+	endDate, err := time.Parse("2006-01-02", endDateStr)
+	if err != nil {
+		JSONError(w, http.StatusBadRequest, "Invalid end_date format. Use YYYY-MM-DD", "INVALID_DATE_FORMAT")
+		return
+	}
+
+	if startDate.After(endDate) || endDate.Sub(startDate).Hours()/24 > maxAnalyticsDays {
+		JSONError(w, http.StatusBadRequest, fmt.Sprintf("start_date must not be after end_date, and the range must not exceed %d days", maxAnalyticsDays), "INVALID_DATE_RANGE")
+		return
+	}
+
+	if _, err := h.awsServices.CurrencyService.GetCurrencyInfo(r.Context(), origin); err != nil {
+		JSONError(w, http.StatusBadRequest, "Invalid origin currency", "INVALID_ORIGIN")
+		return
+	}
+	if _, err := h.awsServices.CurrencyService.GetCurrencyInfo(r.Context(), destination); err != nil {
+		JSONError(w, http.StatusBadRequest, "Invalid destination currency", "INVALID_DESTINATION")
+		return
+	}
+
+	var window int
+	if value := query.Get("window"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 2 || parsed > analytics.MaxWindow {
+			JSONError(w, http.StatusBadRequest, fmt.Sprintf("Invalid window, must be between 2 and %d days", analytics.MaxWindow), "INVALID_WINDOW")
+			return
+		}
+		window = parsed
+	}
+
+	response, err := h.awsServices.CurrencyService.GetAnalytics(r.Context(), &domain.AnalyticsRequest{
+		Origin:      origin,
+		Destination: destination,
+		StartDate:   startDate,
+		EndDate:     endDate,
+		Window:      window,
+	})
+	if errors.Is(err, analytics.ErrInsufficientData) {
+		JSONError(w, http.StatusUnprocessableEntity, "At least two stored rates are needed in the range", "INSUFFICIENT_DATA")
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "analytics failed", "error", err)
+		JSONError(w, http.StatusInternalServerError, "Failed to compute analytics", "ANALYTICS_FAILED")
+		return
+	}
+
+	// The exports carry the daily points only, the summary is in the JSON response
+	filename := exportFilename("analytics", origin, destination, response.StartDate, response.EndDate, format)
+	switch format {
+	case formatCSV:
+		header := []string{"date", "rate", "log_return", "volatility", "sma", "ema"}
+		CSVResponse(w, r, filename, header, len(response.Points), func(i int) []string {
+			p := response.Points[i]
+			return []string{p.Date, formatFloat(&p.Rate), formatFloat(p.LogReturn), formatFloat(p.Volatility), formatFloat(p.SMA), formatFloat(p.EMA)}
+		})
+	case formatNDJSON:
+		NDJSONResponse(w, r, filename, len(response.Points), func(i int) interface{} {
+			return response.Points[i]
+		})
+	default:
+		JSONResponse(w, http.StatusOK, response)
+	}
 }
 
 // GetDestinations handles available destinations requests
@@ -190,7 +473,7 @@ func (h *CurrencyHandler) GetDestinations(w http.ResponseWriter, r *http.Request
 	JSONResponse(w, http.StatusOK, response)
 }
 
-// SaveFavorite handles saving favorite conversions
+// SaveFavorite handles saving favorite conversions, owned by the signed-in user
 // POST /api/v1/favorites
 func (h *CurrencyHandler) SaveFavorite(w http.ResponseWriter, r *http.Request) {
 	var req domain.FavoriteRequest
@@ -199,15 +482,133 @@ func (h *CurrencyHandler) SaveFavorite(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	switch {
+	case !ok:
+		if !h.anonymousFavorites {
+			JSONError(w, http.StatusForbidden, "Favorites belong to users, sign in with a bearer token", "USER_REQUIRED")
+			return
+		}
+	case principal.IsAdmin():
+		// Admins may set up alerts for anyone
+		req.OwnerID = principal.UserID
+	case principal.UserID == "":
+		JSONError(w, http.StatusForbidden, "Favorites belong to users, sign in with a bearer token", "USER_REQUIRED")
+		return
+	case !strings.EqualFold(req.NotifyEmail, principal.Email):
+		JSONError(w, http.StatusForbidden, "notify_email must be the email of your account", "FORBIDDEN_EMAIL")
+		return
+	case !principal.EmailVerified:
+		// Otherwise anyone could register someone else's address and send them alerts
+		JSONError(w, http.StatusForbidden, "Verify your email before saving favorites", "EMAIL_NOT_VERIFIED")
+		return
+	default:
+		req.OwnerID = principal.UserID
+	}
+
 	favorite, err := h.awsServices.FavoriteService.SaveFavorite(r.Context(), &req)
-	if err != nil {
+	if errors.Is(err, domain.ErrAlreadyExists) {
 		JSONError(w, http.StatusConflict, "Favorite already exists", "FAVORITE_EXISTS")
 		return
 	}
+	if errors.Is(err, domain.ErrInvalidInput) {
+		JSONError(w, http.StatusBadRequest, fmt.Sprintf("Unable to save favorite: %s", err.Error()), "INVALID_FAVORITE")
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "saving favorite failed", "error", err)
+		JSONError(w, http.StatusInternalServerError, "Failed to save favorite", "FAVORITE_SAVE_FAILED")
+		return
+	}
 
 	JSONResponse(w, http.StatusCreated, favorite)
 }
 
+// ListFavorites lists the favorites of the signed-in user, or every favorite for admins
+// GET /api/v1/favorites
+func (h *CurrencyHandler) ListFavorites(w http.ResponseWriter, r *http.Request) {
+	owner, ok := h.favoriteOwner(r)
+	if !ok {
+		JSONError(w, http.StatusForbidden, "Favorites belong to users, sign in with a bearer token", "USER_REQUIRED")
+		return
+	}
+
+	favorites, err := h.awsServices.FavoriteService.ListFavorites(r.Context(), owner)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "listing favorites failed", "error", err)
+		JSONError(w, http.StatusInternalServerError, "Failed to list favorites", "LIST_FAILED")
+		return
+	}
+
+	JSONResponse(w, http.StatusOK, map[string]interface{}{
+		"favorites": favorites,
+	})
+}
+
+// GetFavorite returns one favorite, favorites of other users are reported as not found
+// GET /api/v1/favorites/{id}
+func (h *CurrencyHandler) GetFavorite(w http.ResponseWriter, r *http.Request) {
+	owner, ok := h.favoriteOwner(r)
+	if !ok {
+		JSONError(w, http.StatusForbidden, "Favorites belong to users, sign in with a bearer token", "USER_REQUIRED")
+		return
+	}
+
+	favorite, err := h.awsServices.FavoriteService.GetFavorite(r.Context(), chi.URLParam(r, "id"), owner)
+	if errors.Is(err, domain.ErrNotFound) {
+		JSONError(w, http.StatusNotFound, "Favorite not found", "FAVORITE_NOT_FOUND")
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "reading favorite failed", "error", err)
+		JSONError(w, http.StatusInternalServerError, "Failed to read favorite", "FAVORITE_UNAVAILABLE")
+		return
+	}
+
+	JSONResponse(w, http.StatusOK, favorite)
+}
+
+// DeleteFavorite removes a favorite of the signed-in user, or any favorite for admins
+// DELETE /api/v1/favorites/{id}
+func (h *CurrencyHandler) DeleteFavorite(w http.ResponseWriter, r *http.Request) {
+	owner, ok := h.favoriteOwner(r)
+	if !ok {
+		JSONError(w, http.StatusForbidden, "Favorites belong to users, sign in with a bearer token", "USER_REQUIRED")
+		return
+	}
+
+	err := h.awsServices.FavoriteService.DeleteFavorite(r.Context(), chi.URLParam(r, "id"), owner)
+	if errors.Is(err, domain.ErrNotFound) {
+		JSONError(w, http.StatusNotFound, "Favorite not found", "FAVORITE_NOT_FOUND")
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "deleting favorite failed", "error", err)
+		JSONError(w, http.StatusInternalServerError, "Failed to delete favorite", "DELETE_FAILED")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// favoriteOwner returns whose favorites the caller may access: its own as a
+// user, everyone's (empty) as an admin or, when anonymousFavorites is set, for
+// requests without a principal. It reports false for API keys, which own no
+// favorites, and for anonymous requests otherwise.
+func (h *CurrencyHandler) favoriteOwner(r *http.Request) (string, bool) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	switch {
+	case !ok:
+		return "", h.anonymousFavorites
+	case principal.IsAdmin():
+		return "", true
+	case principal.UserID != "":
+		return principal.UserID, true
+	default:
+		return "", false
+	}
+}
+
 // CheckFavorites handles daily favorite checks
 // POST /api/v1/favorites/check
 func (h *CurrencyHandler) CheckFavorites(w http.ResponseWriter, r *http.Request) {
@@ -220,6 +621,19 @@ func (h *CurrencyHandler) CheckFavorites(w http.ResponseWriter, r *http.Request)
 	JSONResponse(w, http.StatusOK, results)
 }
 
+// IngestRates stores the latest rates of the favorite pairs and precomputes their forecasts
+// POST /api/v1/rates/ingest
+func (h *CurrencyHandler) IngestRates(w http.ResponseWriter, r *http.Request) {
+	results, err := h.awsServices.IngestionService.IngestRates(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "rate ingestion failed", "error", err)
+		JSONError(w, http.StatusInternalServerError, "Failed to ingest rates", "INGESTION_FAILED")
+		return
+	}
+
+	JSONResponse(w, http.StatusOK, results)
+}
+
 // SendNotification handles email notifications
 // POST /api/v1/notifications/email
 func (h *CurrencyHandler) SendNotification(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/joy-currency-conversion-private/domain"
+	"github.com/joy-currency-conversion-private/infrastructure"
+	"github.com/joy-currency-conversion-private/infrastructure/auth"
+	"github.com/joy-currency-conversion-private/infrastructure/memory"
+)
+
+// unavailableFavorites fails every write like a storage outage
+type unavailableFavorites struct {
+	*memory.FavoriteRepository
+}
+
+func (unavailableFavorites) Create(ctx context.Context, favorite *domain.Favorite) error {
+	return errors.New("db error: connection refused")
+}
+
+func newTestHandler(favorites domain.FavoriteRepository) *CurrencyHandler {
+	currencies := infrastructure.NewCurrencyService(memory.NewRateRepository(), memory.NewForecastRepository(), nil, "", "")
+	return NewCurrencyHandler(&infrastructure.AWSServices{
+		CurrencyService: currencies,
+		FavoriteService: infrastructure.NewFavoriteService(favorites, memory.NewAlertStateRepository(), currencies),
+	}, true)
+}
+
+func TestSaveFavoriteStatus(t *testing.T) {
+	valid := `{"origin":"EUR","destination":"USD","threshold":1.2,"notify_email":"ana@example.com"}`
+
+	tests := []struct {
+		name       string
+		favorites  domain.FavoriteRepository
+		bodies     []string
+		wantStatus int
+		wantCode   string
+	}{
+		{"created", memory.NewFavoriteRepository(), []string{valid}, http.StatusCreated, ""},
+		{"malformed body", memory.NewFavoriteRepository(), []string{`{`}, http.StatusBadRequest, "INVALID_REQUEST"},
+		{"unknown currency", memory.NewFavoriteRepository(), []string{`{"origin":"EUR","destination":"XXX","threshold":1,"notify_email":"ana@example.com"}`}, http.StatusBadRequest, "INVALID_FAVORITE"},
+		{"duplicate", memory.NewFavoriteRepository(), []string{valid, valid}, http.StatusConflict, "FAVORITE_EXISTS"},
+		{"storage outage", unavailableFavorites{memory.NewFavoriteRepository()}, []string{valid}, http.StatusInternalServerError, "FAVORITE_SAVE_FAILED"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := newTestHandler(tt.favorites)
+
+			var w *httptest.ResponseRecorder
+			for _, body := range tt.bodies {
+				w = httptest.NewRecorder()
+				handler.SaveFavorite(w, httptest.NewRequest(http.MethodPost, "/api/v1/favorites", strings.NewReader(body)))
+			}
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantCode == "" {
+				return
+			}
+			var response map[string]string
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("decoding error response: %v", err)
+			}
+			if response["code"] != tt.wantCode {
+				t.Errorf("code = %q, want %q", response["code"], tt.wantCode)
+			}
+			if strings.Contains(response["error"], "db error") {
+				t.Errorf("error %q leaks the storage error", response["error"])
+			}
+		})
+	}
+}
+
+func TestSaveFavoritePrincipals(t *testing.T) {
+	body := `{"origin":"EUR","destination":"USD","threshold":1.2,"notify_email":"ana@example.com"}`
+	user := func(verified bool) *auth.Principal {
+		return &auth.Principal{UserID: "u1", Email: "ana@example.com", Scopes: []string{domain.ScopeFavorites}, EmailVerified: verified}
+	}
+
+	tests := []struct {
+		name       string
+		principal  *auth.Principal
+		anonymous  bool
+		wantStatus int
+		wantCode   string
+	}{
+		{"verified user", user(true), false, http.StatusCreated, ""},
+		{"unverified user", user(false), false, http.StatusForbidden, "EMAIL_NOT_VERIFIED"},
+		{"other email", &auth.Principal{UserID: "u2", Email: "bo@example.com", EmailVerified: true}, false, http.StatusForbidden, "FORBIDDEN_EMAIL"},
+		{"api key", &auth.Principal{APIKeyID: "k1", Scopes: []string{domain.ScopeFavorites}}, false, http.StatusForbidden, "USER_REQUIRED"},
+		{"admin key", &auth.Principal{APIKeyID: "k1", Scopes: []string{domain.ScopeAdmin}}, false, http.StatusCreated, ""},
+		{"anonymous", nil, false, http.StatusForbidden, "USER_REQUIRED"},
+		{"anonymous allowed", nil, true, http.StatusCreated, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := newTestHandler(memory.NewFavoriteRepository())
+			handler.anonymousFavorites = tt.anonymous
+
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/favorites", strings.NewReader(body))
+			if tt.principal != nil {
+				r = r.WithContext(auth.WithPrincipal(r.Context(), tt.principal))
+			}
+			w := httptest.NewRecorder()
+			handler.SaveFavorite(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantCode != "" && !strings.Contains(w.Body.String(), `"`+tt.wantCode+`"`) {
+				t.Errorf("body = %s, want code %s", w.Body.String(), tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestListFavoritesAnonymous(t *testing.T) {
+	favorites := memory.NewFavoriteRepository()
+	handler := newTestHandler(favorites)
+	if err := favorites.Create(context.Background(), &domain.Favorite{ID: "f1", OwnerID: "u1"}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	for _, anonymous := range []bool{false, true} {
+		handler.anonymousFavorites = anonymous
+		w := httptest.NewRecorder()
+		handler.ListFavorites(w, httptest.NewRequest(http.MethodGet, "/api/v1/favorites", nil))
+
+		want := http.StatusForbidden
+		if anonymous {
+			want = http.StatusOK
+		}
+		if w.Code != want {
+			t.Errorf("anonymousFavorites=%v: status = %d, want %d", anonymous, w.Code, want)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Formats a tabular endpoint can answer with, chosen by exportFormat
+const (
+	formatJSON   = "json"
+	formatCSV    = "csv"
+	formatNDJSON = "ndjson"
+)
+
+// exportFormat picks the response format of a tabular endpoint from the format
+// query parameter, or else the Accept header, where the highest q wins and a
+// named type beats a wildcard. It returns false when none of the accepted
+// types can be produced.
+func exportFormat(r *http.Request) (string, bool) {
+	switch format := r.URL.Query().Get("format"); format {
+	case formatJSON, formatCSV, formatNDJSON:
+		return format, true
+	case "":
+	default:
+		return "", false
+	}
+
+	accept := r.Header.Get("Accept")
+	if strings.TrimSpace(accept) == "" {
+		return formatJSON, true
+	}
+
+	best, bestQ, bestNamed := "", 0.0, false
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		// q=0 marks a type as not acceptable
+		if q <= 0 {
+			continue
+		}
+
+		var format string
+		switch mediaType {
+		case "application/json", "application/*", "*/*":
+			format = formatJSON
+		case "text/csv", "text/*":
+			format = formatCSV
+		case "application/x-ndjson", "application/ndjson":
+			format = formatNDJSON
+		default:
+			continue
+		}
+		named := !strings.HasSuffix(mediaType, "/*")
+		if q > bestQ || (q == bestQ && named && !bestNamed) {
+			best, bestQ, bestNamed = format, q, named
+		}
+	}
+	return best, best != ""
+}
+
+// exportFilename returns the download name of an export, e.g.
+// history_EUR_USD_2026-01-01_2026-03-31.csv
+func exportFilename(kind, origin, destination, startDate, endDate, format string) string {
+	return fmt.Sprintf("%s_%s_%s_%s_%s.%s", kind, origin, destination, startDate, endDate, format)
+}
+
+// CSVResponse writes rows as CSV, with header as the first row and CRLF line
+// endings so spreadsheets open it as is. The rows are computed in full before,
+// a history is at most two years of days.
+func CSVResponse(w http.ResponseWriter, r *http.Request, filename string, header []string, rows int, row func(i int) []string) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	w.WriteHeader(http.StatusOK)
+
+	writer := csv.NewWriter(w)
+	writer.UseCRLF = true
+	writer.Write(header)
+	for i := 0; i < rows; i++ {
+		writer.Write(row(i))
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		slog.DebugContext(r.Context(), "csv export interrupted", "error", err)
+	}
+}
+
+// NDJSONResponse writes one JSON document per row, separated by newlines
+func NDJSONResponse(w http.ResponseWriter, r *http.Request, filename string, rows int, row func(i int) interface{}) {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	w.WriteHeader(http.StatusOK)
+
+	encoder := json.NewEncoder(w)
+	for i := 0; i < rows; i++ {
+		if err := encoder.Encode(row(i)); err != nil {
+			slog.DebugContext(r.Context(), "ndjson export interrupted", "error", err)
+			return
+		}
+	}
+}
+
+// formatFloat formats an optional number for a CSV cell, empty when nil
+func formatFloat(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'f', -1, 64)
+}
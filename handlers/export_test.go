@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/joy-currency-conversion-private/domain"
+	"github.com/joy-currency-conversion-private/infrastructure"
+	"github.com/joy-currency-conversion-private/infrastructure/memory"
+)
+
+func TestExportFormat(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		accept     string
+		wantFormat string
+		wantOK     bool
+	}{
+		{"no preference", "", "", formatJSON, true},
+		{"json", "", "application/json", formatJSON, true},
+		{"csv", "", "text/csv", formatCSV, true},
+		{"ndjson", "", "application/x-ndjson", formatNDJSON, true},
+		{"ndjson alias", "", "application/ndjson", formatNDJSON, true},
+		{"highest q wins", "", "application/json;q=0.5, text/csv;q=0.9", formatCSV, true},
+		{"default q is 1", "", "text/csv;q=0.8, application/x-ndjson", formatNDJSON, true},
+		{"named type beats wildcard", "", "*/*, text/csv", formatCSV, true},
+		{"wildcard", "", "*/*", formatJSON, true},
+		{"text wildcard", "", "text/*", formatCSV, true},
+		{"unsupported types skipped", "", "text/html, text/csv;q=0.1", formatCSV, true},
+		{"q=0 refuses a type", "", "text/csv;q=0", "", false},
+		{"unsupported type", "", "text/html", "", false},
+		{"unsupported types only", "", "application/xml, image/png", "", false},
+		{"format beats accept", "format=csv", "application/json", formatCSV, true},
+		{"format ndjson", "format=ndjson", "", formatNDJSON, true},
+		{"format json over unsupported accept", "format=json", "text/html", formatJSON, true},
+		{"unsupported format", "format=xlsx", "text/csv", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/history?"+tt.query, nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			format, ok := exportFormat(r)
+			if format != tt.wantFormat || ok != tt.wantOK {
+				t.Errorf("exportFormat = %q, %v; want %q, %v", format, ok, tt.wantFormat, tt.wantOK)
+			}
+		})
+	}
+}
+
+// newExportTestHandler serves a history of stored EUR/USD rates, with the
+// weekend of 2025-09-06 missing
+func newExportTestHandler(t *testing.T) *CurrencyHandler {
+	t.Helper()
+	rates := memory.NewRateRepository()
+	stored := []domain.HistoryRate{
+		{Date: "2025-09-01", Rate: 1.17},
+		{Date: "2025-09-02", Rate: 1.16},
+		{Date: "2025-09-03", Rate: 1.165},
+		{Date: "2025-09-04", Rate: 1.166},
+		{Date: "2025-09-05", Rate: 1.171},
+		{Date: "2025-09-08", Rate: 1.175},
+	}
+	if err := rates.SaveRates(context.Background(), "EUR", "USD", infrastructure.ExchangeRatesAPI, stored); err != nil {
+		t.Fatalf("SaveRates: %v", err)
+	}
+	currencies := infrastructure.NewCurrencyService(rates, memory.NewForecastRepository(), nil, "", "")
+	return NewCurrencyHandler(&infrastructure.AWSServices{CurrencyService: currencies}, true)
+}
+
+func TestHistoryExport(t *testing.T) {
+	const history = "/api/v1/history?origin=EUR&destination=USD&start_date=2025-09-01&end_date=2025-09-08"
+
+	tests := []struct {
+		name            string
+		target          string
+		accept          string
+		wantStatus      int
+		wantType        string
+		wantDisposition string
+		wantBody        string
+	}{
+		{
+			name:            "csv",
+			target:          history,
+			accept:          "text/csv",
+			wantStatus:      http.StatusOK,
+			wantType:        "text/csv; charset=utf-8",
+			wantDisposition: "attachment; filename=history_EUR_USD_2025-09-01_2025-09-08.csv",
+			wantBody: "date,rate,missing,filled\r\n" +
+				"2025-09-01,1.17,false,false\r\n" +
+				"2025-09-02,1.16,false,false\r\n" +
+				"2025-09-03,1.165,false,false\r\n" +
+				"2025-09-04,1.166,false,false\r\n" +
+				"2025-09-05,1.171,false,false\r\n" +
+				"2025-09-06,,true,false\r\n" +
+				"2025-09-07,,true,false\r\n" +
+				"2025-09-08,1.175,false,false\r\n",
+		},
+		{
+			name:            "csv filled",
+			target:          history + "&fill=previous&format=csv",
+			wantStatus:      http.StatusOK,
+			wantType:        "text/csv; charset=utf-8",
+			wantDisposition: "attachment; filename=history_EUR_USD_2025-09-01_2025-09-08.csv",
+			wantBody: "date,rate,missing,filled\r\n" +
+				"2025-09-01,1.17,false,false\r\n" +
+				"2025-09-02,1.16,false,false\r\n" +
+				"2025-09-03,1.165,false,false\r\n" +
+				"2025-09-04,1.166,false,false\r\n" +
+				"2025-09-05,1.171,false,false\r\n" +
+				"2025-09-06,1.171,false,true\r\n" +
+				"2025-09-07,1.171,false,true\r\n" +
+				"2025-09-08,1.175,false,false\r\n",
+		},
+		{
+			name:            "weekly csv",
+			target:          history + "&interval=week&format=csv",
+			wantStatus:      http.StatusOK,
+			wantType:        "text/csv; charset=utf-8",
+			wantDisposition: "attachment; filename=history_EUR_USD_2025-09-01_2025-09-08.csv",
+			wantBody: "date,open,high,low,close,days,missing,filled\r\n" +
+				"2025-09-01,1.17,1.171,1.16,1.171,5,false,false\r\n" +
+				"2025-09-08,1.175,1.175,1.175,1.175,1,false,false\r\n",
+		},
+		{
+			name:            "unsupported accept",
+			target:          history,
+			accept:          "text/html",
+			wantStatus:      http.StatusNotAcceptable,
+			wantType:        "application/json",
+			wantDisposition: "",
+		},
+		{
+			name:            "errors stay json",
+			target:          "/api/v1/history?origin=EUR&format=csv",
+			wantStatus:      http.StatusBadRequest,
+			wantType:        "application/json",
+			wantDisposition: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := newExportTestHandler(t)
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			handler.History(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if contentType := w.Header().Get("Content-Type"); contentType != tt.wantType {
+				t.Errorf("Content-Type = %q, want %q", contentType, tt.wantType)
+			}
+			if disposition := w.Header().Get("Content-Disposition"); disposition != tt.wantDisposition {
+				t.Errorf("Content-Disposition = %q, want %q", disposition, tt.wantDisposition)
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("body =\n%q\nwant\n%q", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestHistoryExportNDJSON(t *testing.T) {
+	handler := newExportTestHandler(t)
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/history?origin=EUR&destination=USD&start_date=2025-09-01&end_date=2025-09-08&fill=interpolate", nil)
+	r.Header.Set("Accept", "application/x-ndjson")
+	w := httptest.NewRecorder()
+	handler.History(w, r)
+
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/x-ndjson" {
+		t.Fatalf("status %d, Content-Type %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	if disposition := w.Header().Get("Content-Disposition"); disposition != "attachment; filename=history_EUR_USD_2025-09-01_2025-09-08.ndjson" {
+		t.Errorf("Content-Disposition = %q", disposition)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(w.Body.String(), "\n"), "\n")
+	if len(lines) != 8 {
+		t.Fatalf("%d lines, want one per day of the range: %q", len(lines), w.Body.String())
+	}
+	for i, line := range lines {
+		var point domain.HistoryPoint
+		if err := json.Unmarshal([]byte(line), &point); err != nil {
+			t.Fatalf("line %d %q: %v", i, line, err)
+		}
+		weekend := point.Date == "2025-09-06" || point.Date == "2025-09-07"
+		if point.Rate == nil || point.Missing || point.Filled != weekend {
+			t.Errorf("line %d = %+v, want a rate, filled only on the weekend", i, point)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/joy-currency-conversion-private/infrastructure/health"
+)
+
+// HealthHandler serves the liveness and readiness probes
+type HealthHandler struct {
+	checker *health.Checker
+}
+
+// NewHealthHandler creates a new HealthHandler
+func NewHealthHandler(checker *health.Checker) *HealthHandler {
+	return &HealthHandler{
+		checker: checker,
+	}
+}
+
+// Livez reports that the process is running and able to serve requests
+// GET /livez
+func (h *HealthHandler) Livez(w http.ResponseWriter, r *http.Request) {
+	JSONResponse(w, http.StatusOK, map[string]interface{}{
+		"status":    health.StatusUp,
+		"timestamp": time.Now().UTC(),
+	})
+}
+
+// Readyz checks every dependency and returns 503 when a critical one is down
+// or shutdown has started
+// GET /readyz
+func (h *HealthHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	report := h.checker.Check(r.Context())
+
+	status := http.StatusOK
+	if !report.Ready() {
+		status = http.StatusServiceUnavailable
+	}
+
+	JSONResponse(w, status, report)
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/joy-currency-conversion-private/infrastructure/health"
+)
+
+func TestReadyz(t *testing.T) {
+	up := func(ctx context.Context) error { return nil }
+	down := func(ctx context.Context) error { return errors.New("unreachable") }
+
+	tests := []struct {
+		name     string
+		database health.CheckFunc
+		queue    health.CheckFunc
+		drain    bool
+		want     int
+	}{
+		{"ready", up, up, false, http.StatusOK},
+		{"degraded", up, down, false, http.StatusOK},
+		{"critical down", down, up, false, http.StatusServiceUnavailable},
+		{"draining", up, up, true, http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := health.NewChecker(time.Second)
+			checker.Add("database", true, tt.database)
+			checker.Add("notification queue", false, tt.queue)
+			if tt.drain {
+				checker.Drain()
+			}
+
+			recorder := httptest.NewRecorder()
+			NewHealthHandler(checker).Readyz(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			if recorder.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", recorder.Code, tt.want, recorder.Body)
+			}
+		})
+	}
+}
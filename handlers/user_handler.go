@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/joy-currency-conversion-private/domain"
+	"github.com/joy-currency-conversion-private/infrastructure/auth"
+)
+
+// UserHandler handles registration, login and the signed-in user
+type UserHandler struct {
+	users *auth.Users
+}
+
+// NewUserHandler creates a new UserHandler
+func NewUserHandler(users *auth.Users) *UserHandler {
+	return &UserHandler{
+		users: users,
+	}
+}
+
+// CredentialsRequest represents the body of the registration and login requests
+type CredentialsRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// Register creates a user account
+// POST /api/v1/users
+func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
+	var req CredentialsRequest
+	if err := BindJSON(r, &req); err != nil {
+		JSONError(w, http.StatusBadRequest, "Invalid request body", "INVALID_REQUEST")
+		return
+	}
+
+	user, err := h.users.Register(r.Context(), req.Email, req.Password)
+	if errors.Is(err, auth.ErrInvalidUser) {
+		JSONError(w, http.StatusBadRequest, fmt.Sprintf("Unable to register: %s", err.Error()), "INVALID_USER")
+		return
+	}
+	if errors.Is(err, domain.ErrAlreadyExists) {
+		JSONError(w, http.StatusConflict, "Email already registered", "USER_EXISTS")
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "registering user failed", "error", err)
+		JSONError(w, http.StatusInternalServerError, "Failed to register user", "REGISTER_FAILED")
+		return
+	}
+
+	JSONResponse(w, http.StatusCreated, user)
+}
+
+// Login exchanges an email and password for a bearer token
+// POST /api/v1/auth/login
+func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var req CredentialsRequest
+	if err := BindJSON(r, &req); err != nil {
+		JSONError(w, http.StatusBadRequest, "Invalid request body", "INVALID_REQUEST")
+		return
+	}
+
+	token, expiresAt, err := h.users.Login(r.Context(), req.Email, req.Password)
+	if errors.Is(err, auth.ErrInvalidCredentials) {
+		JSONError(w, http.StatusUnauthorized, "Invalid email or password", "INVALID_CREDENTIALS")
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "login failed", "error", err)
+		JSONError(w, http.StatusInternalServerError, "Failed to log in", "LOGIN_FAILED")
+		return
+	}
+
+	JSONResponse(w, http.StatusOK, map[string]interface{}{
+		"token":      token,
+		"token_type": "Bearer",
+		"expires_at": expiresAt,
+	})
+}
+
+// Me returns the signed-in user
+// GET /api/v1/users/me
+func (h *UserHandler) Me(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok || principal.UserID == "" {
+		JSONError(w, http.StatusUnauthorized, "Sign in with a bearer token to see your account", "UNAUTHORIZED")
+		return
+	}
+
+	user, err := h.users.Get(r.Context(), principal.UserID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "reading user failed", "error", err)
+		JSONError(w, http.StatusInternalServerError, "Failed to read user", "USER_UNAVAILABLE")
+		return
+	}
+
+	JSONResponse(w, http.StatusOK, user)
+}
+
+// VerifyEmailRequest represents the body of the email verification request
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+// VerifyEmail verifies the email of a user with the token sent at registration
+// POST /api/v1/users/verify
+func (h *UserHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req VerifyEmailRequest
+	if err := BindJSON(r, &req); err != nil || req.Token == "" {
+		JSONError(w, http.StatusBadRequest, "Invalid request body", "INVALID_REQUEST")
+		return
+	}
+
+	user, err := h.users.VerifyEmail(r.Context(), req.Token)
+	if errors.Is(err, auth.ErrInvalidToken) {
+		JSONError(w, http.StatusBadRequest, "Invalid or expired verification token", "INVALID_TOKEN")
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "verifying email failed", "error", err)
+		JSONError(w, http.StatusInternalServerError, "Failed to verify email", "VERIFY_FAILED")
+		return
+	}
+
+	JSONResponse(w, http.StatusOK, user)
+}
+
+// ResendVerification sends the signed-in user a new verification email
+// POST /api/v1/users/me/verification
+func (h *UserHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok || principal.UserID == "" {
+		JSONError(w, http.StatusUnauthorized, "Sign in with a bearer token to verify your email", "UNAUTHORIZED")
+		return
+	}
+
+	err := h.users.ResendVerification(r.Context(), principal.UserID)
+	if errors.Is(err, auth.ErrVerificationDisabled) {
+		JSONError(w, http.StatusServiceUnavailable, "Verification emails are not sent by this server", "VERIFICATION_DISABLED")
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "sending verification email failed", "error", err)
+		JSONError(w, http.StatusInternalServerError, "Failed to send verification email", "VERIFICATION_FAILED")
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
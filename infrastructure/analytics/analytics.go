@@ -0,0 +1,189 @@
+package analytics
+
+import (
+	"errors"
+	"math"
+)
+
+// DefaultWindow is how many publishing days the moving averages and the
+// rolling volatility span, about a month, MaxWindow the most they may
+const (
+	DefaultWindow = 20
+	MaxWindow     = 365
+)
+
+// ErrInsufficientData is returned when a series has fewer than two rates
+var ErrInsufficientData = errors.New("insufficient data for analytics")
+
+// Series holds the daily indicators of a rate series, aligned with it. A
+// value is nil until enough rates precede it.
+type Series struct {
+	LogReturns []*float64
+	Volatility []*float64 // sample standard deviation of the last window log returns
+	SMA        []*float64
+	EMA        []*float64
+}
+
+// Drawdown is the largest fall from a peak to a later trough, as a fraction of the peak
+type Drawdown struct {
+	Fraction float64
+	Peak     int // index of the peak, equal to Trough when the series never falls
+	Trough   int
+}
+
+// Summary describes a rate series as a whole
+type Summary struct {
+	Min, Max      int // indexes of the lowest and highest rates, the first ones on ties
+	PercentChange float64
+	MaxDrawdown   Drawdown
+
+	// Volatility is the sample standard deviation of all the daily log returns
+	Volatility float64
+}
+
+// Compute returns the daily indicators of rates, ordered oldest first, with
+// moving averages and volatility over window days
+func Compute(rates []float64, window int) (*Series, error) {
+	if len(rates) < 2 {
+		return nil, ErrInsufficientData
+	}
+
+	returns := logReturns(rates)
+	return &Series{
+		LogReturns: returns,
+		Volatility: rollingVolatility(returns, window),
+		SMA:        sma(rates, window),
+		EMA:        ema(rates, window),
+	}, nil
+}
+
+// Summarize returns the summary of rates, ordered oldest first
+func Summarize(rates []float64) (*Summary, error) {
+	if len(rates) < 2 {
+		return nil, ErrInsufficientData
+	}
+
+	summary := &Summary{
+		PercentChange: (rates[len(rates)-1] - rates[0]) / rates[0] * 100,
+		MaxDrawdown:   maxDrawdown(rates),
+	}
+	for i, rate := range rates {
+		if rate < rates[summary.Min] {
+			summary.Min = i
+		}
+		if rate > rates[summary.Max] {
+			summary.Max = i
+		}
+	}
+
+	returns := make([]float64, 0, len(rates)-1)
+	for _, r := range logReturns(rates)[1:] {
+		returns = append(returns, *r)
+	}
+	summary.Volatility = stdDev(returns)
+
+	return summary, nil
+}
+
+// logReturns returns ln(rate / previous rate) for every rate after the first
+func logReturns(rates []float64) []*float64 {
+	returns := make([]*float64, len(rates))
+	for i := 1; i < len(rates); i++ {
+		r := math.Log(rates[i] / rates[i-1])
+		returns[i] = &r
+	}
+	return returns
+}
+
+// rollingVolatility returns the sample standard deviation of the last window
+// log returns, defined once window returns are available
+func rollingVolatility(returns []*float64, window int) []*float64 {
+	volatility := make([]*float64, len(returns))
+	if window < 2 {
+		return volatility
+	}
+	last := make([]float64, window)
+	for i := window; i < len(returns); i++ {
+		for j := range last {
+			last[j] = *returns[i-window+1+j]
+		}
+		v := stdDev(last)
+		volatility[i] = &v
+	}
+	return volatility
+}
+
+// sma returns the simple moving average of the last window values
+func sma(values []float64, window int) []*float64 {
+	averages := make([]*float64, len(values))
+	var sum float64
+	for i, v := range values {
+		sum += v
+		if i >= window {
+			sum -= values[i-window]
+		}
+		if i >= window-1 {
+			average := sum / float64(window)
+			averages[i] = &average
+		}
+	}
+	return averages
+}
+
+// ema returns the exponential moving average with smoothing 2/(window+1),
+// seeded with the simple average of the first window values
+func ema(values []float64, window int) []*float64 {
+	averages := make([]*float64, len(values))
+	if len(values) < window {
+		return averages
+	}
+
+	alpha := 2 / float64(window+1)
+	var average float64
+	for _, v := range values[:window] {
+		average += v
+	}
+	average /= float64(window)
+
+	for i := window - 1; i < len(values); i++ {
+		if i >= window {
+			average = alpha*values[i] + (1-alpha)*average
+		}
+		a := average
+		averages[i] = &a
+	}
+	return averages
+}
+
+// maxDrawdown returns the largest fall from a running peak
+func maxDrawdown(values []float64) Drawdown {
+	var drawdown Drawdown
+	peak := 0
+	for i, v := range values {
+		if v > values[peak] {
+			peak = i
+		}
+		if fall := (values[peak] - v) / values[peak]; fall > drawdown.Fraction {
+			drawdown = Drawdown{Fraction: fall, Peak: peak, Trough: i}
+		}
+	}
+	return drawdown
+}
+
+// stdDev returns the sample standard deviation of values, zero for fewer than two
+func stdDev(values []float64) float64 {
+	if len(values) < 2 {
+		return 0
+	}
+	var mean float64
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+
+	var squares float64
+	for _, v := range values {
+		squares += (v - mean) * (v - mean)
+	}
+	return math.Sqrt(squares / float64(len(values)-1))
+}
@@ -0,0 +1,182 @@
+package analytics
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func near(got, want float64) bool {
+	return math.Abs(got-want) <= 1e-9*math.Max(1, math.Abs(want))
+}
+
+// values turns a golden column into optional values, NaN standing for nil
+func values(column ...float64) []*float64 {
+	result := make([]*float64, len(column))
+	for i, v := range column {
+		if !math.IsNaN(v) {
+			result[i] = &column[i]
+		}
+	}
+	return result
+}
+
+func checkColumn(t *testing.T, name string, got, want []*float64) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s has %d values, want %d", name, len(got), len(want))
+	}
+	for i := range want {
+		switch {
+		case (got[i] == nil) != (want[i] == nil):
+			t.Errorf("%s[%d] set = %v, want %v", name, i, got[i] != nil, want[i] != nil)
+		case got[i] != nil && !near(*got[i], *want[i]):
+			t.Errorf("%s[%d] = %v, want %v", name, i, *got[i], *want[i])
+		}
+	}
+}
+
+func TestComputeGoldenValues(t *testing.T) {
+	nan, ln2 := math.NaN(), math.Ln2
+
+	// Every window of three returns holds two of one sign and one of the
+	// other: deviations of 2/3, 2/3 and 4/3 ln 2, a sample variance of 4/3 ln² 2
+	vol := 2 * ln2 / math.Sqrt(3)
+
+	tests := []struct {
+		name       string
+		rates      []float64
+		window     int
+		returns    []*float64
+		volatility []*float64
+		sma        []*float64
+		ema        []*float64
+	}{
+		{
+			// The EMA smooths with 2/(3+1) = 1/2 from the first SMA on
+			name:       "doubling and halving",
+			rates:      []float64{1, 2, 4, 2, 1, 2},
+			window:     3,
+			returns:    values(nan, ln2, ln2, -ln2, -ln2, ln2),
+			volatility: values(nan, nan, nan, vol, vol, vol),
+			sma:        values(nan, nan, 7.0/3, 8.0/3, 7.0/3, 5.0/3),
+			ema:        values(nan, nan, 7.0/3, 13.0/6, 19.0/12, 43.0/24),
+		},
+		{
+			// A single return has no spread, the averages are the rates themselves
+			name:       "window of one",
+			rates:      []float64{1, 2, 4},
+			window:     1,
+			returns:    values(nan, ln2, ln2),
+			volatility: values(nan, nan, nan),
+			sma:        values(1, 2, 4),
+			ema:        values(1, 2, 4),
+		},
+		{
+			name:       "window as long as the series",
+			rates:      []float64{1, 2, 4},
+			window:     3,
+			returns:    values(nan, ln2, ln2),
+			volatility: values(nan, nan, nan),
+			sma:        values(nan, nan, 7.0/3),
+			ema:        values(nan, nan, 7.0/3),
+		},
+		{
+			name:       "window longer than the series",
+			rates:      []float64{1, 2},
+			window:     3,
+			returns:    values(nan, ln2),
+			volatility: values(nan, nan),
+			sma:        values(nan, nan),
+			ema:        values(nan, nan),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			series, err := Compute(tt.rates, tt.window)
+			if err != nil {
+				t.Fatalf("Compute: %v", err)
+			}
+			checkColumn(t, "log returns", series.LogReturns, tt.returns)
+			checkColumn(t, "volatility", series.Volatility, tt.volatility)
+			checkColumn(t, "SMA", series.SMA, tt.sma)
+			checkColumn(t, "EMA", series.EMA, tt.ema)
+		})
+	}
+}
+
+func TestSummarizeGoldenValues(t *testing.T) {
+	tests := []struct {
+		name           string
+		rates          []float64
+		wantMin        int
+		wantMax        int
+		wantChange     float64
+		wantDrawdown   Drawdown
+		wantVolatility float64
+	}{
+		{
+			// Returns ln 2 × (1, 1, -1, -1, 1): deviations of 4/5 and 6/5, a
+			// sample variance of 1.2 ln² 2. The low of 1 appears twice.
+			name:           "doubling and halving",
+			rates:          []float64{1, 2, 4, 2, 1, 2},
+			wantMin:        0,
+			wantMax:        2,
+			wantChange:     100,
+			wantDrawdown:   Drawdown{Fraction: 0.75, Peak: 2, Trough: 4},
+			wantVolatility: math.Ln2 * math.Sqrt(1.2),
+		},
+		{
+			// Falling 50% from 2 outweighs falling 10% from the later peak of 3.
+			// The volatility of ln 0.5, ln 3 and ln 0.9 was computed independently.
+			name:           "largest fall before the highest peak",
+			rates:          []float64{2, 1, 3, 2.7},
+			wantMin:        1,
+			wantMax:        2,
+			wantChange:     35,
+			wantDrawdown:   Drawdown{Fraction: 0.5, Peak: 0, Trough: 1},
+			wantVolatility: 0.9133679135085357,
+		},
+		{
+			name:           "never falls",
+			rates:          []float64{1, 1, 2},
+			wantMin:        0,
+			wantMax:        2,
+			wantChange:     100,
+			wantDrawdown:   Drawdown{},
+			wantVolatility: math.Ln2 / math.Sqrt2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			summary, err := Summarize(tt.rates)
+			if err != nil {
+				t.Fatalf("Summarize: %v", err)
+			}
+			if summary.Min != tt.wantMin || summary.Max != tt.wantMax {
+				t.Errorf("min, max = %d, %d; want %d, %d", summary.Min, summary.Max, tt.wantMin, tt.wantMax)
+			}
+			if !near(summary.PercentChange, tt.wantChange) {
+				t.Errorf("percent change = %v, want %v", summary.PercentChange, tt.wantChange)
+			}
+			got := summary.MaxDrawdown
+			if !near(got.Fraction, tt.wantDrawdown.Fraction) || got.Peak != tt.wantDrawdown.Peak || got.Trough != tt.wantDrawdown.Trough {
+				t.Errorf("max drawdown = %+v, want %+v", got, tt.wantDrawdown)
+			}
+			if !near(summary.Volatility, tt.wantVolatility) {
+				t.Errorf("volatility = %v, want %v", summary.Volatility, tt.wantVolatility)
+			}
+		})
+	}
+}
+
+func TestInsufficientData(t *testing.T) {
+	for _, rates := range [][]float64{nil, {1.1}} {
+		if _, err := Compute(rates, DefaultWindow); !errors.Is(err, ErrInsufficientData) {
+			t.Errorf("Compute(%v) error = %v, want ErrInsufficientData", rates, err)
+		}
+		if _, err := Summarize(rates); !errors.Is(err, ErrInsufficientData) {
+			t.Errorf("Summarize(%v) error = %v, want ErrInsufficientData", rates, err)
+		}
+	}
+}
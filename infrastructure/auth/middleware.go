@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// challenge is sent with 401 responses, naming both ways to authenticate
+const challenge = `Bearer, ApiKey header="` + APIKeyHeader + `"`
+
+// Authenticator identifies the caller of each request from a user token or an API key
+type Authenticator struct {
+	keys  *Keys
+	users *Users
+}
+
+// NewAuthenticator creates an Authenticator checking API keys and user tokens
+func NewAuthenticator(keys *Keys, users *Users) *Authenticator {
+	return &Authenticator{
+		keys:  keys,
+		users: users,
+	}
+}
+
+// Middleware stores the principal of requests carrying an `Authorization:
+// Bearer <token>` or X-API-Key header in their context. Invalid credentials
+// are rejected with 401; requests without any go on anonymously and Require
+// decides whether that is allowed.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var principal *Principal
+		var err error
+		if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			principal, err = a.fromToken(r, token)
+		} else if plain := r.Header.Get(APIKeyHeader); plain != "" {
+			principal, err = a.fromKey(r, plain)
+		} else {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if errors.Is(err, ErrInvalidToken) || errors.Is(err, ErrInvalidKey) {
+			w.Header().Set("WWW-Authenticate", challenge)
+			writeError(w, http.StatusUnauthorized, "Invalid credentials", "UNAUTHORIZED")
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "authentication failed", "error", err)
+			writeError(w, http.StatusInternalServerError, "Unable to verify credentials", "AUTH_UNAVAILABLE")
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+	})
+}
+
+// fromToken returns the principal of a user token
+func (a *Authenticator) fromToken(r *http.Request, token string) (*Principal, error) {
+	user, err := a.users.Authenticate(r.Context(), strings.TrimSpace(token))
+	if err != nil {
+		return nil, err
+	}
+	return &Principal{UserID: user.ID, Email: user.Email, Scopes: userScopes(user.Role), EmailVerified: user.EmailVerifiedAt != nil}, nil
+}
+
+// fromKey returns the principal of an API key
+func (a *Authenticator) fromKey(r *http.Request, plain string) (*Principal, error) {
+	key, err := a.keys.Authenticate(r.Context(), plain)
+	if err != nil {
+		return nil, err
+	}
+	return &Principal{APIKeyID: key.ID, Scopes: key.Scopes}, nil
+}
+
+// Require returns a middleware rejecting anonymous requests (401) and those
+// whose principal lacks scope (403). It must run after Authenticator.Middleware.
+func Require(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := PrincipalFromContext(r.Context())
+			if !ok {
+				w.Header().Set("WWW-Authenticate", challenge)
+				writeError(w, http.StatusUnauthorized, "Missing credentials, send a bearer token or an API key", "UNAUTHORIZED")
+				return
+			}
+			if !principal.HasScope(scope) {
+				writeError(w, http.StatusForbidden, fmt.Sprintf("Credentials lack the %s scope", scope), "INSUFFICIENT_SCOPE")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// writeError writes an error body in the same shape as the handlers
+func writeError(w http.ResponseWriter, statusCode int, message, code string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(map[string]string{
+		"error": message,
+		"code":  code,
+	})
+}
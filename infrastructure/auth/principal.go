@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"context"
+	"slices"
+
+	"github.com/joy-currency-conversion-private/domain"
+)
+
+// Principal is the authenticated caller of a request, a user signed in with
+// a token or a client using an API key
+type Principal struct {
+	// UserID is set when the caller is a user, APIKeyID when it is an API key
+	UserID   string
+	APIKeyID string
+
+	Email  string
+	Scopes []string
+
+	// EmailVerified is set when the user proved owning Email
+	EmailVerified bool
+}
+
+// HasScope reports whether the principal grants scope, admin grants every scope
+func (p *Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope) || slices.Contains(p.Scopes, domain.ScopeAdmin)
+}
+
+// IsAdmin reports whether the principal may act on every user's data
+func (p *Principal) IsAdmin() bool {
+	return slices.Contains(p.Scopes, domain.ScopeAdmin)
+}
+
+// Subject identifies the principal across requests, e.g. to key rate limits
+func (p *Principal) Subject() string {
+	if p.UserID != "" {
+		return "user:" + p.UserID
+	}
+	return "key:" + p.APIKeyID
+}
+
+// userScopes returns the scopes granted to a user signed in with a token
+func userScopes(role string) []string {
+	scopes := []string{domain.ScopeRatesRead, domain.ScopeFavorites}
+	if role == domain.RoleAdmin {
+		scopes = append(scopes, domain.ScopeAdmin)
+	}
+	return scopes
+}
+
+type contextKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying the authenticated principal
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, principal)
+}
+
+// PrincipalFromContext returns the principal authenticated for the request, if any
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(contextKey{}).(*Principal)
+	return principal, ok
+}
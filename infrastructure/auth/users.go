@@ -0,0 +1,307 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/mail"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/joy-currency-conversion-private/domain"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Password length limits, bcrypt ignores anything past 72 bytes
+const (
+	minPasswordLength = 8
+	maxPasswordLength = 72
+)
+
+// Verification tokens carry their own audience so they are never accepted as
+// login tokens, and the other way around
+const (
+	verificationAudience = "email-verification"
+	verificationTTL      = 24 * time.Hour
+)
+
+// verificationClaims names the address a verification token was sent to, so
+// it can't verify the account once its email changes
+type verificationClaims struct {
+	Email string `json:"email"`
+	jwt.RegisteredClaims
+}
+
+var (
+	// ErrInvalidCredentials is returned by Login for an unknown email or a wrong password
+	ErrInvalidCredentials = errors.New("invalid email or password")
+
+	// ErrInvalidToken is returned for malformed, expired or forged tokens
+	ErrInvalidToken = errors.New("invalid token")
+
+	// ErrInvalidUser is returned when registering with an invalid email or password
+	ErrInvalidUser = errors.New("invalid user")
+
+	// ErrVerificationDisabled is returned when asking for a verification email
+	// and no sender is configured
+	ErrVerificationDisabled = errors.New("verification emails are disabled")
+)
+
+// VerificationSender delivers the token proving a user owns their email
+type VerificationSender interface {
+	SendVerificationEmail(ctx context.Context, email, token string) error
+}
+
+// Users registers users and signs them in with HS256 JSON Web Tokens
+type Users struct {
+	repo   domain.UserRepository
+	ttl    time.Duration
+	sender VerificationSender
+
+	mu     sync.RWMutex
+	secret []byte
+	// previous is the secret replaced by the last rotation, still accepted
+	// until previousUntil so the tokens it signed don't stop working at once
+	previous      []byte
+	previousUntil time.Time
+}
+
+// NewUsers creates a Users service signing tokens with secret, valid for ttl.
+// Verification emails go through sender, which may be nil when none are sent.
+func NewUsers(repo domain.UserRepository, secret []byte, ttl time.Duration, sender VerificationSender) *Users {
+	return &Users{
+		repo:   repo,
+		secret: secret,
+		ttl:    ttl,
+		sender: sender,
+	}
+}
+
+// RotateSecret signs new tokens with secret. Tokens signed with the previous
+// secret are accepted until they expire. It reports whether the secret changed.
+func (u *Users) RotateSecret(secret []byte) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if bytes.Equal(secret, u.secret) {
+		return false
+	}
+	u.previous = u.secret
+	u.previousUntil = time.Now().Add(max(u.ttl, verificationTTL))
+	u.secret = secret
+	return true
+}
+
+// signingKey returns the secret new tokens are signed with
+func (u *Users) signingKey() []byte {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	return u.secret
+}
+
+// verificationKeys returns the secrets tokens are accepted with: the current
+// one and, after a rotation, the previous one until its tokens have expired
+func (u *Users) verificationKeys() jwt.VerificationKeySet {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	keys := jwt.VerificationKeySet{Keys: []jwt.VerificationKey{u.secret}}
+	if u.previous != nil && time.Now().Before(u.previousUntil) {
+		keys.Keys = append(keys.Keys, u.previous)
+	}
+	return keys
+}
+
+// Register creates a user with the user role, or returns domain.ErrAlreadyExists
+// when the email is taken
+func (u *Users) Register(ctx context.Context, email, password string) (*domain.User, error) {
+	address, err := mail.ParseAddress(strings.TrimSpace(email))
+	if err != nil || address.Name != "" {
+		return nil, fmt.Errorf("email %q is not a valid address: %w", email, ErrInvalidUser)
+	}
+	if len(password) < minPasswordLength || len(password) > maxPasswordLength {
+		return nil, fmt.Errorf("password must be between %d and %d characters: %w", minPasswordLength, maxPasswordLength, ErrInvalidUser)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("hashing password: %w", err)
+	}
+
+	user := &domain.User{
+		ID:           uuid.New().String(),
+		Email:        strings.ToLower(address.Address),
+		PasswordHash: string(hash),
+		Role:         domain.RoleUser,
+		CreatedAt:    time.Now().UTC(),
+	}
+	if err := u.repo.Create(ctx, user); err != nil {
+		return nil, err
+	}
+
+	// The account exists either way, a lost email is sent again on request
+	if err := u.sendVerification(ctx, user); err != nil {
+		slog.WarnContext(ctx, "sending verification email failed", "user", user.ID, "error", err)
+	}
+	return user, nil
+}
+
+// Login checks the password of a user and returns a signed token with its expiry
+func (u *Users) Login(ctx context.Context, email, password string) (string, time.Time, error) {
+	user, err := u.repo.GetByEmail(ctx, strings.ToLower(strings.TrimSpace(email)))
+	if errors.Is(err, domain.ErrNotFound) {
+		return "", time.Time{}, ErrInvalidCredentials
+	}
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return "", time.Time{}, ErrInvalidCredentials
+	}
+
+	now := time.Now().UTC()
+	expiresAt := now.Add(u.ttl)
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Subject:   user.ID,
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+	}).SignedString(u.signingKey())
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("signing token: %w", err)
+	}
+	return token, expiresAt, nil
+}
+
+// Authenticate returns the user a token was issued to, or ErrInvalidToken.
+// The user is read again so role changes apply to tokens already issued.
+func (u *Users) Authenticate(ctx context.Context, token string) (*domain.User, error) {
+	var claims jwt.RegisteredClaims
+	if err := u.parse(token, &claims); err != nil || len(claims.Audience) > 0 {
+		return nil, ErrInvalidToken
+	}
+
+	user, err := u.repo.Get(ctx, claims.Subject)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Get returns the user with the given ID, or domain.ErrNotFound
+func (u *Users) Get(ctx context.Context, id string) (*domain.User, error) {
+	return u.repo.Get(ctx, id)
+}
+
+// SetRole changes the role of the user with the given email, or returns domain.ErrNotFound
+func (u *Users) SetRole(ctx context.Context, email, role string) (*domain.User, error) {
+	if role != domain.RoleUser && role != domain.RoleAdmin {
+		return nil, fmt.Errorf("unknown role %q (use %s or %s): %w", role, domain.RoleUser, domain.RoleAdmin, ErrInvalidUser)
+	}
+	user, err := u.repo.GetByEmail(ctx, strings.ToLower(strings.TrimSpace(email)))
+	if err != nil {
+		return nil, err
+	}
+	if err := u.repo.SetRole(ctx, user.ID, role); err != nil {
+		return nil, err
+	}
+	user.Role = role
+	return user, nil
+}
+
+// ResendVerification sends a new verification email to the user with the
+// given ID, unless their email is already verified
+func (u *Users) ResendVerification(ctx context.Context, id string) error {
+	user, err := u.repo.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt != nil {
+		return nil
+	}
+	if u.sender == nil {
+		return ErrVerificationDisabled
+	}
+	return u.sendVerification(ctx, user)
+}
+
+// VerifyEmail marks the email of the user a verification token was issued to
+// as verified, or returns ErrInvalidToken
+func (u *Users) VerifyEmail(ctx context.Context, token string) (*domain.User, error) {
+	var claims verificationClaims
+	if err := u.parse(token, &claims); err != nil || !slices.Contains(claims.Audience, verificationAudience) {
+		return nil, ErrInvalidToken
+	}
+
+	user, err := u.repo.Get(ctx, claims.Subject)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(claims.Email, user.Email) {
+		return nil, ErrInvalidToken
+	}
+	return u.markVerified(ctx, user)
+}
+
+// MarkVerified verifies the email of the user with the given email without a
+// token, or returns domain.ErrNotFound
+func (u *Users) MarkVerified(ctx context.Context, email string) (*domain.User, error) {
+	user, err := u.repo.GetByEmail(ctx, strings.ToLower(strings.TrimSpace(email)))
+	if err != nil {
+		return nil, err
+	}
+	return u.markVerified(ctx, user)
+}
+
+// markVerified records the verification once, later calls keep the first time
+func (u *Users) markVerified(ctx context.Context, user *domain.User) (*domain.User, error) {
+	if user.EmailVerifiedAt != nil {
+		return user, nil
+	}
+	now := time.Now().UTC()
+	if err := u.repo.SetEmailVerified(ctx, user.ID, now); err != nil {
+		return nil, err
+	}
+	user.EmailVerifiedAt = &now
+	return user, nil
+}
+
+// sendVerification signs a verification token for the user and sends it to their email
+func (u *Users) sendVerification(ctx context.Context, user *domain.User) error {
+	if u.sender == nil {
+		return nil
+	}
+	now := time.Now().UTC()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, verificationClaims{
+		Email: user.Email,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   user.ID,
+			Audience:  jwt.ClaimStrings{verificationAudience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(verificationTTL)),
+		},
+	}).SignedString(u.signingKey())
+	if err != nil {
+		return fmt.Errorf("signing verification token: %w", err)
+	}
+	return u.sender.SendVerificationEmail(ctx, user.Email, token)
+}
+
+// parse checks the signature and expiry of a token and decodes it into claims
+func (u *Users) parse(token string, claims jwt.Claims) error {
+	_, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (any, error) {
+		return u.verificationKeys(), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	return err
+}
@@ -0,0 +1,172 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/joy-currency-conversion-private/infrastructure/memory"
+)
+
+// recordingSender keeps the last verification token sent to each email
+type recordingSender map[string]string
+
+func (s recordingSender) SendVerificationEmail(ctx context.Context, email, token string) error {
+	s[email] = token
+	return nil
+}
+
+func TestVerifyEmail(t *testing.T) {
+	sent := recordingSender{}
+	users := NewUsers(memory.NewUserRepository(), []byte("secret"), time.Hour, sent)
+	ctx := context.Background()
+
+	user, err := users.Register(ctx, "Ana@Example.com", "password1")
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if user.EmailVerifiedAt != nil {
+		t.Fatal("new user is already verified")
+	}
+	verification, ok := sent["ana@example.com"]
+	if !ok {
+		t.Fatal("no verification email sent at registration")
+	}
+
+	// Neither kind of token is accepted in place of the other
+	login, _, err := users.Login(ctx, "ana@example.com", "password1")
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if _, err := users.VerifyEmail(ctx, login); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("VerifyEmail(login token) error = %v, want ErrInvalidToken", err)
+	}
+	if _, err := users.Authenticate(ctx, verification); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Authenticate(verification token) error = %v, want ErrInvalidToken", err)
+	}
+	forged := NewUsers(memory.NewUserRepository(), []byte("other"), time.Hour, recordingSender{})
+	if _, err := forged.VerifyEmail(ctx, verification); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("VerifyEmail with another secret error = %v, want ErrInvalidToken", err)
+	}
+
+	verified, err := users.VerifyEmail(ctx, verification)
+	if err != nil {
+		t.Fatalf("VerifyEmail: %v", err)
+	}
+	if verified.EmailVerifiedAt == nil {
+		t.Fatal("VerifyEmail returned an unverified user")
+	}
+	authenticated, err := users.Authenticate(ctx, login)
+	if err != nil || authenticated.EmailVerifiedAt == nil {
+		t.Errorf("Authenticate after verifying = %+v, %v; want a verified user", authenticated, err)
+	}
+
+	// Verified users get no more emails
+	delete(sent, "ana@example.com")
+	if err := users.ResendVerification(ctx, user.ID); err != nil {
+		t.Fatalf("ResendVerification: %v", err)
+	}
+	if _, ok := sent["ana@example.com"]; ok {
+		t.Error("verification email sent to a verified user")
+	}
+}
+
+func TestResendVerification(t *testing.T) {
+	sent := recordingSender{}
+	users := NewUsers(memory.NewUserRepository(), []byte("secret"), time.Hour, sent)
+	ctx := context.Background()
+
+	user, err := users.Register(ctx, "bo@example.com", "password1")
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	delete(sent, "bo@example.com")
+
+	if err := users.ResendVerification(ctx, user.ID); err != nil {
+		t.Fatalf("ResendVerification: %v", err)
+	}
+	if _, err := users.VerifyEmail(ctx, sent["bo@example.com"]); err != nil {
+		t.Errorf("VerifyEmail(resent token): %v", err)
+	}
+
+	// Without a sender the request fails instead of pretending to send
+	unsent := NewUsers(memory.NewUserRepository(), []byte("secret"), time.Hour, nil)
+	other, err := unsent.Register(ctx, "bo@example.com", "password1")
+	if err != nil {
+		t.Fatalf("Register without sender: %v", err)
+	}
+	if err := unsent.ResendVerification(ctx, other.ID); !errors.Is(err, ErrVerificationDisabled) {
+		t.Errorf("ResendVerification without sender error = %v, want ErrVerificationDisabled", err)
+	}
+}
+
+func TestMarkVerified(t *testing.T) {
+	users := NewUsers(memory.NewUserRepository(), []byte("secret"), time.Hour, nil)
+	ctx := context.Background()
+
+	if _, err := users.Register(ctx, "cy@example.com", "password1"); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	first, err := users.MarkVerified(ctx, " CY@example.com ")
+	if err != nil || first.EmailVerifiedAt == nil {
+		t.Fatalf("MarkVerified = %+v, %v", first, err)
+	}
+	at := *first.EmailVerifiedAt
+
+	// Verifying again keeps the first time
+	again, err := users.MarkVerified(ctx, "cy@example.com")
+	if err != nil || !again.EmailVerifiedAt.Equal(at) {
+		t.Errorf("MarkVerified again = %+v, %v; want verified at %v", again, err, at)
+	}
+}
+
+func TestRotateSecret(t *testing.T) {
+	sent := recordingSender{}
+	users := NewUsers(memory.NewUserRepository(), []byte("old"), time.Hour, sent)
+	ctx := context.Background()
+
+	if _, err := users.Register(ctx, "ana@example.com", "password1"); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	before, _, err := users.Login(ctx, "ana@example.com", "password1")
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	if users.RotateSecret([]byte("old")) {
+		t.Error("RotateSecret(same secret) = true, want no rotation")
+	}
+	if !users.RotateSecret([]byte("new")) {
+		t.Fatal("RotateSecret(new secret) = false")
+	}
+	after, _, err := users.Login(ctx, "ana@example.com", "password1")
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	// Both secrets are accepted during the grace period
+	for name, token := range map[string]string{"before": before, "after": after} {
+		if _, err := users.Authenticate(ctx, token); err != nil {
+			t.Errorf("Authenticate(token %s the rotation): %v", name, err)
+		}
+	}
+	if _, err := users.VerifyEmail(ctx, sent["ana@example.com"]); err != nil {
+		t.Errorf("VerifyEmail(token signed with the previous secret): %v", err)
+	}
+
+	// Only the new one once the tokens signed with the previous secret have expired
+	users.previousUntil = time.Now().Add(-time.Second)
+	if _, err := users.Authenticate(ctx, before); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Authenticate(token before the rotation) error = %v, want ErrInvalidToken", err)
+	}
+	if _, err := users.Authenticate(ctx, after); err != nil {
+		t.Errorf("Authenticate(token after the rotation): %v", err)
+	}
+
+	// A token signed with a secret never used is rejected
+	stranger := NewUsers(memory.NewUserRepository(), []byte("other"), time.Hour, nil)
+	if _, err := stranger.Authenticate(ctx, after); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Authenticate(foreign token) error = %v, want ErrInvalidToken", err)
+	}
+}
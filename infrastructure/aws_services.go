@@ -1,8 +1,8 @@
 package infrastructure
 
 import (
-	/*
 	"context"
+	/*
 	"time"
 	*/
 
@@ -12,6 +12,7 @@ import (
 	"github.com/aws/aws-sdk-go/service/ses"
 	"github.com/aws/aws-sdk-go/service/sqs"
 	"github.com/joy-currency-conversion-private/domain"
+	"github.com/joy-currency-conversion-private/infrastructure/upstream"
 )
 
 // AWSServices contains all AWS service clients and implementations
@@ -25,13 +26,17 @@ type AWSServices struct {
 	CurrencyService    domain.CurrencyService
 	FavoriteService    domain.FavoriteService
 	NotificationService domain.NotificationService
+	IngestionService    domain.IngestionService
+
+	currencyService     *CurrencyService
+	notificationService *NotificationService
 }
 
-// NewAWSServices creates a new AWSServices instance
-func NewAWSServices(exchangeRateAPIKey, exchangeRatesAPIKey string) *AWSServices {
+// NewAWSServices creates a new AWSServices instance backed by the given repositories
+func NewAWSServices(region string, client *upstream.Client, exchangeRateAPIKey, exchangeRatesAPIKey, queueURL, emailFrom string, favorites domain.FavoriteRepository, rates domain.RateRepository, forecasts domain.ForecastRepository, alerts domain.AlertStateRepository) *AWSServices {
 	// Create AWS session
 	sess := session.Must(session.NewSession(&aws.Config{
-		Region: aws.String("us-east-1"), // Configure your preferred region
+		Region: aws.String(region),
 	}))
 
 	// Initialize AWS clients
@@ -40,9 +45,10 @@ func NewAWSServices(exchangeRateAPIKey, exchangeRatesAPIKey string) *AWSServices
 	sqsClient := sqs.New(sess)
 
 	// Initialize service implementations
-	currencyService := NewCurrencyService(dynamoDB, exchangeRateAPIKey, exchangeRatesAPIKey)
-	favoriteService := NewFavoriteService(dynamoDB, exchangeRateAPIKey, exchangeRatesAPIKey)
-	notificationService := NewNotificationService(sesClient, sqsClient)
+	currencyService := NewCurrencyService(rates, forecasts, client, exchangeRateAPIKey, exchangeRatesAPIKey)
+	favoriteService := NewFavoriteService(favorites, alerts, currencyService)
+	notificationService := NewNotificationService(sesClient, sqsClient, queueURL, emailFrom)
+	ingestionService := NewIngestionService(favorites, currencyService)
 
 	return &AWSServices{
 		DynamoDB:            dynamoDB,
@@ -51,5 +57,18 @@ func NewAWSServices(exchangeRateAPIKey, exchangeRatesAPIKey string) *AWSServices
 		CurrencyService:     currencyService,
 		FavoriteService:     favoriteService,
 		NotificationService: notificationService,
+		IngestionService:    ingestionService,
+		currencyService:     currencyService,
+		notificationService: notificationService,
 	}
 }
+
+// UpdateAPIKeys propagates rotated provider keys to the services using them
+func (a *AWSServices) UpdateAPIKeys(exchangeRateAPIKey, exchangeRatesAPIKey string) {
+	a.currencyService.SetAPIKeys(exchangeRateAPIKey, exchangeRatesAPIKey)
+}
+
+// PingQueue checks the notification queue is reachable
+func (a *AWSServices) PingQueue(ctx context.Context) error {
+	return a.notificationService.PingQueue(ctx)
+}
@@ -2,32 +2,81 @@ package infrastructure
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"math"
 	"net/http"
+	"strconv"
+	"sync/atomic"
 	"time"
 
-	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/joy-currency-conversion-private/domain"
+	"github.com/joy-currency-conversion-private/infrastructure/analytics"
+	"github.com/joy-currency-conversion-private/infrastructure/forecast"
+	"github.com/joy-currency-conversion-private/infrastructure/metrics"
 	"github.com/joy-currency-conversion-private/infrastructure/response"
+	"github.com/joy-currency-conversion-private/infrastructure/upstream"
 )
 
-// CurrencyService implements domain.CurrencyService using AWS services
+// Base URLs of the rate providers
+const (
+	ExchangeRateAPIBaseURL  = "https://v6.exchangerate-api.com"
+	ExchangeRatesAPIBaseURL = "https://api.exchangeratesapi.io"
+)
+
+// Names of the rate providers, used in metrics, logs and upstream policies
+const (
+	ExchangeRateAPI  = "exchange-rate-api"
+	ExchangeRatesAPI = "api.exchangeratesapi.io"
+)
+
+// CurrencyService implements domain.CurrencyService using the external rate APIs
 type CurrencyService struct {
-	dynamoDB            *dynamodb.DynamoDB
+	rates     domain.RateRepository
+	forecasts domain.ForecastRepository
+	client    *upstream.Client
+	keys      atomic.Pointer[APIKeys]
+}
+
+// APIKeys holds the credentials of the rate providers
+type APIKeys struct {
 	ExchangeRateAPIKey  string
 	ExchangeRatesAPIKey string
 }
 
 // NewCurrencyService creates a new CurrencyService
-func NewCurrencyService(dynamoDB *dynamodb.DynamoDB, exchangeRateAPIKey, echangeRatesAPIKey string) *CurrencyService {
-	return &CurrencyService{
-		dynamoDB:            dynamoDB,
+func NewCurrencyService(rates domain.RateRepository, forecasts domain.ForecastRepository, client *upstream.Client, exchangeRateAPIKey, echangeRatesAPIKey string) *CurrencyService {
+	s := &CurrencyService{
+		rates:     rates,
+		forecasts: forecasts,
+		client:    client,
+	}
+	s.SetAPIKeys(exchangeRateAPIKey, echangeRatesAPIKey)
+	return s
+}
+
+// SetAPIKeys replaces the provider keys. Requests already running keep the
+// keys they started with, new requests use the new ones.
+func (s *CurrencyService) SetAPIKeys(exchangeRateAPIKey, exchangeRatesAPIKey string) {
+	s.keys.Store(&APIKeys{
 		ExchangeRateAPIKey:  exchangeRateAPIKey,
-		ExchangeRatesAPIKey: echangeRatesAPIKey,
-	}
+		ExchangeRatesAPIKey: exchangeRatesAPIKey,
+	})
+}
+
+// apiKeys returns the current provider keys
+func (s *CurrencyService) apiKeys() APIKeys {
+	return *s.keys.Load()
+}
+
+// get calls a provider through the shared upstream client
+func (s *CurrencyService) get(ctx context.Context, provider, url string) (*http.Response, error) {
+	return s.client.Get(ctx, provider, url)
 }
 
 type exchangeRateResponse struct {
@@ -51,10 +100,13 @@ func (s *CurrencyService) GetExchangeRateGivenAmount(ctx context.Context, origin
 	// - Or store rates in DynamoDB and update them periodically
 
 	// This was built with https://app.exchangerate-api.com/ api
-	url := fmt.Sprintf("https://v6.exchangerate-api.com/v6/%s/pair/%s/%s/%.3f", s.ExchangeRateAPIKey, origin, destination, amount)
+	url := fmt.Sprintf("%s/v6/%s/pair/%s/%s/%.3f", ExchangeRateAPIBaseURL, s.apiKeys().ExchangeRateAPIKey, origin, destination, amount)
 
 	// Make the GET request
-	resp, err := http.Get(url)
+	resp, err := s.get(ctx, ExchangeRateAPI, url)
+	if errors.Is(err, domain.ErrQuotaExhausted) {
+		return response.ExchangeRateResponse{}, err
+	}
 	if err != nil {
 		return response.ExchangeRateResponse{}, fmt.Errorf("error when get the conversion rate for %s to %s", origin, destination)
 	}
@@ -91,10 +143,13 @@ func (s *CurrencyService) GetExchangeRate(ctx context.Context, origin, destinati
 	// - Or store rates in DynamoDB and update them periodically
 
 	// This was built with https://app.exchangerate-api.com/ api
-	url := fmt.Sprintf("https://v6.exchangerate-api.com/v6/%s/pair/%s/%s", s.ExchangeRateAPIKey, origin, destination)
+	url := fmt.Sprintf("%s/v6/%s/pair/%s/%s", ExchangeRateAPIBaseURL, s.apiKeys().ExchangeRateAPIKey, origin, destination)
 
 	// Make the GET request
-	resp, err := http.Get(url)
+	resp, err := s.get(ctx, ExchangeRateAPI, url)
+	if errors.Is(err, domain.ErrQuotaExhausted) {
+		return 0, "", err
+	}
 	if err != nil {
 		return 0, "", fmt.Errorf("error when get the conversion rate for %s to %s", origin, destination)
 	}
@@ -138,7 +193,13 @@ func (s *CurrencyService) GetExchangeRate(ctx context.Context, origin, destinati
 	*/
 }
 
-// GetHistoricalRates returns historical exchange rates for a date range
+// maxFetchDays bounds the ranges GetHistoricalRates may fetch from the
+// provider, each day is one call
+const maxFetchDays = 5
+
+// GetHistoricalRates returns historical exchange rates for a date range. Only
+// the days the provider published a rate for are returned: weekends, holidays
+// and days it returned no rate for are left out.
 func (s *CurrencyService) GetHistoricalRates(ctx context.Context, origin, destination string, startDate, endDate time.Time) ([]domain.HistoryRate, string, error) {
 	// TODO: Implement historical rate fetching
 	// This could:
@@ -165,7 +226,8 @@ func (s *CurrencyService) GetHistoricalRates(ctx context.Context, origin, destin
 			current = current.AddDate(0, 0, 1)
 		}
 	*/
-	fmt.Printf("*** End date: %s and Start date: %s", startDate, endDate)
+	slog.DebugContext(ctx, "fetching historical rates", "origin", origin, "destination", destination,
+		"start_date", startDate.Format("2006-01-02"), "end_date", endDate.Format("2006-01-02"))
 	if startDate.After(time.Now()) || endDate.After(time.Now()) {
 		return []domain.HistoryRate{}, "", fmt.Errorf("the start date and end date must not be greater than the current date")
 	}
@@ -174,18 +236,63 @@ func (s *CurrencyService) GetHistoricalRates(ctx context.Context, origin, destin
 		return []domain.HistoryRate{}, "", fmt.Errorf("the start date must not be greater than end date")
 	}
 
-	if endDate.Sub(startDate).Hours()/24 > 5 {
-		return []domain.HistoryRate{}, "", fmt.Errorf("the difference between start date and end date must not be greater than 5")
+	if endDate.Sub(startDate).Hours()/24 > maxFetchDays {
+		return []domain.HistoryRate{}, "", fmt.Errorf("the difference between start date and end date must not be greater than %d", maxFetchDays)
+	}
+	// The API only serves EUR based rates, so they are stored under the EUR origin
+	stored, err := s.rates.GetRates(ctx, "EUR", destination, startDate, endDate)
+	if err != nil {
+		return []domain.HistoryRate{}, "", fmt.Errorf("error reading stored rates: %w", err)
 	}
+	// Weekends stored before they were skipped only repeat Friday
+	stored = publishedRates(ExchangeRatesAPI, stored)
+	storedByDate := make(map[string]float64, len(stored))
+	for _, rate := range stored {
+		storedByDate[rate.Date] = rate.Rate
+	}
+
+	// Use the same key for every day of this request even if it is rotated meanwhile
+	apiKey := s.apiKeys().ExchangeRatesAPIKey
+
 	var rates []domain.HistoryRate
+	var fetched []domain.HistoryRate
+	var quotaErr error
 	current := startDate
 	for current.Before(endDate) || current.Equal(endDate) {
+		if rate, exists := storedByDate[current.Format("2006-01-02")]; exists {
+			metrics.RateCacheHit()
+			rates = append(rates, domain.HistoryRate{
+				Date: current.Format("2006-01-02"),
+				Rate: rate,
+			})
+			current = current.AddDate(0, 0, 1)
+			continue
+		}
+
+		// Weekends are never published, asking for them would only repeat Friday
+		if weekdaysOnly[ExchangeRatesAPI] && isWeekend(current) {
+			current = current.AddDate(0, 0, 1)
+			continue
+		}
+
+		metrics.RateCacheMiss()
+
+		// Once the quota is exhausted only the stored days are served
+		if quotaErr != nil {
+			current = current.AddDate(0, 0, 1)
+			continue
+		}
 
 		// No wokr the query param base and symbols, by the fault, the base is EUR, i think i can't use the endpoint with another base
-		url := fmt.Sprintf("https://api.exchangeratesapi.io/v1/%s?access_key=%s&base=%s&symbols=%s", current.Format("2006-01-02"), s.ExchangeRatesAPIKey, "EUR", destination)
+		url := fmt.Sprintf("%s/v1/%s?access_key=%s&base=%s&symbols=%s", ExchangeRatesAPIBaseURL, current.Format("2006-01-02"), apiKey, "EUR", destination)
 
 		// Make the GET request
-		resp, err := http.Get(url)
+		resp, err := s.get(ctx, ExchangeRatesAPI, url)
+		if errors.Is(err, domain.ErrQuotaExhausted) {
+			quotaErr = err
+			current = current.AddDate(0, 0, 1)
+			continue
+		}
 		if err != nil {
 			return []domain.HistoryRate{}, "", fmt.Errorf("error when get the historical data for %s to %s, date: %s", origin, destination, current)
 		}
@@ -208,19 +315,51 @@ func (s *CurrencyService) GetHistoricalRates(ctx context.Context, origin, destin
 			return []domain.HistoryRate{}, "", fmt.Errorf("error unmarshalling response body: %v", err)
 		}
 
-		rates = append(rates, domain.HistoryRate{
+		// A holiday is answered with the rate of the previous publishing day, and a
+		// currency the provider doesn't track is left out of the rates; neither is a rate of this day
+		value, ok := exchangeRatesResponse.Rates[destination]
+		if !ok || value <= 0 || (exchangeRatesResponse.Date != "" && exchangeRatesResponse.Date != current.Format("2006-01-02")) {
+			slog.DebugContext(ctx, "no rate published", "provider", ExchangeRatesAPI, "destination", destination,
+				"date", current.Format("2006-01-02"), "published_date", exchangeRatesResponse.Date)
+			current = current.AddDate(0, 0, 1)
+			continue
+		}
+
+		rate := domain.HistoryRate{
 			Date: current.Format("2006-01-02"),
-			Rate: exchangeRatesResponse.Rates[destination],
-		})
+			Rate: value,
+		}
+		rates = append(rates, rate)
+		fetched = append(fetched, rate)
 		current = current.AddDate(0, 0, 1)
-		time.Sleep(1 * time.Second)
+	}
+
+	if len(fetched) > 0 {
+		if err := s.rates.SaveRates(ctx, "EUR", destination, "api.exchangeratesapi.io", fetched); err != nil {
+			return []domain.HistoryRate{}, "", fmt.Errorf("error storing rates: %w", err)
+		}
+	}
+
+	if quotaErr != nil {
+		if len(rates) == 0 {
+			return []domain.HistoryRate{}, "", quotaErr
+		}
+		slog.WarnContext(ctx, "provider quota exhausted, serving stored rates only", "provider", ExchangeRatesAPI,
+			"destination", destination, "days", len(rates))
 	}
 
 	return rates, "api.exchangeratesapi.io", nil
 }
 
 // GetForecast returns a forecast for the next day's exchange rate
-func (s *CurrencyService) GetForecast(ctx context.Context, origin, destination string) (*domain.ForecastResponse, error) {
+func (s *CurrencyService) GetForecast(ctx context.Context, req *domain.ForecastRequest) (*domain.ForecastResponse, error) {
+	origin, destination := req.Origin, req.Destination
+
+	model, err := forecast.Get(req.Model)
+	if err != nil {
+		return nil, err
+	}
+
 	// Get currency information
 	originCurrency, err := s.GetCurrencyInfo(ctx, origin)
 	if err != nil {
@@ -237,124 +376,427 @@ func (s *CurrencyService) GetForecast(ctx context.Context, origin, destination s
 		return nil, err
 	}
 
-	// Calculate date range for last 5 days (to respect API limitations)
+	lookback := req.Lookback
+	if lookback == 0 {
+		lookback = forecast.DefaultWindow
+	}
+	horizon := max(req.Horizon, 1)
+
 	endDate := time.Now().AddDate(0, 0, -1) // Yesterday (since today's data might not be available)
-	startDate := endDate.AddDate(0, 0, -4)  // 5 days ago
+	response := s.storedForecast(ctx, model, destination, horizon, lookback, endDate)
+	if response != nil {
+		metrics.ForecastCacheHit()
+	} else {
+		metrics.ForecastCacheMiss()
+		response, err = s.computeForecast(ctx, model, origin, destination, horizon, lookback, endDate)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	response.Origin = *originCurrency
+	response.Destination = *destCurrency
+	if req.Threshold > 0 {
+		withExceedance(response, req.Threshold)
+	}
+
+	return response, nil
+}
 
-	// Get historical data for the last 5 days
-	historicalRates, source, err := s.GetHistoricalRates(ctx, origin, destination, startDate, endDate)
+// computeForecast fits the model on the lookback window ending at endDate and
+// keeps the result in the forecast store. The response has no currencies nor
+// exceedance, they depend on the request.
+func (s *CurrencyService) computeForecast(ctx context.Context, model forecast.Forecaster, origin, destination string, horizon, lookback int, endDate time.Time) (*domain.ForecastResponse, error) {
+	// Top up the last 5 days from the provider (to respect API limitations), the
+	// rest of the window is read from the rate store
+	_, source, err := s.GetHistoricalRates(ctx, origin, destination, endDate.AddDate(0, 0, -4), endDate)
 	if err != nil {
 		return nil, fmt.Errorf("unable to get historical data for forecast: %w", err)
 	}
 
-	// Check if we have enough data (at least 3 days)
-	if len(historicalRates) < 3 {
-		return nil, fmt.Errorf("insufficient historical data for forecast (need at least 3 days, got %d)", len(historicalRates))
+	historicalRates, err := s.forecastInputs(ctx, source, destination, lookback, endDate)
+	if err != nil {
+		return nil, err
 	}
 
-	// Calculate statistics from historical data
-	var sum float64
-	var rates []float64
+	rates := make([]float64, len(historicalRates))
+	for i, rate := range historicalRates {
+		rates[i] = rate.Rate
+	}
 
-	for _, rate := range historicalRates {
-		sum += rate.Rate
-		rates = append(rates, rate.Rate)
+	result, err := model.Forecast(rates, horizon)
+	if err != nil {
+		return nil, err
+	}
+
+	dates := nextPublishingDays(source, time.Now(), horizon)
+	points := make([]domain.ForecastPoint, horizon)
+	for i, step := range result.Steps {
+		intervals := make([]domain.PredictionInterval, 0, len(forecast.IntervalLevels))
+		for _, level := range forecast.IntervalLevels {
+			lower, upper := step.Interval(level)
+			intervals = append(intervals, domain.PredictionInterval{Level: level, Lower: lower, Upper: upper})
+		}
+
+		points[i] = domain.ForecastPoint{
+			Date:          dates[i],
+			PredictedRate: step.Predicted,
+			StdError:      step.StdError,
+			Intervals:     intervals,
+		}
+	}
+
+	response := &domain.ForecastResponse{
+		PredictedDate:  points[0].Date,
+		PredictedRate:  points[0].PredictedRate,
+		Model:          model.Name(),
+		Parameters:     result.Parameters,
+		StdError:       points[0].StdError,
+		IntervalMethod: result.Method,
+		Intervals:      points[0].Intervals,
+		Horizon:        horizon,
+		Points:         points,
+		Window:         windowStats(historicalRates, lookback),
+		Timestamp:      time.Now().UTC(),
+		RatesSource:    source,
+	}
+
+	// A failed save only costs recomputing the forecast on the next request
+	err = s.forecasts.Save(ctx, &domain.StoredForecast{
+		Origin:       "EUR",
+		Destination:  destination,
+		Model:        model.Name(),
+		Horizon:      horizon,
+		Lookback:     lookback,
+		ModelVersion: model.Version(),
+		InputsHash:   inputsHash(model, horizon, lookback, historicalRates),
+		EndDate:      endDate.Format("2006-01-02"),
+		Response:     *response,
+		ComputedAt:   response.Timestamp,
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "storing forecast failed", "destination", destination, "model", model.Name(), "error", err)
 	}
 
-	average := sum / float64(len(rates))
+	return response, nil
+}
+
+// storedForecast returns the forecast kept in the forecast store when it is
+// fresh: computed by the same model version for the same last day, on the
+// same rates. It returns nil otherwise.
+func (s *CurrencyService) storedForecast(ctx context.Context, model forecast.Forecaster, destination string, horizon, lookback int, endDate time.Time) *domain.ForecastResponse {
+	stored, err := s.forecasts.Get(ctx, "EUR", destination, model.Name(), horizon, lookback)
+	if err != nil {
+		if !errors.Is(err, domain.ErrNotFound) {
+			slog.WarnContext(ctx, "reading stored forecast failed", "destination", destination, "model", model.Name(), "error", err)
+		}
+		return nil
+	}
+	if stored.ModelVersion != model.Version() || stored.EndDate != endDate.Format("2006-01-02") {
+		return nil
+	}
+
+	// Rates stored or corrected since the forecast was computed change the hash
+	historicalRates, err := s.forecastInputs(ctx, stored.Response.RatesSource, destination, lookback, endDate)
+	if err != nil || inputsHash(model, horizon, lookback, historicalRates) != stored.InputsHash {
+		return nil
+	}
+
+	return &stored.Response
+}
 
-	// Calculate standard deviation for confidence estimation
-	var variance float64
+// forecastInputs reads the lookback window ending at endDate from the rate store
+func (s *CurrencyService) forecastInputs(ctx context.Context, source, destination string, lookback int, endDate time.Time) ([]domain.HistoryRate, error) {
+	// The API only serves EUR based rates, so they are stored under the EUR origin
+	startDate := publishingDaysBack(source, endDate, lookback)
+	historicalRates, err := s.rates.GetRates(ctx, "EUR", destination, startDate, endDate)
+	if err != nil {
+		return nil, fmt.Errorf("error reading stored rates: %w", err)
+	}
+
+	// Models step one publishing day at a time, so weekends that only repeat Friday are left out
+	return publishedRates(source, historicalRates), nil
+}
+
+// inputsHash identifies the rates and settings a forecast is computed from
+func inputsHash(model forecast.Forecaster, horizon, lookback int, rates []domain.HistoryRate) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s|%s|%d|%d", model.Name(), model.Version(), horizon, lookback)
 	for _, rate := range rates {
-		diff := rate - average
-		variance += diff * diff
+		fmt.Fprintf(hash, "|%s=%s", rate.Date, strconv.FormatFloat(rate.Rate, 'g', -1, 64))
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// withExceedance adds the probability of exceeding threshold to every point.
+// Points are copied, the response may share them with the forecast store.
+func withExceedance(response *domain.ForecastResponse, threshold float64) {
+	points := make([]domain.ForecastPoint, len(response.Points))
+	for i, point := range response.Points {
+		step := forecast.Step{Predicted: point.PredictedRate, StdError: point.StdError}
+		point.Exceedance = &domain.Exceedance{
+			Threshold:   threshold,
+			Probability: step.ProbabilityAbove(threshold),
+		}
+		points[i] = point
+	}
+	response.Points = points
+	response.Exceedance = points[0].Exceedance
+}
+
+// BacktestForecast replays a forecast model over the rates already in the
+// rate store, so it never calls the providers
+func (s *CurrencyService) BacktestForecast(ctx context.Context, req *domain.BacktestRequest) (*domain.BacktestResponse, error) {
+	model, err := forecast.Get(req.Model)
+	if err != nil {
+		return nil, err
 	}
-	variance = variance / float64(len(rates))
-	stdDev := math.Sqrt(variance)
 
-	// Simple trend analysis: compare first half vs second half
-	midPoint := len(rates) / 2
-	firstHalfAvg := 0.0
-	secondHalfAvg := 0.0
+	originCurrency, err := s.GetCurrencyInfo(ctx, req.Origin)
+	if err != nil {
+		return nil, err
+	}
+	// Temporal code because currently the API  only allows EUR origin
+	if req.Origin != "EUR" {
+		originCurrency, _ = s.GetCurrencyInfo(ctx, "EUR")
+	}
+
+	destCurrency, err := s.GetCurrencyInfo(ctx, req.Destination)
+	if err != nil {
+		return nil, err
+	}
 
-	for i := 0; i < midPoint; i++ {
-		firstHalfAvg += rates[i]
+	window := req.Window
+	if window == 0 {
+		window = forecast.DefaultWindow
 	}
-	firstHalfAvg = firstHalfAvg / float64(midPoint)
 
-	for i := midPoint; i < len(rates); i++ {
-		secondHalfAvg += rates[i]
+	// The API only serves EUR based rates, so they are stored under the EUR origin
+	stored, err := s.rates.GetRates(ctx, "EUR", req.Destination, req.From, req.To)
+	if err != nil {
+		return nil, fmt.Errorf("error reading stored rates: %w", err)
 	}
-	secondHalfAvg = secondHalfAvg / float64(len(rates)-midPoint)
+	stored = publishedRates(ExchangeRatesAPI, stored)
 
-	// Calculate trend (positive = increasing, negative = decreasing)
-	trend := (secondHalfAvg - firstHalfAvg) / firstHalfAvg
+	rates := make([]float64, len(stored))
+	for i, rate := range stored {
+		rates[i] = rate.Rate
+	}
 
-	// Predict next day's rate using simple linear trend
-	// Use a conservative approach: 50% of the trend + 50% of the average
-	trendAdjustment := average * trend * 0.5
-	predictedRate := average + trendAdjustment
+	backtest, err := forecast.Run(model, rates, window)
+	if err != nil {
+		return nil, err
+	}
 
-	// Ensure predicted rate is positive
-	if predictedRate <= 0 {
-		predictedRate = average
+	response := &domain.BacktestResponse{
+		Origin:      *originCurrency,
+		Destination: *destCurrency,
+		Model:       model.Name(),
+		From:        req.From.Format("2006-01-02"),
+		To:          req.To.Format("2006-01-02"),
+		Window:      window,
+		Forecasts:   backtest.Forecasts,
+		Metrics:     forecastMetrics(backtest.Model),
+		Baseline:    forecastMetrics(backtest.Baseline),
+		Timestamp:   time.Now().UTC(),
+		RatesSource: ExchangeRatesAPI,
+	}
+	if backtest.Baseline.RMSE > 0 {
+		skill := 1 - backtest.Model.RMSE/backtest.Baseline.RMSE
+		response.Skill = &skill
 	}
 
-	// Calculate confidence based on data consistency
-	// Lower standard deviation = higher confidence
-	// More data points = higher confidence
-	confidence := 0.5 // Base confidence
+	return response, nil
+}
 
-	// Adjust confidence based on standard deviation (lower std dev = higher confidence)
-	if stdDev > 0 {
-		coefficientOfVariation := stdDev / average
-		if coefficientOfVariation < 0.05 { // Less than 5% variation
-			confidence += 0.3
-		} else if coefficientOfVariation < 0.1 { // Less than 10% variation
-			confidence += 0.2
-		} else if coefficientOfVariation < 0.2 { // Less than 20% variation
-			confidence += 0.1
-		}
+// GetAnalytics computes the indicators of a pair over the rates already in the
+// rate store, so it never calls the providers
+func (s *CurrencyService) GetAnalytics(ctx context.Context, req *domain.AnalyticsRequest) (*domain.AnalyticsResponse, error) {
+	originCurrency, err := s.GetCurrencyInfo(ctx, req.Origin)
+	if err != nil {
+		return nil, err
+	}
+	// Temporal code because currently the API  only allows EUR origin
+	if req.Origin != "EUR" {
+		originCurrency, _ = s.GetCurrencyInfo(ctx, "EUR")
 	}
 
-	// Adjust confidence based on data points (adjusted for 5-day period)
-	if len(rates) >= 5 {
-		confidence += 0.1
-	} else if len(rates) >= 4 {
-		confidence += 0.05
+	destCurrency, err := s.GetCurrencyInfo(ctx, req.Destination)
+	if err != nil {
+		return nil, err
 	}
 
-	// Cap confidence at 0.9 (90%)
-	if confidence > 0.9 {
-		confidence = 0.9
+	window := req.Window
+	if window == 0 {
+		window = analytics.DefaultWindow
 	}
 
-	// Ensure minimum confidence
-	if confidence < 0.3 {
-		confidence = 0.3
+	// The API only serves EUR based rates, so they are stored under the EUR origin
+	stored, err := s.rates.GetRates(ctx, "EUR", req.Destination, req.StartDate, req.EndDate)
+	if err != nil {
+		return nil, fmt.Errorf("error reading stored rates: %w", err)
 	}
+	// Weekends only repeat Friday, they would add zero returns
+	stored = publishedRates(ExchangeRatesAPI, stored)
 
-	// Calculate predicted date (tomorrow)
-	tomorrow := time.Now().AddDate(0, 0, 1)
+	rates := make([]float64, len(stored))
+	for i, rate := range stored {
+		rates[i] = rate.Rate
+	}
 
-	response := &domain.ForecastResponse{
-		Origin:        *originCurrency,
-		Destination:   *destCurrency,
-		PredictedDate: tomorrow.Format("2006-01-02"),
-		PredictedRate: predictedRate,
-		Confidence:    confidence,
-		Last30Days: struct {
-			Average float64 `json:"average"`
-		}{
-			Average: average,
+	series, err := analytics.Compute(rates, window)
+	if err != nil {
+		return nil, err
+	}
+	summary, err := analytics.Summarize(rates)
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]domain.AnalyticsPoint, len(stored))
+	for i, rate := range stored {
+		points[i] = domain.AnalyticsPoint{
+			Date:       rate.Date,
+			Rate:       rate.Rate,
+			LogReturn:  series.LogReturns[i],
+			Volatility: series.Volatility[i],
+			SMA:        series.SMA[i],
+			EMA:        series.EMA[i],
+		}
+	}
+
+	drawdown := domain.Drawdown{Percent: summary.MaxDrawdown.Fraction * 100}
+	if summary.MaxDrawdown.Fraction > 0 {
+		drawdown.PeakDate = stored[summary.MaxDrawdown.Peak].Date
+		drawdown.TroughDate = stored[summary.MaxDrawdown.Trough].Date
+	}
+
+	response := &domain.AnalyticsResponse{
+		Origin:      *originCurrency,
+		Destination: *destCurrency,
+		StartDate:   req.StartDate.Format("2006-01-02"),
+		EndDate:     req.EndDate.Format("2006-01-02"),
+		Window:      window,
+		Summary: domain.AnalyticsSummary{
+			Count:         len(stored),
+			StartRate:     rates[0],
+			EndRate:       rates[len(rates)-1],
+			PercentChange: summary.PercentChange,
+			Min:           stored[summary.Min],
+			Max:           stored[summary.Max],
+			MaxDrawdown:   drawdown,
+			Volatility:    summary.Volatility,
 		},
+		Points:      points,
 		Timestamp:   time.Now().UTC(),
-		RatesSource: source,
+		RatesSource: ExchangeRatesAPI,
 	}
 
 	return response, nil
 }
 
+// forecastMetrics converts backtest metrics to their response form
+func forecastMetrics(m forecast.Metrics) domain.ForecastMetrics {
+	metrics := domain.ForecastMetrics{
+		MAE:                 m.MAE,
+		RMSE:                m.RMSE,
+		MAPE:                m.MAPE,
+		DirectionalAccuracy: m.DirectionalAccuracy,
+	}
+	for i, coverage := range m.Coverage {
+		metrics.Coverage = append(metrics.Coverage, domain.IntervalCoverage{
+			Level:    forecast.IntervalLevels[i],
+			Coverage: coverage,
+		})
+	}
+	return metrics
+}
+
+// weekdaysOnly lists the providers publishing no rates on weekends, like the
+// ECB reference rates behind api.exchangeratesapi.io
+var weekdaysOnly = map[string]bool{
+	ExchangeRatesAPI: true,
+}
+
+// isWeekend reports whether t falls on a Saturday or Sunday
+func isWeekend(t time.Time) bool {
+	return t.Weekday() == time.Saturday || t.Weekday() == time.Sunday
+}
+
+// publishingDaysBack returns the earliest of the last n days up to end for
+// which the provider publishes rates
+func publishingDaysBack(source string, end time.Time, n int) time.Time {
+	day := end
+	for {
+		if !weekdaysOnly[source] || !isWeekend(day) {
+			n--
+			if n == 0 {
+				return day
+			}
+		}
+		day = day.AddDate(0, 0, -1)
+	}
+}
+
+// windowStats summarizes the rates a forecast was fitted on
+func windowStats(rates []domain.HistoryRate, lookback int) domain.WindowStats {
+	stats := domain.WindowStats{
+		Lookback: lookback,
+		Count:    len(rates),
+	}
+	if len(rates) == 0 {
+		return stats
+	}
+
+	stats.Start, stats.End = rates[0].Date, rates[len(rates)-1].Date
+	stats.Min, stats.Max = rates[0].Rate, rates[0].Rate
+	var sum float64
+	for _, rate := range rates {
+		sum += rate.Rate
+		stats.Min = math.Min(stats.Min, rate.Rate)
+		stats.Max = math.Max(stats.Max, rate.Rate)
+	}
+	stats.Mean = sum / float64(len(rates))
+
+	// Sample standard deviation, the window is a sample of the pair's history
+	if len(rates) > 1 {
+		var variance float64
+		for _, rate := range rates {
+			variance += (rate.Rate - stats.Mean) * (rate.Rate - stats.Mean)
+		}
+		stats.StdDev = math.Sqrt(variance / float64(len(rates)-1))
+	}
+	return stats
+}
+
+// publishedRates drops the weekend days of providers that don't publish
+// them, for which the provider only repeats Friday's rate
+func publishedRates(source string, rates []domain.HistoryRate) []domain.HistoryRate {
+	if !weekdaysOnly[source] {
+		return rates
+	}
+	published := make([]domain.HistoryRate, 0, len(rates))
+	for _, rate := range rates {
+		if date, err := time.Parse("2006-01-02", rate.Date); err == nil && isWeekend(date) {
+			continue
+		}
+		published = append(published, rate)
+	}
+	return published
+}
+
+// nextPublishingDays returns the n dates after from for which the provider publishes rates
+func nextPublishingDays(source string, from time.Time, n int) []string {
+	dates := make([]string, 0, n)
+	for day := from.AddDate(0, 0, 1); len(dates) < n; day = day.AddDate(0, 0, 1) {
+		if weekdaysOnly[source] && isWeekend(day) {
+			continue
+		}
+		dates = append(dates, day.Format("2006-01-02"))
+	}
+	return dates
+}
+
 // GetSupportedDestinations returns supported destination currencies for an origin
 func (s *CurrencyService) GetSupportedDestinations(ctx context.Context, origin string) ([]domain.Currency, string, error) {
 	// TODO: Implement destination lookup
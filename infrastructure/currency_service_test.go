@@ -0,0 +1,222 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/joy-currency-conversion-private/domain"
+	"github.com/joy-currency-conversion-private/infrastructure/forecast"
+	"github.com/joy-currency-conversion-private/infrastructure/memory"
+)
+
+// newStoreOnlyCurrencyService returns a CurrencyService without a provider
+// client, over a rate store holding the given EUR/USD rates
+func newStoreOnlyCurrencyService(t *testing.T, rates []domain.HistoryRate) *CurrencyService {
+	t.Helper()
+	store := memory.NewRateRepository()
+	if err := store.SaveRates(context.Background(), "EUR", "USD", ExchangeRatesAPI, rates); err != nil {
+		t.Fatalf("SaveRates: %v", err)
+	}
+	return NewCurrencyService(store, memory.NewForecastRepository(), nil, "", "")
+}
+
+func date(value string) time.Time {
+	d, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func TestGetHistoricalRatesFromStore(t *testing.T) {
+	service := newStoreOnlyCurrencyService(t, []domain.HistoryRate{
+		{Date: "2025-09-05", Rate: 1.17},
+		{Date: "2025-09-06", Rate: 1.17}, // weekend repeat of Friday stored before they were skipped
+		{Date: "2025-09-08", Rate: 1.18},
+	})
+
+	// Friday to Monday: every weekday is stored, so the provider is never called
+	rates, source, err := service.GetHistoricalRates(context.Background(), "EUR", "USD", date("2025-09-05"), date("2025-09-08"))
+	if err != nil {
+		t.Fatalf("GetHistoricalRates: %v", err)
+	}
+	want := []domain.HistoryRate{{Date: "2025-09-05", Rate: 1.17}, {Date: "2025-09-08", Rate: 1.18}}
+	if len(rates) != len(want) {
+		t.Fatalf("rates = %v, want %v", rates, want)
+	}
+	for i := range want {
+		if rates[i] != want[i] {
+			t.Errorf("rates[%d] = %v, want %v", i, rates[i], want[i])
+		}
+	}
+	if source != ExchangeRatesAPI {
+		t.Errorf("source = %q, want %q", source, ExchangeRatesAPI)
+	}
+}
+
+func TestGetHistoricalRatesRejectsRanges(t *testing.T) {
+	service := newStoreOnlyCurrencyService(t, nil)
+	tomorrow := time.Now().AddDate(0, 0, 1)
+
+	tests := []struct {
+		name       string
+		start, end time.Time
+	}{
+		{"future", tomorrow.AddDate(0, 0, -1), tomorrow},
+		{"reversed", date("2025-09-08"), date("2025-09-05")},
+		{"longer than maxFetchDays", date("2025-09-01"), date("2025-09-01").AddDate(0, 0, maxFetchDays+1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := service.GetHistoricalRates(context.Background(), "EUR", "USD", tt.start, tt.end); err == nil {
+				t.Error("GetHistoricalRates succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestGetCurrencyInfo(t *testing.T) {
+	service := newStoreOnlyCurrencyService(t, nil)
+
+	currency, err := service.GetCurrencyInfo(context.Background(), "COP")
+	if err != nil || currency.Country != "Colombia" {
+		t.Errorf("GetCurrencyInfo(COP) = %v, %v", currency, err)
+	}
+	if _, err := service.GetCurrencyInfo(context.Background(), "XXX"); err == nil {
+		t.Error("GetCurrencyInfo(XXX) succeeded, want an error")
+	}
+}
+
+func TestBacktestForecastFromStore(t *testing.T) {
+	// Weekday rates 1, 1, 1, 1, 2, 1 around a stored Saturday the provider doesn't publish
+	service := newStoreOnlyCurrencyService(t, []domain.HistoryRate{
+		{Date: "2025-09-03", Rate: 1},
+		{Date: "2025-09-04", Rate: 1},
+		{Date: "2025-09-05", Rate: 1},
+		{Date: "2025-09-06", Rate: 5},
+		{Date: "2025-09-08", Rate: 1},
+		{Date: "2025-09-09", Rate: 2},
+		{Date: "2025-09-10", Rate: 1},
+	})
+
+	response, err := service.BacktestForecast(context.Background(), &domain.BacktestRequest{
+		Origin: "EUR", Destination: "USD", Model: "heuristic", From: date("2025-09-01"), To: date("2025-09-10"), Window: 3,
+	})
+	if err != nil {
+		t.Fatalf("BacktestForecast: %v", err)
+	}
+
+	// The golden values of the forecast package, the Saturday left out
+	if response.Forecasts != 3 || !nearly(response.Metrics.RMSE, math.Sqrt(13.0/27)) || !nearly(response.Baseline.RMSE, math.Sqrt(2.0/3)) {
+		t.Errorf("forecasts %d, RMSE %v, baseline RMSE %v", response.Forecasts, response.Metrics.RMSE, response.Baseline.RMSE)
+	}
+	if response.Skill == nil || !nearly(*response.Skill, 1-math.Sqrt(13.0/18)) {
+		t.Errorf("skill = %v, want %v", response.Skill, 1-math.Sqrt(13.0/18))
+	}
+	if len(response.Metrics.Coverage) != 2 || response.Metrics.Coverage[1].Level != 0.95 {
+		t.Errorf("coverage = %+v, want the 80%% and 95%% levels", response.Metrics.Coverage)
+	}
+
+	if _, err := service.BacktestForecast(context.Background(), &domain.BacktestRequest{
+		Origin: "EUR", Destination: "USD", From: date("2025-09-01"), To: date("2025-09-10"),
+	}); !errors.Is(err, forecast.ErrInsufficientData) {
+		t.Errorf("BacktestForecast with the default window error = %v, want ErrInsufficientData", err)
+	}
+}
+
+func TestWindowStats(t *testing.T) {
+	rates := []domain.HistoryRate{
+		{Date: "2025-09-01", Rate: 1},
+		{Date: "2025-09-02", Rate: 4},
+		{Date: "2025-09-03", Rate: 2},
+		{Date: "2025-09-04", Rate: 3},
+	}
+
+	tests := []struct {
+		name  string
+		rates []domain.HistoryRate
+		want  domain.WindowStats
+	}{
+		{"empty", nil, domain.WindowStats{Lookback: 30}},
+		{"one day", rates[:1], domain.WindowStats{Lookback: 30, Start: "2025-09-01", End: "2025-09-01", Count: 1, Mean: 1, Min: 1, Max: 1}},
+		// Deviations -1.5, 1.5, -0.5, 0.5: a sample variance of 5/3
+		{"four days", rates, domain.WindowStats{Lookback: 30, Start: "2025-09-01", End: "2025-09-04", Count: 4, Mean: 2.5, StdDev: math.Sqrt(5.0 / 3), Min: 1, Max: 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := windowStats(tt.rates, 30)
+			if !nearly(got.StdDev, tt.want.StdDev) {
+				t.Errorf("stddev = %v, want %v", got.StdDev, tt.want.StdDev)
+			}
+			got.StdDev, tt.want.StdDev = 0, 0
+			if got != tt.want {
+				t.Errorf("windowStats = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPublishingDaysBack(t *testing.T) {
+	wednesday := date("2025-09-10")
+
+	tests := []struct {
+		name   string
+		source string
+		days   int
+		want   string
+	}{
+		{"same day", ExchangeRatesAPI, 1, "2025-09-10"},
+		{"back to Monday", ExchangeRatesAPI, 3, "2025-09-08"},
+		{"over the weekend", ExchangeRatesAPI, 4, "2025-09-05"},
+		{"two weeks of weekdays", ExchangeRatesAPI, 10, "2025-08-28"},
+		{"every day published", ExchangeRateAPI, 4, "2025-09-07"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := publishingDaysBack(tt.source, wednesday, tt.days).Format("2006-01-02"); got != tt.want {
+				t.Errorf("publishingDaysBack(%d) = %s, want %s", tt.days, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetAnalyticsFromStore(t *testing.T) {
+	// Weekdays 1, 2, 4, 2, 1, 2 with a stored Saturday the provider doesn't publish
+	service := newStoreOnlyCurrencyService(t, []domain.HistoryRate{
+		{Date: "2025-09-03", Rate: 1},
+		{Date: "2025-09-04", Rate: 2},
+		{Date: "2025-09-05", Rate: 4},
+		{Date: "2025-09-06", Rate: 8},
+		{Date: "2025-09-08", Rate: 2},
+		{Date: "2025-09-09", Rate: 1},
+		{Date: "2025-09-10", Rate: 2},
+	})
+
+	response, err := service.GetAnalytics(context.Background(), &domain.AnalyticsRequest{
+		Origin: "EUR", Destination: "USD", StartDate: date("2025-09-01"), EndDate: date("2025-09-10"), Window: 3,
+	})
+	if err != nil {
+		t.Fatalf("GetAnalytics: %v", err)
+	}
+
+	// The golden values of the analytics package, placed on their dates
+	summary := response.Summary
+	if summary.Count != 6 || summary.Min.Date != "2025-09-03" || summary.Max.Date != "2025-09-05" || summary.PercentChange != 100 {
+		t.Errorf("summary = %+v", summary)
+	}
+	want := domain.Drawdown{Percent: 75, PeakDate: "2025-09-05", TroughDate: "2025-09-09"}
+	if summary.MaxDrawdown != want {
+		t.Errorf("max drawdown = %+v, want %+v", summary.MaxDrawdown, want)
+	}
+	last := response.Points[len(response.Points)-1]
+	if last.Date != "2025-09-10" || last.SMA == nil || !nearly(*last.SMA, 5.0/3) || last.EMA == nil || !nearly(*last.EMA, 43.0/24) {
+		t.Errorf("last point = %+v", last)
+	}
+}
+
+func nearly(got, want float64) bool {
+	return math.Abs(got-want) <= 1e-9
+}
@@ -0,0 +1,61 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/joy-currency-conversion-private/domain"
+)
+
+// AlertStateRepository implements domain.AlertStateRepository on MySQL or SQLite
+type AlertStateRepository struct {
+	conn *Conn
+}
+
+// NewAlertStateRepository creates a new AlertStateRepository
+func NewAlertStateRepository(conn *sql.DB, dialect Dialect) *AlertStateRepository {
+	return &AlertStateRepository{conn: WrapConn(conn, dialect)}
+}
+
+// Get returns the alert state of a favorite
+func (r *AlertStateRepository) Get(ctx context.Context, favoriteID string) (*domain.AlertState, error) {
+	var state domain.AlertState
+	var lastNotifiedAt sql.NullTime
+	err := r.conn.QueryRowContext(ctx,
+		`SELECT favorite_id, exceeded, last_rate, last_checked_at, last_notified_at FROM alert_states WHERE favorite_id = ?`,
+		favoriteID,
+	).Scan(&state.FavoriteID, &state.Exceeded, &state.LastRate, &state.LastCheckedAt, &lastNotifiedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("alert state %s: %w", favoriteID, domain.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("db error: %w", err)
+	}
+	if lastNotifiedAt.Valid {
+		state.LastNotifiedAt = &lastNotifiedAt.Time
+	}
+	return &state, nil
+}
+
+// Save stores the alert state of a favorite
+func (r *AlertStateRepository) Save(ctx context.Context, state *domain.AlertState) error {
+	_, err := r.conn.ExecContext(ctx,
+		`INSERT INTO alert_states (favorite_id, exceeded, last_rate, last_checked_at, last_notified_at) VALUES (?, ?, ?, ?, ?) `+
+			r.conn.dialect.upsert([]string{"favorite_id"}, "exceeded", "last_rate", "last_checked_at", "last_notified_at"),
+		state.FavoriteID, state.Exceeded, state.LastRate, state.LastCheckedAt, state.LastNotifiedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("db error: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the alert state of a favorite
+func (r *AlertStateRepository) Delete(ctx context.Context, favoriteID string) error {
+	if _, err := r.conn.ExecContext(ctx, `DELETE FROM alert_states WHERE favorite_id = ?`, favoriteID); err != nil {
+		return fmt.Errorf("db error: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/joy-currency-conversion-private/domain"
+)
+
+// APIKeyRepository implements domain.APIKeyRepository on MySQL or SQLite
+type APIKeyRepository struct {
+	conn *Conn
+}
+
+// NewAPIKeyRepository creates a new APIKeyRepository
+func NewAPIKeyRepository(conn *sql.DB, dialect Dialect) *APIKeyRepository {
+	return &APIKeyRepository{conn: WrapConn(conn, dialect)}
+}
+
+// Create stores a new API key
+func (r *APIKeyRepository) Create(ctx context.Context, key *domain.APIKey) error {
+	_, err := r.conn.ExecContext(ctx,
+		`INSERT INTO api_keys (id, name, prefix, key_hash, scopes, created_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		key.ID, key.Name, key.Prefix, key.Hash, strings.Join(key.Scopes, ","), key.CreatedAt,
+	)
+	if r.conn.dialect.IsDuplicate(err) {
+		return fmt.Errorf("api key %s: %w", key.ID, domain.ErrAlreadyExists)
+	}
+	if err != nil {
+		return fmt.Errorf("db error: %w", err)
+	}
+	return nil
+}
+
+// GetByHash returns the API key with the given hash
+func (r *APIKeyRepository) GetByHash(ctx context.Context, hash string) (*domain.APIKey, error) {
+	row := r.conn.QueryRowContext(ctx,
+		`SELECT id, name, prefix, key_hash, scopes, created_at, revoked_at FROM api_keys WHERE key_hash = ?`, hash)
+
+	key, err := scanAPIKey(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("api key: %w", domain.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("db error: %w", err)
+	}
+	return key, nil
+}
+
+// List returns all API keys
+func (r *APIKeyRepository) List(ctx context.Context) ([]domain.APIKey, error) {
+	rows, err := r.conn.QueryContext(ctx,
+		`SELECT id, name, prefix, key_hash, scopes, created_at, revoked_at FROM api_keys ORDER BY created_at, id`)
+	if err != nil {
+		return nil, fmt.Errorf("db error: %w", err)
+	}
+	defer rows.Close()
+
+	keys := make([]domain.APIKey, 0)
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("db error: %w", err)
+		}
+		keys = append(keys, *key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("db error: %w", err)
+	}
+	return keys, nil
+}
+
+// Revoke marks the API key with the given ID as revoked
+func (r *APIKeyRepository) Revoke(ctx context.Context, id string, at time.Time) error {
+	result, err := r.conn.ExecContext(ctx, `UPDATE api_keys SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL`, at, id)
+	if err != nil {
+		return fmt.Errorf("db error: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("db error: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("api key %s: %w", id, domain.ErrNotFound)
+	}
+	return nil
+}
+
+// ScanAPIKey reads an api_keys row selected as id, name, prefix, key_hash,
+// scopes, created_at, revoked_at
+func scanAPIKey(row interface{ Scan(dest ...any) error }) (*domain.APIKey, error) {
+	var key domain.APIKey
+	var scopes string
+	var revokedAt sql.NullTime
+	if err := row.Scan(&key.ID, &key.Name, &key.Prefix, &key.Hash, &scopes, &key.CreatedAt, &revokedAt); err != nil {
+		return nil, err
+	}
+	key.Scopes = strings.Split(scopes, ",")
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+	return &key, nil
+}
@@ -0,0 +1,90 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/joy-currency-conversion-private/infrastructure/logging"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/joy-currency-conversion-private/infrastructure/db")
+
+// Conn wraps a connection pool so every statement run by the repositories is
+// traced and logged with the caller's context, which carries the request ID
+type Conn struct {
+	*sql.DB
+	dialect Dialect
+}
+
+// WrapConn wraps a connection pool to a database speaking dialect
+func WrapConn(conn *sql.DB, dialect Dialect) *Conn {
+	return &Conn{DB: conn, dialect: dialect}
+}
+
+// ExecContext executes a statement without returning rows
+func (c *Conn) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	ctx, end := c.StartQuery(ctx, query)
+	result, err := c.DB.ExecContext(ctx, query, args...)
+	end(err)
+	return result, err
+}
+
+// QueryContext executes a query returning rows
+func (c *Conn) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	ctx, end := c.StartQuery(ctx, query)
+	rows, err := c.DB.QueryContext(ctx, query, args...)
+	end(err)
+	return rows, err
+}
+
+// QueryRowContext executes a query returning at most one row
+func (c *Conn) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	ctx, end := c.StartQuery(ctx, query)
+	row := c.DB.QueryRowContext(ctx, query, args...)
+	end(row.Err())
+	return row
+}
+
+// StartQuery opens a span for a statement and returns the function that ends
+// it and logs the statement. It is used directly for statements run inside a
+// transaction.
+func (c *Conn) StartQuery(ctx context.Context, query string) (context.Context, func(err error)) {
+	statement := logging.CompactSQL(query)
+	operation, _, _ := strings.Cut(statement, " ")
+
+	ctx, span := tracer.Start(ctx, "sql "+strings.ToUpper(operation),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", c.dialect.Name),
+			attribute.String("db.statement", statement),
+		),
+	)
+	start := time.Now()
+
+	return ctx, func(err error) {
+		defer span.End()
+
+		attrs := []any{
+			"statement", statement,
+			"duration_ms", time.Since(start).Milliseconds(),
+		}
+		// Missing rows and duplicate keys are answers the repositories expect
+		if err != nil && err != sql.ErrNoRows && !c.dialect.IsDuplicate(err) {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			slog.ErrorContext(ctx, "sql statement failed", append(attrs, "error", err)...)
+			return
+		}
+		if err != nil {
+			attrs = append(attrs, "error", err)
+		}
+		slog.DebugContext(ctx, "sql statement", attrs...)
+	}
+}
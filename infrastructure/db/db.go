@@ -3,92 +3,93 @@ package db
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
+	"sync/atomic"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/service/ssm"
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 )
 
-var DB *sql.DB
+// maxIdleConns is how many idle connections the pool keeps open
+const maxIdleConns = 5
 
-// Connect opens DB connection using env vars
-func Connect() error {
-	// Comment the way we are getting the environment variables to use the local database
-	
-	// user := os.Getenv("DB_USER")
-	// pass := os.Getenv("DB_PASSWORD")
-	// host := os.Getenv("DB_HOST")
-	// port := os.Getenv("DB_PORT")
-	// name := os.Getenv("DB_NAME")
+// Connector opens MySQL connections with credentials that can be rotated
+// while the pool is in use
+type Connector struct {
+	name string
+	dsn  atomic.Pointer[string]
+}
+
+// NewConnector creates a Connector to the database name with the given credentials
+func NewConnector(user, pass, host, port, name string) *Connector {
+	c := &Connector{name: name}
+	dsn := c.format(user, pass, host, port)
+	c.dsn.Store(&dsn)
+	return c
+}
 
-	// if user == "" || pass == "" || host == "" || name == "" {
-	// 	return fmt.Errorf("database configuration missing")
-	// }
+func (c *Connector) format(user, pass, host, port string) string {
+	return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true&multiStatements=true", user, pass, host, port, c.name)
+}
 
-	// Get from Parameter Store
-	fmt.Println("Fetching DB credentials from Parameter Store...")
-	user, pass, host, port, name, err := fetchDBCredentials()
+// Connect opens a connection with the current credentials, implementing driver.Connector
+func (c *Connector) Connect(ctx context.Context) (driver.Conn, error) {
+	connector, err := mysql.MySQLDriver{}.OpenConnector(*c.dsn.Load())
 	if err != nil {
-		return fmt.Errorf("fetching credentials: %w", err)
+		return nil, err
 	}
+	return connector.Connect(ctx)
+}
+
+// Driver returns the MySQL driver, implementing driver.Connector
+func (c *Connector) Driver() driver.Driver {
+	return mysql.MySQLDriver{}
+}
 
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true&multiStatements=true", user, pass, host, port, name)
+// Rotate switches the pool conn to new credentials. They are tried on a
+// connection of their own first, on failure the current ones are kept. Idle
+// connections are closed, the ones in use are replaced once they reach
+// ConnMaxLifetime. It reports whether the credentials changed.
+func (c *Connector) Rotate(ctx context.Context, conn *sql.DB, user, pass, host, port string) (bool, error) {
+	dsn := c.format(user, pass, host, port)
+	if dsn == *c.dsn.Load() {
+		return false, nil
+	}
 
-	DB, err = sql.Open("mysql", dsn)
+	connector, err := mysql.MySQLDriver{}.OpenConnector(dsn)
 	if err != nil {
-		return err
+		return false, err
+	}
+	test := sql.OpenDB(connector)
+	defer test.Close()
+	if err := test.PingContext(ctx); err != nil {
+		return false, fmt.Errorf("connecting with the new credentials: %w", err)
 	}
 
+	c.dsn.Store(&dsn)
+	conn.SetMaxIdleConns(0)
+	conn.SetMaxIdleConns(maxIdleConns)
+	return true, nil
+}
+
+// Connect opens a MySQL connection pool through connector
+func Connect(connector *Connector) (*sql.DB, error) {
+	conn := sql.OpenDB(connector)
+
 	// reasonable connection settings
-	DB.SetConnMaxLifetime(5 * time.Minute)
-	DB.SetMaxOpenConns(10)
-	DB.SetMaxIdleConns(5)
+	conn.SetConnMaxLifetime(5 * time.Minute)
+	conn.SetMaxOpenConns(10)
+	conn.SetMaxIdleConns(maxIdleConns)
 
 	// Wait for db to be ready (optional)
+	var err error
 	for i := 0; i < 10; i++ {
-		if err = DB.Ping(); err == nil {
-			return nil
+		if err = conn.Ping(); err == nil {
+			return conn, nil
 		}
 		time.Sleep(1 * time.Second)
 	}
-	return fmt.Errorf("could not connect to db: %w", err)
+	conn.Close()
+	return nil, fmt.Errorf("could not connect to db: %w", err)
 }
-
-func fetchDBCredentials() (user, pass, host, port, name string, err error) {
-	ctx := context.TODO()
-	cfg, err := config.LoadDefaultConfig(ctx)
-	if err != nil {
-		return "", "", "", "", "", err
-	}
-
-	client := ssm.NewFromConfig(cfg)
-
-	params := []string{
-		"/exchange-rate/mysql/host",
-		"/exchange-rate/mysql/port",
-		"/exchange-rate/mysql/user",
-		"/exchange-rate/mysql/password",
-	}
-
-	out, err := client.GetParameters(ctx, &ssm.GetParametersInput{
-		Names:          params,
-		WithDecryption: aws.Bool(true),
-	})
-	if err != nil {
-		return "", "", "", "", "", err
-	}
-
-	values := map[string]string{}
-	for _, p := range out.Parameters {
-		values[*p.Name] = *p.Value
-	}
-
-	return values["/exchange-rate/mysql/user"],
-		values["/exchange-rate/mysql/password"],
-		values["/exchange-rate/mysql/host"],
-		values["/exchange-rate/mysql/port"],
-		"exchange_db", nil
-}
\ No newline at end of file
@@ -0,0 +1,54 @@
+package db
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// Dialect holds the SQL that differs between the databases the repositories
+// run on, the rest of every statement is shared
+type Dialect struct {
+	// Name identifies the database in traces, e.g. "mysql"
+	Name string
+
+	// OnConflict starts the clause of an INSERT updating the row that
+	// conflicts with it on the unique keys
+	OnConflict func(keys ...string) string
+
+	// Inserted refers to the value a column would have been inserted with,
+	// inside the OnConflict clause
+	Inserted func(column string) string
+
+	// LockRows is appended to a SELECT to lock the rows read until the
+	// transaction ends, empty where writers are serialized anyway
+	LockRows string
+
+	// IsDuplicate reports whether err violates a primary key or unique constraint
+	IsDuplicate func(err error) bool
+}
+
+// mysqlDuplicateEntry is the MySQL error number for unique key violations
+const mysqlDuplicateEntry = 1062
+
+// MySQL is the dialect of MySQL 8
+var MySQL = Dialect{
+	Name:       "mysql",
+	OnConflict: func(keys ...string) string { return "ON DUPLICATE KEY UPDATE" },
+	Inserted:   func(column string) string { return "VALUES(" + column + ")" },
+	LockRows:   " FOR UPDATE",
+	IsDuplicate: func(err error) bool {
+		var mysqlErr *mysql.MySQLError
+		return errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry
+	},
+}
+
+// upsert returns the clause replacing columns of the conflicting row with the inserted values
+func (d Dialect) upsert(keys []string, columns ...string) string {
+	assignments := make([]string, len(columns))
+	for i, column := range columns {
+		assignments[i] = column + " = " + d.Inserted(column)
+	}
+	return d.OnConflict(keys...) + " " + strings.Join(assignments, ", ")
+}
@@ -0,0 +1,140 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/joy-currency-conversion-private/domain"
+)
+
+// FavoriteRepository implements domain.FavoriteRepository on MySQL or SQLite
+type FavoriteRepository struct {
+	conn *Conn
+}
+
+// NewFavoriteRepository creates a new FavoriteRepository
+func NewFavoriteRepository(conn *sql.DB, dialect Dialect) *FavoriteRepository {
+	return &FavoriteRepository{conn: WrapConn(conn, dialect)}
+}
+
+// Create stores a new favorite, the unique index on its UniqueKey rejects duplicates
+func (r *FavoriteRepository) Create(ctx context.Context, favorite *domain.Favorite) error {
+	_, err := r.conn.ExecContext(ctx,
+		`INSERT INTO favorites (id, origin, destination, threshold, notify_email, owner_id, unique_key, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		favorite.ID, favorite.Origin.Code, favorite.Destination.Code, favorite.Threshold, favorite.NotifyEmail,
+		sql.NullString{String: favorite.OwnerID, Valid: favorite.OwnerID != ""}, favorite.UniqueKey(), favorite.CreatedAt,
+	)
+	if r.conn.dialect.IsDuplicate(err) {
+		return fmt.Errorf("favorite %s: %w", favorite.ID, domain.ErrAlreadyExists)
+	}
+	if err != nil {
+		return fmt.Errorf("db error: %w", err)
+	}
+	return nil
+}
+
+// Get returns the favorite with the given ID
+func (r *FavoriteRepository) Get(ctx context.Context, id string) (*domain.Favorite, error) {
+	row := r.conn.QueryRowContext(ctx,
+		`SELECT id, origin, destination, threshold, notify_email, owner_id, created_at FROM favorites WHERE id = ?`, id)
+
+	favorite, err := scanFavorite(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("favorite %s: %w", id, domain.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("db error: %w", err)
+	}
+	return favorite, nil
+}
+
+// List returns all stored favorites
+func (r *FavoriteRepository) List(ctx context.Context) ([]domain.Favorite, error) {
+	rows, err := r.conn.QueryContext(ctx,
+		`SELECT id, origin, destination, threshold, notify_email, owner_id, created_at FROM favorites ORDER BY created_at, id`)
+	if err != nil {
+		return nil, fmt.Errorf("db error: %w", err)
+	}
+	return scanFavorites(rows)
+}
+
+// ListByOwner returns the favorites of the given user
+func (r *FavoriteRepository) ListByOwner(ctx context.Context, ownerID string) ([]domain.Favorite, error) {
+	rows, err := r.conn.QueryContext(ctx,
+		`SELECT id, origin, destination, threshold, notify_email, owner_id, created_at FROM favorites WHERE owner_id = ? ORDER BY created_at, id`,
+		ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("db error: %w", err)
+	}
+	return scanFavorites(rows)
+}
+
+// ListByEmail returns the favorites notifying the given email
+func (r *FavoriteRepository) ListByEmail(ctx context.Context, email string) ([]domain.Favorite, error) {
+	rows, err := r.conn.QueryContext(ctx,
+		`SELECT id, origin, destination, threshold, notify_email, owner_id, created_at FROM favorites WHERE notify_email = ? ORDER BY created_at, id`,
+		email)
+	if err != nil {
+		return nil, fmt.Errorf("db error: %w", err)
+	}
+	return scanFavorites(rows)
+}
+
+func scanFavorites(rows *sql.Rows) ([]domain.Favorite, error) {
+	defer rows.Close()
+
+	favorites := make([]domain.Favorite, 0)
+	for rows.Next() {
+		favorite, err := scanFavorite(rows)
+		if err != nil {
+			return nil, fmt.Errorf("db error: %w", err)
+		}
+		favorites = append(favorites, *favorite)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("db error: %w", err)
+	}
+	return favorites, nil
+}
+
+// Delete removes the favorite with the given ID
+func (r *FavoriteRepository) Delete(ctx context.Context, id string) error {
+	result, err := r.conn.ExecContext(ctx, `DELETE FROM favorites WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("db error: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("db error: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("favorite %s: %w", id, domain.ErrNotFound)
+	}
+	return nil
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanFavorite(row rowScanner) (*domain.Favorite, error) {
+	var favorite domain.Favorite
+	var ownerID sql.NullString
+	err := row.Scan(
+		&favorite.ID,
+		&favorite.Origin.Code,
+		&favorite.Destination.Code,
+		&favorite.Threshold,
+		&favorite.NotifyEmail,
+		&ownerID,
+		&favorite.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	favorite.OwnerID = ownerID.String
+	return &favorite, nil
+}
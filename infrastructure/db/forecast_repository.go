@@ -0,0 +1,71 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/joy-currency-conversion-private/domain"
+)
+
+// ForecastRepository implements domain.ForecastRepository on MySQL or SQLite
+type ForecastRepository struct {
+	conn *Conn
+}
+
+// NewForecastRepository creates a new ForecastRepository
+func NewForecastRepository(conn *sql.DB, dialect Dialect) *ForecastRepository {
+	return &ForecastRepository{conn: WrapConn(conn, dialect)}
+}
+
+// Get returns the stored forecast for a pair, model, horizon and lookback
+func (r *ForecastRepository) Get(ctx context.Context, origin, destination, model string, horizon, lookback int) (*domain.StoredForecast, error) {
+	forecast := domain.StoredForecast{
+		Origin:      origin,
+		Destination: destination,
+		Model:       model,
+		Horizon:     horizon,
+		Lookback:    lookback,
+	}
+	var endDate time.Time
+	var response string
+	err := r.conn.QueryRowContext(ctx,
+		`SELECT model_version, inputs_hash, end_date, response, computed_at FROM forecasts
+		WHERE origin = ? AND destination = ? AND model = ? AND horizon = ? AND lookback = ?`,
+		origin, destination, model, horizon, lookback,
+	).Scan(&forecast.ModelVersion, &forecast.InputsHash, &endDate, &response, &forecast.ComputedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("forecast %s/%s %s: %w", origin, destination, model, domain.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("db error: %w", err)
+	}
+	forecast.EndDate = endDate.Format("2006-01-02")
+	if err := json.Unmarshal([]byte(response), &forecast.Response); err != nil {
+		return nil, fmt.Errorf("decoding stored forecast: %w", err)
+	}
+	return &forecast, nil
+}
+
+// Save stores a forecast, replacing the previous one
+func (r *ForecastRepository) Save(ctx context.Context, forecast *domain.StoredForecast) error {
+	response, err := json.Marshal(forecast.Response)
+	if err != nil {
+		return fmt.Errorf("encoding forecast: %w", err)
+	}
+	_, err = r.conn.ExecContext(ctx,
+		`INSERT INTO forecasts (origin, destination, model, horizon, lookback, model_version, inputs_hash, end_date, response, computed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?) `+
+			r.conn.dialect.upsert([]string{"origin", "destination", "model", "horizon", "lookback"},
+				"model_version", "inputs_hash", "end_date", "response", "computed_at"),
+		forecast.Origin, forecast.Destination, forecast.Model, forecast.Horizon, forecast.Lookback,
+		forecast.ModelVersion, forecast.InputsHash, forecast.EndDate, string(response), forecast.ComputedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("db error: %w", err)
+	}
+	return nil
+}
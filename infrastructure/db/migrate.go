@@ -0,0 +1,253 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migration is a single numbered schema change with its up and down scripts
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationState describes whether a migration has been applied
+type MigrationState struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+const createMigrationsTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+  version BIGINT PRIMARY KEY,
+  name VARCHAR(255) NOT NULL,
+  applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+)`
+
+// LoadMigrations reads the embedded migrations of a driver ordered by version.
+// Files are named <version>_<name>.up.sql and <version>_<name>.down.sql; a
+// <version>_<name>.<up|down>.<driver>.sql script replaces the portable one on
+// that driver, for the few statements MySQL and SQLite spell differently.
+func LoadMigrations(driver string) ([]Migration, error) {
+	files, err := fs.Sub(migrationFiles, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("reading migrations: %w", err)
+	}
+	return loadMigrations(files, driver)
+}
+
+// loadMigrations reads the migrations of a driver from the files of a directory
+func loadMigrations(files fs.FS, driver string) ([]Migration, error) {
+	entries, err := fs.ReadDir(files, ".")
+	if err != nil {
+		return nil, fmt.Errorf("reading migrations: %w", err)
+	}
+
+	byVersion := map[int]*Migration{}
+	specific := map[string]bool{} // version and direction replaced by a driver script
+	for _, entry := range entries {
+		fileName := entry.Name()
+		if !strings.HasSuffix(fileName, ".sql") {
+			continue
+		}
+		parts := strings.Split(strings.TrimSuffix(fileName, ".sql"), ".")
+		if len(parts) < 2 || len(parts) > 3 || (parts[1] != "up" && parts[1] != "down") {
+			continue
+		}
+		base, direction := parts[0], parts[1]
+		if len(parts) == 3 && parts[2] != driver {
+			continue
+		}
+
+		versionStr, name, found := strings.Cut(base, "_")
+		if !found {
+			return nil, fmt.Errorf("invalid migration file name %s", fileName)
+		}
+		version, err := strconv.Atoi(versionStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", fileName, err)
+		}
+
+		content, err := fs.ReadFile(files, fileName)
+		if err != nil {
+			return nil, fmt.Errorf("reading migration %s: %w", fileName, err)
+		}
+
+		key := versionStr + "." + direction
+		if specific[key] {
+			continue
+		}
+		specific[key] = len(parts) == 3
+
+		m, exists := byVersion[version]
+		if !exists {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		} else if m.Name != name {
+			return nil, fmt.Errorf("migration version %d used by %s and %s", version, m.Name, name)
+		}
+		if direction == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// MigrateUp applies every pending migration and returns how many were applied
+func MigrateUp(ctx context.Context, conn *sql.DB, driver string) (int, error) {
+	migrations, err := LoadMigrations(driver)
+	if err != nil {
+		return 0, err
+	}
+	return migrateUp(ctx, conn, migrations)
+}
+
+// migrateUp applies the pending ones of migrations in order, stopping at the first failure
+func migrateUp(ctx context.Context, conn *sql.DB, migrations []Migration) (int, error) {
+	applied, err := appliedVersions(ctx, conn)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, m := range migrations {
+		if _, done := applied[m.Version]; done {
+			continue
+		}
+		if err := runMigration(ctx, conn, m.Up, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name) VALUES (?, ?)`, m.Version, m.Name)
+			return err
+		}); err != nil {
+			return count, fmt.Errorf("applying migration %d_%s: %w", m.Version, m.Name, err)
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// MigrateDown reverts the latest applied migrations, at most steps of them
+func MigrateDown(ctx context.Context, conn *sql.DB, driver string, steps int) (int, error) {
+	migrations, err := LoadMigrations(driver)
+	if err != nil {
+		return 0, err
+	}
+
+	applied, err := appliedVersions(ctx, conn)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for i := len(migrations) - 1; i >= 0 && count < steps; i-- {
+		m := migrations[i]
+		if _, done := applied[m.Version]; !done {
+			continue
+		}
+		if m.Down == "" {
+			return count, fmt.Errorf("migration %d_%s has no down script", m.Version, m.Name)
+		}
+		if err := runMigration(ctx, conn, m.Down, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = ?`, m.Version)
+			return err
+		}); err != nil {
+			return count, fmt.Errorf("reverting migration %d_%s: %w", m.Version, m.Name, err)
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// MigrationStatus lists every known migration and when it was applied
+func MigrationStatus(ctx context.Context, conn *sql.DB, driver string) ([]MigrationState, error) {
+	migrations, err := LoadMigrations(driver)
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := appliedVersions(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	states := make([]MigrationState, 0, len(migrations))
+	for _, m := range migrations {
+		state := MigrationState{Migration: m}
+		if appliedAt, done := applied[m.Version]; done {
+			state.AppliedAt = &appliedAt
+		}
+		states = append(states, state)
+	}
+
+	return states, nil
+}
+
+func appliedVersions(ctx context.Context, conn *sql.DB) (map[int]time.Time, error) {
+	if _, err := conn.ExecContext(ctx, createMigrationsTable); err != nil {
+		return nil, fmt.Errorf("creating schema_migrations: %w", err)
+	}
+
+	rows, err := conn.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("reading schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := map[int]time.Time{}
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("reading schema_migrations: %w", err)
+		}
+		applied[version] = appliedAt
+	}
+
+	return applied, rows.Err()
+}
+
+// runMigration executes a script and records the change in the same transaction.
+// MySQL commits DDL implicitly, so the transaction mainly keeps the bookkeeping
+// consistent with the statements that did run.
+func runMigration(ctx context.Context, conn *sql.DB, script string, record func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if strings.TrimSpace(script) != "" {
+		if _, err := tx.ExecContext(ctx, script); err != nil {
+			return err
+		}
+	}
+	if err := record(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
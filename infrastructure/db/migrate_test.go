@@ -0,0 +1,289 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	_ "modernc.org/sqlite"
+)
+
+// openSQLite returns an empty in-memory SQLite database, kept on one
+// connection since every connection to ":memory:" gets its own database
+func openSQLite(t *testing.T) *sql.DB {
+	t.Helper()
+	conn, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	conn.SetMaxOpenConns(1)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// recorded returns the versions in schema_migrations, in order
+func recorded(t *testing.T, conn *sql.DB) []int {
+	t.Helper()
+	rows, err := conn.Query(`SELECT version FROM schema_migrations ORDER BY version`)
+	if err != nil {
+		t.Fatalf("reading schema_migrations: %v", err)
+	}
+	defer rows.Close()
+	var versions []int
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			t.Fatalf("reading schema_migrations: %v", err)
+		}
+		versions = append(versions, version)
+	}
+	return versions
+}
+
+// tables returns the names of the tables in a SQLite database
+func tables(t *testing.T, conn *sql.DB) []string {
+	t.Helper()
+	rows, err := conn.Query(`SELECT name FROM sqlite_master WHERE type = 'table' ORDER BY name`)
+	if err != nil {
+		t.Fatalf("listing tables: %v", err)
+	}
+	defer rows.Close()
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatalf("listing tables: %v", err)
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+func TestLoadMigrations(t *testing.T) {
+	for _, driver := range []string{"mysql", "sqlite"} {
+		t.Run(driver, func(t *testing.T) {
+			migrations, err := LoadMigrations(driver)
+			if err != nil {
+				t.Fatalf("LoadMigrations: %v", err)
+			}
+			for i, m := range migrations {
+				if m.Version != i+1 {
+					t.Fatalf("migration %d has version %d, want versions 1, 2, 3... in order", i, m.Version)
+				}
+				if m.Up == "" || m.Down == "" {
+					t.Errorf("migration %d_%s misses a script", m.Version, m.Name)
+				}
+			}
+		})
+	}
+
+	// The driver scripts replace the portable ones on their driver only
+	scripts := []struct {
+		driver  string
+		version int
+		script  func(m Migration) string
+		want    string
+	}{
+		{"mysql", 9, func(m Migration) string { return m.Down }, "DROP INDEX idx_favorites_owner_id ON favorites;"},
+		{"sqlite", 9, func(m Migration) string { return m.Down }, "DROP INDEX idx_favorites_owner_id;"},
+		{"mysql", 11, func(m Migration) string { return m.Up }, "CONCAT('email:'"},
+		{"sqlite", 11, func(m Migration) string { return m.Up }, "'email:' || notify_email"},
+	}
+	for _, tt := range scripts {
+		migrations, err := LoadMigrations(tt.driver)
+		if err != nil {
+			t.Fatalf("LoadMigrations(%s): %v", tt.driver, err)
+		}
+		m := migrations[tt.version-1]
+		if got := tt.script(m); !strings.Contains(got, tt.want) {
+			t.Errorf("%s script of %d_%s = %q, want it to contain %q", tt.driver, m.Version, m.Name, got, tt.want)
+		}
+	}
+}
+
+func TestLoadMigrationsFiles(t *testing.T) {
+	file := func(content string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(content)} }
+
+	tests := []struct {
+		name    string
+		files   fstest.MapFS
+		driver  string
+		want    []Migration
+		wantErr string
+	}{
+		{
+			name: "ordered by version",
+			files: fstest.MapFS{
+				"10_c.up.sql":  file("c"),
+				"2_b.up.sql":   file("b"),
+				"1_a.up.sql":   file("a"),
+				"1_a.down.sql": file("undo a"),
+				"README.md":    file("not a migration"),
+				"1_a.sql":      file("no direction"),
+			},
+			driver: "sqlite",
+			want:   []Migration{{1, "a", "a", "undo a"}, {2, "b", "b", ""}, {10, "c", "c", ""}},
+		},
+		{
+			name: "driver script listed after the portable one",
+			files: fstest.MapFS{
+				"1_a.up.sql":          file("a"),
+				"1_a.down.sql":        file("portable"),
+				"1_a.down.sqlite.sql": file("sqlite"),
+			},
+			driver: "sqlite",
+			want:   []Migration{{1, "a", "a", "sqlite"}},
+		},
+		{
+			name: "driver script listed before the portable one",
+			files: fstest.MapFS{
+				"1_a.up.sql":         file("a"),
+				"1_a.down.mysql.sql": file("mysql"),
+				"1_a.down.sql":       file("portable"),
+			},
+			driver: "mysql",
+			want:   []Migration{{1, "a", "a", "mysql"}},
+		},
+		{
+			name: "other driver's script ignored",
+			files: fstest.MapFS{
+				"1_a.up.sql":         file("a"),
+				"1_a.up.mysql.sql":   file("mysql"),
+				"1_a.down.sql":       file("portable"),
+				"1_a.down.mysql.sql": file("mysql"),
+			},
+			driver: "sqlite",
+			want:   []Migration{{1, "a", "a", "portable"}},
+		},
+		{
+			name:    "version used twice",
+			files:   fstest.MapFS{"1_a.up.sql": file("a"), "1_b.up.sql": file("b")},
+			driver:  "sqlite",
+			wantErr: "used by",
+		},
+		{
+			name:    "no up script",
+			files:   fstest.MapFS{"1_a.down.sql": file("undo a")},
+			driver:  "sqlite",
+			wantErr: "no up script",
+		},
+		{
+			name:    "invalid version",
+			files:   fstest.MapFS{"one_a.up.sql": file("a")},
+			driver:  "sqlite",
+			wantErr: "invalid migration version",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := loadMigrations(tt.files, tt.driver)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("loadMigrations error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadMigrations: %v", err)
+			}
+			if len(migrations) != len(tt.want) {
+				t.Fatalf("migrations = %+v, want %+v", migrations, tt.want)
+			}
+			for i := range tt.want {
+				if migrations[i] != tt.want[i] {
+					t.Errorf("migration %d = %+v, want %+v", i, migrations[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMigrateUpAndDown(t *testing.T) {
+	conn := openSQLite(t)
+	ctx := context.Background()
+	migrations, err := LoadMigrations("sqlite")
+	if err != nil {
+		t.Fatalf("LoadMigrations: %v", err)
+	}
+	latest := migrations[len(migrations)-1].Version
+
+	applied, err := MigrateUp(ctx, conn, "sqlite")
+	if err != nil || applied != len(migrations) {
+		t.Fatalf("MigrateUp = %d, %v; want all %d applied", applied, err, len(migrations))
+	}
+	if versions := recorded(t, conn); len(versions) != len(migrations) || versions[len(versions)-1] != latest {
+		t.Errorf("schema_migrations versions = %v, want 1 to %d", versions, latest)
+	}
+	var name string
+	if err := conn.QueryRow(`SELECT name FROM schema_migrations WHERE version = 1`).Scan(&name); err != nil || name != migrations[0].Name {
+		t.Errorf("name of version 1 = %q, %v; want %q", name, err, migrations[0].Name)
+	}
+	if applied, err := MigrateUp(ctx, conn, "sqlite"); err != nil || applied != 0 {
+		t.Errorf("MigrateUp again = %d, %v; want nothing left to apply", applied, err)
+	}
+
+	// Two steps down revert the latest two, the status lists them as pending
+	if reverted, err := MigrateDown(ctx, conn, "sqlite", 2); err != nil || reverted != 2 {
+		t.Fatalf("MigrateDown(2) = %d, %v; want 2 reverted", reverted, err)
+	}
+	states, err := MigrationStatus(ctx, conn, "sqlite")
+	if err != nil {
+		t.Fatalf("MigrationStatus: %v", err)
+	}
+	for _, state := range states {
+		pending := state.Version > latest-2
+		if (state.AppliedAt == nil) != pending {
+			t.Errorf("migration %d_%s applied at %v, want pending %v", state.Version, state.Name, state.AppliedAt, pending)
+		}
+	}
+
+	// Everything down leaves only the bookkeeping table, and back up again
+	if reverted, err := MigrateDown(ctx, conn, "sqlite", len(migrations)); err != nil || reverted != len(migrations)-2 {
+		t.Fatalf("MigrateDown(all) = %d, %v; want %d reverted", reverted, err, len(migrations)-2)
+	}
+	if versions := recorded(t, conn); len(versions) != 0 {
+		t.Errorf("schema_migrations versions after rolling back all = %v, want none", versions)
+	}
+	if names := tables(t, conn); len(names) != 1 || names[0] != "schema_migrations" {
+		t.Errorf("tables after rolling back all = %v, want only schema_migrations", names)
+	}
+	if applied, err := MigrateUp(ctx, conn, "sqlite"); err != nil || applied != len(migrations) {
+		t.Errorf("MigrateUp after rolling back all = %d, %v; want all %d applied", applied, err, len(migrations))
+	}
+}
+
+func TestFailingMigrationNotRecorded(t *testing.T) {
+	conn := openSQLite(t)
+	ctx := context.Background()
+	migrations := []Migration{
+		{Version: 1, Name: "a", Up: `CREATE TABLE a (id INTEGER)`},
+		{Version: 2, Name: "b", Up: `CREATE TABLE b (id INTEGER); INSERT INTO missing VALUES (1)`},
+		{Version: 3, Name: "c", Up: `CREATE TABLE c (id INTEGER)`},
+	}
+
+	applied, err := migrateUp(ctx, conn, migrations)
+	if err == nil || !strings.Contains(err.Error(), "2_b") {
+		t.Fatalf("migrateUp error = %v, want migration 2_b failing", err)
+	}
+	if applied != 1 {
+		t.Errorf("%d migrations applied, want 1 before the failure", applied)
+	}
+	if versions := recorded(t, conn); len(versions) != 1 || versions[0] != 1 {
+		t.Errorf("schema_migrations versions = %v, want only 1", versions)
+	}
+	// SQLite runs DDL in the transaction, so the failed script left nothing behind
+	if names := tables(t, conn); strings.Join(names, ",") != "a,schema_migrations" {
+		t.Errorf("tables = %v, want a and schema_migrations only", names)
+	}
+
+	// Once fixed, the failed migration and the ones after it are applied
+	migrations[1].Up = `CREATE TABLE b (id INTEGER)`
+	if applied, err := migrateUp(ctx, conn, migrations); err != nil || applied != 2 {
+		t.Errorf("migrateUp after the fix = %d, %v; want 2 applied", applied, err)
+	}
+	if versions := recorded(t, conn); len(versions) != 3 {
+		t.Errorf("schema_migrations versions = %v, want 1, 2 and 3", versions)
+	}
+}
@@ -5,4 +5,4 @@ CREATE TABLE IF NOT EXISTS favorites (
   threshold DECIMAL(20,10) NOT NULL,
   notify_email VARCHAR(255) NOT NULL,
   created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-);
\ No newline at end of file
+);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS exchange_rates (
+  origin CHAR(3) NOT NULL,
+  destination CHAR(3) NOT NULL,
+  rate_date DATE NOT NULL,
+  rate DECIMAL(20,10) NOT NULL,
+  source VARCHAR(100) NOT NULL,
+  fetched_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (origin, destination, rate_date)
+);
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS alert_states (
+  favorite_id VARCHAR(50) PRIMARY KEY,
+  exceeded BOOLEAN NOT NULL,
+  last_rate DECIMAL(20,10) NOT NULL,
+  last_checked_at TIMESTAMP NOT NULL,
+  last_notified_at TIMESTAMP NULL
+);
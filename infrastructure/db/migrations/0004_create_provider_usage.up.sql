@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS provider_usage (
+  provider VARCHAR(50) NOT NULL,
+  period CHAR(7) NOT NULL,
+  calls INT NOT NULL,
+  updated_at TIMESTAMP NOT NULL,
+  PRIMARY KEY (provider, period)
+);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+  id VARCHAR(50) PRIMARY KEY,
+  name VARCHAR(100) NOT NULL,
+  prefix VARCHAR(16) NOT NULL,
+  key_hash CHAR(64) NOT NULL UNIQUE,
+  scopes VARCHAR(255) NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+  revoked_at TIMESTAMP NULL
+);
@@ -0,0 +1,3 @@
+ALTER TABLE favorites DROP COLUMN owner_id;
+
+DROP TABLE IF EXISTS users;
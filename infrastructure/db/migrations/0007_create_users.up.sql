@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS users (
+  id VARCHAR(50) PRIMARY KEY,
+  email VARCHAR(255) NOT NULL UNIQUE,
+  password_hash VARCHAR(100) NOT NULL,
+  role VARCHAR(20) NOT NULL,
+  created_at TIMESTAMP NOT NULL
+);
+
+ALTER TABLE favorites ADD COLUMN owner_id VARCHAR(50) NULL;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS forecasts (
+  origin CHAR(3) NOT NULL,
+  destination CHAR(3) NOT NULL,
+  model VARCHAR(20) NOT NULL,
+  horizon INT NOT NULL,
+  lookback INT NOT NULL,
+  model_version VARCHAR(20) NOT NULL,
+  inputs_hash CHAR(64) NOT NULL,
+  end_date DATE NOT NULL,
+  response TEXT NOT NULL,
+  computed_at TIMESTAMP NOT NULL,
+  PRIMARY KEY (origin, destination, model, horizon, lookback)
+);
@@ -0,0 +1 @@
+DROP INDEX idx_favorites_owner_id;
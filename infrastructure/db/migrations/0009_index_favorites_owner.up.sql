@@ -0,0 +1 @@
+CREATE INDEX idx_favorites_owner_id ON favorites (owner_id);
@@ -0,0 +1,2 @@
+DROP INDEX idx_favorites_unique_key ON favorites;
+ALTER TABLE favorites DROP COLUMN unique_key;
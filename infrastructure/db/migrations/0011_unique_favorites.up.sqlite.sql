@@ -0,0 +1,15 @@
+ALTER TABLE favorites ADD COLUMN unique_key VARCHAR(300) NULL;
+
+-- Favorite.UniqueKey of the existing favorites, duplicates saved before keep no key
+UPDATE favorites SET unique_key = CASE
+    WHEN owner_id IS NULL THEN 'email:' || notify_email || '|' || origin || '|' || destination
+    ELSE 'owner:' || owner_id || '|' || origin || '|' || destination
+  END
+WHERE id IN (
+  SELECT id FROM (
+    SELECT MIN(id) AS id FROM favorites
+    GROUP BY owner_id, CASE WHEN owner_id IS NULL THEN notify_email END, origin, destination
+  ) AS firsts
+);
+
+CREATE UNIQUE INDEX idx_favorites_unique_key ON favorites (unique_key);
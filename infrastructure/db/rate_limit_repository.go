@@ -0,0 +1,74 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/joy-currency-conversion-private/domain"
+)
+
+// RateLimitRepository implements domain.RateLimitRepository on MySQL or SQLite, so
+// every MySQL backed instance of the API shares the same buckets
+type RateLimitRepository struct {
+	conn        *Conn
+	selectQuery string
+	saveQuery   string
+}
+
+// NewRateLimitRepository creates a new RateLimitRepository
+func NewRateLimitRepository(conn *sql.DB, dialect Dialect) *RateLimitRepository {
+	return &RateLimitRepository{
+		conn:        WrapConn(conn, dialect),
+		selectQuery: `SELECT tokens, updated_at FROM rate_limit_buckets WHERE bucket_key = ?` + dialect.LockRows,
+		saveQuery: `INSERT INTO rate_limit_buckets (bucket_key, tokens, updated_at) VALUES (?, ?, ?) ` +
+			dialect.upsert([]string{"bucket_key"}, "tokens", "updated_at"),
+	}
+}
+
+// Update replaces the bucket stored under key inside a transaction, locking its row meanwhile
+func (r *RateLimitRepository) Update(ctx context.Context, key string, fn func(bucket *domain.RateLimitBucket) domain.RateLimitBucket) error {
+	tx, err := r.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("db error: %w", err)
+	}
+	defer tx.Rollback()
+
+	var current *domain.RateLimitBucket
+	var tokens float64
+	var updatedAt int64
+	queryCtx, end := r.conn.StartQuery(ctx, r.selectQuery)
+	err = tx.QueryRowContext(queryCtx, r.selectQuery, key).Scan(&tokens, &updatedAt)
+	end(err)
+	switch {
+	case err == nil:
+		current = &domain.RateLimitBucket{Tokens: tokens, UpdatedAt: time.Unix(0, updatedAt)}
+	case !errors.Is(err, sql.ErrNoRows):
+		return fmt.Errorf("db error: %w", err)
+	}
+
+	next := fn(current)
+
+	queryCtx, end = r.conn.StartQuery(ctx, r.saveQuery)
+	_, err = tx.ExecContext(queryCtx, r.saveQuery, key, next.Tokens, next.UpdatedAt.UnixNano())
+	end(err)
+	if err != nil {
+		return fmt.Errorf("db error: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("db error: %w", err)
+	}
+	return nil
+}
+
+// DeleteBefore removes the buckets not updated since t
+func (r *RateLimitRepository) DeleteBefore(ctx context.Context, t time.Time) (int64, error) {
+	result, err := r.conn.ExecContext(ctx, `DELETE FROM rate_limit_buckets WHERE updated_at < ?`, t.UnixNano())
+	if err != nil {
+		return 0, fmt.Errorf("db error: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,82 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/joy-currency-conversion-private/domain"
+)
+
+// RateRepository implements domain.RateRepository on MySQL or SQLite
+type RateRepository struct {
+	conn      *Conn
+	saveQuery string
+}
+
+// NewRateRepository creates a new RateRepository
+func NewRateRepository(conn *sql.DB, dialect Dialect) *RateRepository {
+	return &RateRepository{
+		conn: WrapConn(conn, dialect),
+		saveQuery: `INSERT INTO exchange_rates (origin, destination, rate_date, rate, source) VALUES (?, ?, ?, ?, ?) ` +
+			dialect.upsert([]string{"origin", "destination", "rate_date"}, "rate", "source") + `, fetched_at = CURRENT_TIMESTAMP`,
+	}
+}
+
+// SaveRates stores daily rates for a currency pair
+func (r *RateRepository) SaveRates(ctx context.Context, origin, destination, source string, rates []domain.HistoryRate) error {
+	tx, err := r.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("db error: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, r.saveQuery)
+	if err != nil {
+		return fmt.Errorf("db error: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, rate := range rates {
+		queryCtx, end := r.conn.StartQuery(ctx, r.saveQuery)
+		_, err := stmt.ExecContext(queryCtx, origin, destination, rate.Date, rate.Rate, source)
+		end(err)
+		if err != nil {
+			return fmt.Errorf("db error: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("db error: %w", err)
+	}
+	return nil
+}
+
+// GetRates returns the stored daily rates for a currency pair within a date range
+func (r *RateRepository) GetRates(ctx context.Context, origin, destination string, startDate, endDate time.Time) ([]domain.HistoryRate, error) {
+	rows, err := r.conn.QueryContext(ctx,
+		`SELECT rate_date, rate FROM exchange_rates
+		WHERE origin = ? AND destination = ? AND rate_date BETWEEN ? AND ?
+		ORDER BY rate_date`,
+		origin, destination, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("db error: %w", err)
+	}
+	defer rows.Close()
+
+	rates := make([]domain.HistoryRate, 0)
+	for rows.Next() {
+		var date time.Time
+		var rate domain.HistoryRate
+		if err := rows.Scan(&date, &rate.Rate); err != nil {
+			return nil, fmt.Errorf("db error: %w", err)
+		}
+		rate.Date = date.Format("2006-01-02")
+		rates = append(rates, rate)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("db error: %w", err)
+	}
+	return rates, nil
+}
@@ -0,0 +1,49 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ProviderUsageRepository implements domain.ProviderUsageRepository on MySQL or SQLite
+type ProviderUsageRepository struct {
+	conn *Conn
+}
+
+// NewProviderUsageRepository creates a new ProviderUsageRepository
+func NewProviderUsageRepository(conn *sql.DB, dialect Dialect) *ProviderUsageRepository {
+	return &ProviderUsageRepository{conn: WrapConn(conn, dialect)}
+}
+
+// GetCalls returns the calls made to a provider in a period
+func (r *ProviderUsageRepository) GetCalls(ctx context.Context, provider, period string) (int, error) {
+	var calls int
+	err := r.conn.QueryRowContext(ctx,
+		`SELECT calls FROM provider_usage WHERE provider = ? AND period = ?`,
+		provider, period,
+	).Scan(&calls)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("db error: %w", err)
+	}
+	return calls, nil
+}
+
+// AddCalls increments the counter of a provider in a period and returns the new total
+func (r *ProviderUsageRepository) AddCalls(ctx context.Context, provider, period string, calls int) (int, error) {
+	_, err := r.conn.ExecContext(ctx,
+		`INSERT INTO provider_usage (provider, period, calls, updated_at) VALUES (?, ?, ?, ?) `+
+			r.conn.dialect.OnConflict("provider", "period")+
+			` calls = calls + `+r.conn.dialect.Inserted("calls")+`, updated_at = `+r.conn.dialect.Inserted("updated_at"),
+		provider, period, calls, time.Now().UTC(),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("db error: %w", err)
+	}
+	return r.GetCalls(ctx, provider, period)
+}
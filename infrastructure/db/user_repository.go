@@ -0,0 +1,104 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/joy-currency-conversion-private/domain"
+)
+
+// UserRepository implements domain.UserRepository on MySQL or SQLite
+type UserRepository struct {
+	conn *Conn
+}
+
+// NewUserRepository creates a new UserRepository
+func NewUserRepository(conn *sql.DB, dialect Dialect) *UserRepository {
+	return &UserRepository{conn: WrapConn(conn, dialect)}
+}
+
+// Create stores a new user
+func (r *UserRepository) Create(ctx context.Context, user *domain.User) error {
+	_, err := r.conn.ExecContext(ctx,
+		`INSERT INTO users (id, email, password_hash, role, created_at) VALUES (?, ?, ?, ?, ?)`,
+		user.ID, user.Email, user.PasswordHash, user.Role, user.CreatedAt,
+	)
+	if r.conn.dialect.IsDuplicate(err) {
+		return fmt.Errorf("user %s: %w", user.Email, domain.ErrAlreadyExists)
+	}
+	if err != nil {
+		return fmt.Errorf("db error: %w", err)
+	}
+	return nil
+}
+
+// Get returns the user with the given ID
+func (r *UserRepository) Get(ctx context.Context, id string) (*domain.User, error) {
+	return r.getBy(ctx, "id", id)
+}
+
+// GetByEmail returns the user with the given email
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	return r.getBy(ctx, "email", email)
+}
+
+// getBy returns the user whose column equals value, column is never user input
+func (r *UserRepository) getBy(ctx context.Context, column, value string) (*domain.User, error) {
+	row := r.conn.QueryRowContext(ctx,
+		`SELECT id, email, password_hash, role, created_at, email_verified_at FROM users WHERE `+column+` = ?`, value)
+
+	var user domain.User
+	var verifiedAt sql.NullTime
+	err := row.Scan(&user.ID, &user.Email, &user.PasswordHash, &user.Role, &user.CreatedAt, &verifiedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("user: %w", domain.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("db error: %w", err)
+	}
+	if verifiedAt.Valid {
+		user.EmailVerifiedAt = &verifiedAt.Time
+	}
+	return &user, nil
+}
+
+// SetRole changes the role of the user with the given ID
+func (r *UserRepository) SetRole(ctx context.Context, id, role string) error {
+	result, err := r.conn.ExecContext(ctx, `UPDATE users SET role = ? WHERE id = ?`, role, id)
+	if err != nil {
+		return fmt.Errorf("db error: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("db error: %w", err)
+	}
+	if affected == 0 {
+		// MySQL reports zero affected rows when the role is unchanged too, tell it from a missing user
+		if _, err := r.Get(ctx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetEmailVerified records when the user with the given ID verified their email
+func (r *UserRepository) SetEmailVerified(ctx context.Context, id string, at time.Time) error {
+	result, err := r.conn.ExecContext(ctx, `UPDATE users SET email_verified_at = ? WHERE id = ?`, at, id)
+	if err != nil {
+		return fmt.Errorf("db error: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("db error: %w", err)
+	}
+	if affected == 0 {
+		// MySQL reports zero affected rows when the value is unchanged too, tell it from a missing user
+		if _, err := r.Get(ctx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
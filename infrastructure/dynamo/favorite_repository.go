@@ -0,0 +1,366 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/joy-currency-conversion-private/domain"
+)
+
+// Global secondary indexes used to query favorites by notify_email and by owner_id
+const (
+	EmailIndex = "notify_email-index"
+	OwnerIndex = "owner_id-index"
+)
+
+// uniquePrefix starts the ID of the items reserving the UniqueKey of a favorite.
+// They live in the favorites table so a transaction can write both atomically.
+const uniquePrefix = "unique#"
+
+// favoriteItem is the DynamoDB representation of a favorite
+type favoriteItem struct {
+	ID          string    `dynamodbav:"id"`
+	Origin      string    `dynamodbav:"origin"`
+	Destination string    `dynamodbav:"destination"`
+	Threshold   float64   `dynamodbav:"threshold"`
+	NotifyEmail string    `dynamodbav:"notify_email"`
+	OwnerID     string    `dynamodbav:"owner_id,omitempty"`  // index keys can't be empty strings
+	UniqueID    string    `dynamodbav:"unique_id,omitempty"` // ID of the uniqueness item, empty on older favorites
+	CreatedAt   time.Time `dynamodbav:"created_at"`
+}
+
+// uniqueItem reserves the UniqueKey of a favorite. It has neither notify_email
+// nor owner_id, so the indexes never return it.
+type uniqueItem struct {
+	ID         string `dynamodbav:"id"`
+	FavoriteID string `dynamodbav:"favorite_id"`
+}
+
+// FavoriteRepository implements domain.FavoriteRepository using DynamoDB
+type FavoriteRepository struct {
+	client *dynamodb.DynamoDB
+	table  string
+}
+
+// NewFavoriteRepository creates a new FavoriteRepository on the given table
+func NewFavoriteRepository(client *dynamodb.DynamoDB, table string) *FavoriteRepository {
+	return &FavoriteRepository{
+		client: client,
+		table:  table,
+	}
+}
+
+// Ping checks the favorites table exists and is active
+func (r *FavoriteRepository) Ping(ctx context.Context) error {
+	output, err := r.client.DescribeTableWithContext(ctx, &dynamodb.DescribeTableInput{
+		TableName: aws.String(r.table),
+	})
+	if err != nil {
+		return fmt.Errorf("dynamodb error: %w", err)
+	}
+	if status := aws.StringValue(output.Table.TableStatus); status != dynamodb.TableStatusActive {
+		return fmt.Errorf("table %s is %s", r.table, status)
+	}
+	return nil
+}
+
+// EnsureTable creates the favorites table and its indexes if they don't exist.
+// Meant for DynamoDB Local and development, production tables are provisioned separately.
+func (r *FavoriteRepository) EnsureTable(ctx context.Context) error {
+	_, err := r.client.DescribeTableWithContext(ctx, &dynamodb.DescribeTableInput{
+		TableName: aws.String(r.table),
+	})
+	if err == nil {
+		return nil
+	}
+	var awsErr awserr.Error
+	if !errors.As(err, &awsErr) || awsErr.Code() != dynamodb.ErrCodeResourceNotFoundException {
+		return fmt.Errorf("dynamodb error: %w", err)
+	}
+
+	_, err = r.client.CreateTableWithContext(ctx, &dynamodb.CreateTableInput{
+		TableName:   aws.String(r.table),
+		BillingMode: aws.String(dynamodb.BillingModePayPerRequest),
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{AttributeName: aws.String("id"), AttributeType: aws.String(dynamodb.ScalarAttributeTypeS)},
+			{AttributeName: aws.String("notify_email"), AttributeType: aws.String(dynamodb.ScalarAttributeTypeS)},
+			{AttributeName: aws.String("owner_id"), AttributeType: aws.String(dynamodb.ScalarAttributeTypeS)},
+		},
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{AttributeName: aws.String("id"), KeyType: aws.String(dynamodb.KeyTypeHash)},
+		},
+		GlobalSecondaryIndexes: []*dynamodb.GlobalSecondaryIndex{
+			{
+				IndexName: aws.String(EmailIndex),
+				KeySchema: []*dynamodb.KeySchemaElement{
+					{AttributeName: aws.String("notify_email"), KeyType: aws.String(dynamodb.KeyTypeHash)},
+				},
+				Projection: &dynamodb.Projection{ProjectionType: aws.String(dynamodb.ProjectionTypeAll)},
+			},
+			{
+				IndexName: aws.String(OwnerIndex),
+				KeySchema: []*dynamodb.KeySchemaElement{
+					{AttributeName: aws.String("owner_id"), KeyType: aws.String(dynamodb.KeyTypeHash)},
+				},
+				Projection: &dynamodb.Projection{ProjectionType: aws.String(dynamodb.ProjectionTypeAll)},
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("dynamodb error: %w", err)
+	}
+
+	return r.client.WaitUntilTableExistsWithContext(ctx, &dynamodb.DescribeTableInput{
+		TableName: aws.String(r.table),
+	})
+}
+
+// Create stores a new favorite together with the item reserving its UniqueKey,
+// in one transaction whose conditions reject a taken ID or a duplicate
+func (r *FavoriteRepository) Create(ctx context.Context, favorite *domain.Favorite) error {
+	item := toItem(favorite)
+	item.UniqueID = uniquePrefix + favorite.UniqueKey()
+
+	favoriteAttrs, err := dynamodbattribute.MarshalMap(item)
+	if err != nil {
+		return fmt.Errorf("marshalling favorite: %w", err)
+	}
+	uniqueAttrs, err := dynamodbattribute.MarshalMap(uniqueItem{ID: item.UniqueID, FavoriteID: favorite.ID})
+	if err != nil {
+		return fmt.Errorf("marshalling favorite: %w", err)
+	}
+
+	_, err = r.client.TransactWriteItemsWithContext(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []*dynamodb.TransactWriteItem{
+			{Put: &dynamodb.Put{
+				TableName:           aws.String(r.table),
+				Item:                favoriteAttrs,
+				ConditionExpression: aws.String("attribute_not_exists(id)"),
+			}},
+			{Put: &dynamodb.Put{
+				TableName:           aws.String(r.table),
+				Item:                uniqueAttrs,
+				ConditionExpression: aws.String("attribute_not_exists(id)"),
+			}},
+		},
+	})
+	if isConditionFailed(err) {
+		return fmt.Errorf("favorite %s: %w", favorite.ID, domain.ErrAlreadyExists)
+	}
+	if err != nil {
+		return fmt.Errorf("dynamodb error: %w", err)
+	}
+	return nil
+}
+
+// Get returns the favorite with the given ID
+func (r *FavoriteRepository) Get(ctx context.Context, id string) (*domain.Favorite, error) {
+	if strings.HasPrefix(id, uniquePrefix) {
+		return nil, fmt.Errorf("favorite %s: %w", id, domain.ErrNotFound)
+	}
+	out, err := r.client.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(r.table),
+		Key:            idKey(id),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("dynamodb error: %w", err)
+	}
+	if out.Item == nil {
+		return nil, fmt.Errorf("favorite %s: %w", id, domain.ErrNotFound)
+	}
+
+	var item favoriteItem
+	if err := dynamodbattribute.UnmarshalMap(out.Item, &item); err != nil {
+		return nil, fmt.Errorf("unmarshalling favorite: %w", err)
+	}
+	favorite := item.toFavorite()
+	return &favorite, nil
+}
+
+// List returns all stored favorites, following Scan pagination
+func (r *FavoriteRepository) List(ctx context.Context) ([]domain.Favorite, error) {
+	favorites := make([]domain.Favorite, 0)
+	var unmarshalErr error
+
+	err := r.client.ScanPagesWithContext(ctx, &dynamodb.ScanInput{
+		TableName: aws.String(r.table),
+		// Skip the items reserving unique keys
+		FilterExpression: aws.String("attribute_not_exists(favorite_id)"),
+	}, func(page *dynamodb.ScanOutput, lastPage bool) bool {
+		favorites, unmarshalErr = appendItems(favorites, page.Items)
+		return unmarshalErr == nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("dynamodb error: %w", err)
+	}
+	if unmarshalErr != nil {
+		return nil, unmarshalErr
+	}
+
+	sortFavorites(favorites)
+	return favorites, nil
+}
+
+// ListByEmail returns the favorites notifying the given email, querying the email index
+func (r *FavoriteRepository) ListByEmail(ctx context.Context, email string) ([]domain.Favorite, error) {
+	return r.queryIndex(ctx, EmailIndex, "notify_email", email)
+}
+
+// ListByOwner returns the favorites of the given user, querying the owner index
+func (r *FavoriteRepository) ListByOwner(ctx context.Context, ownerID string) ([]domain.Favorite, error) {
+	return r.queryIndex(ctx, OwnerIndex, "owner_id", ownerID)
+}
+
+// queryIndex returns the favorites whose attribute equals value, following Query pagination
+func (r *FavoriteRepository) queryIndex(ctx context.Context, index, attribute, value string) ([]domain.Favorite, error) {
+	favorites := make([]domain.Favorite, 0)
+	var unmarshalErr error
+
+	err := r.client.QueryPagesWithContext(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(r.table),
+		IndexName:              aws.String(index),
+		KeyConditionExpression: aws.String("#attr = :value"),
+		ExpressionAttributeNames: map[string]*string{
+			"#attr": aws.String(attribute),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":value": {S: aws.String(value)},
+		},
+	}, func(page *dynamodb.QueryOutput, lastPage bool) bool {
+		favorites, unmarshalErr = appendItems(favorites, page.Items)
+		return unmarshalErr == nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("dynamodb error: %w", err)
+	}
+	if unmarshalErr != nil {
+		return nil, unmarshalErr
+	}
+
+	sortFavorites(favorites)
+	return favorites, nil
+}
+
+// Delete removes the favorite with the given ID and releases its unique key
+func (r *FavoriteRepository) Delete(ctx context.Context, id string) error {
+	if strings.HasPrefix(id, uniquePrefix) {
+		return fmt.Errorf("favorite %s: %w", id, domain.ErrNotFound)
+	}
+	out, err := r.client.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(r.table),
+		Key:            idKey(id),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return fmt.Errorf("dynamodb error: %w", err)
+	}
+	var item favoriteItem
+	if out.Item != nil {
+		if err := dynamodbattribute.UnmarshalMap(out.Item, &item); err != nil {
+			return fmt.Errorf("unmarshalling favorite: %w", err)
+		}
+	}
+
+	items := []*dynamodb.TransactWriteItem{
+		{Delete: &dynamodb.Delete{
+			TableName:           aws.String(r.table),
+			Key:                 idKey(id),
+			ConditionExpression: aws.String("attribute_exists(id)"),
+		}},
+	}
+	if item.UniqueID != "" {
+		items = append(items, &dynamodb.TransactWriteItem{Delete: &dynamodb.Delete{
+			TableName:           aws.String(r.table),
+			Key:                 idKey(item.UniqueID),
+			ConditionExpression: aws.String("favorite_id = :id"),
+			ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+				":id": {S: aws.String(id)},
+			},
+		}})
+	}
+
+	_, err = r.client.TransactWriteItemsWithContext(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: items})
+	if isConditionFailed(err) {
+		return fmt.Errorf("favorite %s: %w", id, domain.ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("dynamodb error: %w", err)
+	}
+	return nil
+}
+
+func toItem(favorite *domain.Favorite) favoriteItem {
+	return favoriteItem{
+		ID:          favorite.ID,
+		Origin:      favorite.Origin.Code,
+		Destination: favorite.Destination.Code,
+		Threshold:   favorite.Threshold,
+		NotifyEmail: favorite.NotifyEmail,
+		OwnerID:     favorite.OwnerID,
+		CreatedAt:   favorite.CreatedAt,
+	}
+}
+
+func (item favoriteItem) toFavorite() domain.Favorite {
+	return domain.Favorite{
+		ID:          item.ID,
+		Origin:      domain.Currency{Code: item.Origin},
+		Destination: domain.Currency{Code: item.Destination},
+		Threshold:   item.Threshold,
+		NotifyEmail: item.NotifyEmail,
+		OwnerID:     item.OwnerID,
+		CreatedAt:   item.CreatedAt,
+	}
+}
+
+func appendItems(favorites []domain.Favorite, items []map[string]*dynamodb.AttributeValue) ([]domain.Favorite, error) {
+	var page []favoriteItem
+	if err := dynamodbattribute.UnmarshalListOfMaps(items, &page); err != nil {
+		return favorites, fmt.Errorf("unmarshalling favorites: %w", err)
+	}
+	for _, item := range page {
+		favorites = append(favorites, item.toFavorite())
+	}
+	return favorites, nil
+}
+
+// sortFavorites orders by creation time, Scan and index queries return items unordered
+func sortFavorites(favorites []domain.Favorite) {
+	sort.Slice(favorites, func(i, j int) bool {
+		if favorites[i].CreatedAt.Equal(favorites[j].CreatedAt) {
+			return favorites[i].ID < favorites[j].ID
+		}
+		return favorites[i].CreatedAt.Before(favorites[j].CreatedAt)
+	})
+}
+
+func idKey(id string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"id": {S: aws.String(id)},
+	}
+}
+
+// isConditionFailed reports whether a write, or one of the writes of a
+// transaction, was rejected by its condition
+func isConditionFailed(err error) bool {
+	var canceled *dynamodb.TransactionCanceledException
+	if errors.As(err, &canceled) {
+		for _, reason := range canceled.CancellationReasons {
+			if aws.StringValue(reason.Code) == "ConditionalCheckFailed" {
+				return true
+			}
+		}
+		return false
+	}
+	var awsErr awserr.Error
+	return errors.As(err, &awsErr) && awsErr.Code() == dynamodb.ErrCodeConditionalCheckFailedException
+}
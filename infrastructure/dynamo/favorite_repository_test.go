@@ -0,0 +1,158 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/joy-currency-conversion-private/domain"
+)
+
+// newLocalRepository returns a repository on a fresh table of the DynamoDB Local
+// instance at DYNAMODB_ENDPOINT, e.g. started with
+// docker run -p 8000:8000 amazon/dynamodb-local -jar DynamoDBLocal.jar -inMemory
+func newLocalRepository(t *testing.T) *FavoriteRepository {
+	t.Helper()
+	endpoint := os.Getenv("DYNAMODB_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("DYNAMODB_ENDPOINT not set, skipping DynamoDB Local test")
+	}
+
+	sess, err := session.NewSession(&aws.Config{
+		Region:      aws.String("us-east-1"),
+		Endpoint:    aws.String(endpoint),
+		Credentials: credentials.NewStaticCredentials("local", "local", ""),
+	})
+	if err != nil {
+		t.Fatalf("aws session: %v", err)
+	}
+	client := dynamodb.New(sess)
+	table := fmt.Sprintf("favorites-test-%d", time.Now().UnixNano())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	repo := NewFavoriteRepository(client, table)
+	if err := repo.EnsureTable(ctx); err != nil {
+		t.Fatalf("EnsureTable: %v", err)
+	}
+	t.Cleanup(func() {
+		client.DeleteTable(&dynamodb.DeleteTableInput{TableName: aws.String(table)})
+	})
+	return repo
+}
+
+func favorite(id, owner, email, origin, destination string) *domain.Favorite {
+	return &domain.Favorite{
+		ID:          id,
+		Origin:      domain.Currency{Code: origin},
+		Destination: domain.Currency{Code: destination},
+		Threshold:   1.1,
+		NotifyEmail: email,
+		OwnerID:     owner,
+		CreatedAt:   time.Now().UTC().Truncate(time.Second),
+	}
+}
+
+func TestFavoriteRepositoryRejectsDuplicates(t *testing.T) {
+	repo := newLocalRepository(t)
+	ctx := context.Background()
+
+	if err := repo.Create(ctx, favorite("f1", "u1", "a@example.com", "EUR", "USD")); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		favorite *domain.Favorite
+		wantErr  error
+	}{
+		{"same ID", favorite("f1", "u2", "b@example.com", "EUR", "GBP"), domain.ErrAlreadyExists},
+		{"same owner and pair under a new ID", favorite("f2", "u1", "a@example.com", "EUR", "USD"), domain.ErrAlreadyExists},
+		{"same owner, other pair", favorite("f3", "u1", "a@example.com", "EUR", "GBP"), nil},
+		{"other owner, same pair", favorite("f4", "u2", "b@example.com", "EUR", "USD"), nil},
+		{"unowned favorite", favorite("f5", "", "c@example.com", "EUR", "USD"), nil},
+		{"unowned duplicate", favorite("f6", "", "c@example.com", "EUR", "USD"), domain.ErrAlreadyExists},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := repo.Create(ctx, tt.favorite)
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Create error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	favorites, err := repo.List(ctx)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(favorites) != 4 {
+		t.Errorf("List returned %d favorites, want 4 without the unique key items", len(favorites))
+	}
+}
+
+func TestFavoriteRepositoryDeleteReleasesUniqueKey(t *testing.T) {
+	repo := newLocalRepository(t)
+	ctx := context.Background()
+
+	if err := repo.Create(ctx, favorite("f1", "u1", "a@example.com", "EUR", "USD")); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := repo.Delete(ctx, "f1"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := repo.Delete(ctx, "f1"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("second Delete error = %v, want ErrNotFound", err)
+	}
+	if err := repo.Create(ctx, favorite("f2", "u1", "a@example.com", "EUR", "USD")); err != nil {
+		t.Errorf("Create after Delete: %v", err)
+	}
+	if _, err := repo.Get(ctx, uniquePrefix+"owner:u1|EUR|USD"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Get of a unique key item error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestFavoriteRepositoryQueriesIndexes(t *testing.T) {
+	repo := newLocalRepository(t)
+	ctx := context.Background()
+
+	for _, f := range []*domain.Favorite{
+		favorite("f1", "u1", "a@example.com", "EUR", "USD"),
+		favorite("f2", "u1", "a@example.com", "EUR", "GBP"),
+		favorite("f3", "", "b@example.com", "EUR", "USD"),
+	} {
+		if err := repo.Create(ctx, f); err != nil {
+			t.Fatalf("Create %s: %v", f.ID, err)
+		}
+	}
+
+	byOwner, err := repo.ListByOwner(ctx, "u1")
+	if err != nil || len(byOwner) != 2 {
+		t.Errorf("ListByOwner = %d favorites, %v; want 2", len(byOwner), err)
+	}
+	byEmail, err := repo.ListByEmail(ctx, "b@example.com")
+	if err != nil || len(byEmail) != 1 || byEmail[0].ID != "f3" {
+		t.Errorf("ListByEmail = %v, %v; want f3", byEmail, err)
+	}
+}
+
+func TestPing(t *testing.T) {
+	repo := newLocalRepository(t)
+	if err := repo.Ping(context.Background()); err != nil {
+		t.Errorf("Ping: %v", err)
+	}
+	missing := NewFavoriteRepository(repo.client, repo.table+"-missing")
+	if err := missing.Ping(context.Background()); err == nil {
+		t.Error("Ping(missing table) = nil, want an error")
+	}
+}
@@ -2,32 +2,28 @@ package infrastructure
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
-	/*
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
-	*/
-	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/google/uuid"
 	"github.com/joy-currency-conversion-private/domain"
-	"github.com/joy-currency-conversion-private/infrastructure/db"
+	"github.com/joy-currency-conversion-private/infrastructure/metrics"
 )
 
-// FavoriteService implements domain.FavoriteService using DynamoDB
+// FavoriteService implements domain.FavoriteService on top of a FavoriteRepository
 type FavoriteService struct {
-	dynamoDB *dynamodb.DynamoDB
-	ExchangeRateAPIKey string
-	ExchangeRatesAPIKey string
+	favorites       domain.FavoriteRepository
+	alerts          domain.AlertStateRepository
+	currencyService domain.CurrencyService
 }
 
 // NewFavoriteService creates a new FavoriteService
-func NewFavoriteService(dynamoDB *dynamodb.DynamoDB, exchangeRateAPIKey, exchangeRatesAPIKey string) *FavoriteService {
+func NewFavoriteService(favorites domain.FavoriteRepository, alerts domain.AlertStateRepository, currencyService domain.CurrencyService) *FavoriteService {
 	return &FavoriteService{
-		dynamoDB: dynamoDB,
-		ExchangeRateAPIKey: exchangeRateAPIKey,
-		ExchangeRatesAPIKey: exchangeRatesAPIKey,
+		favorites:       favorites,
+		alerts:          alerts,
+		currencyService: currencyService,
 	}
 }
 
@@ -36,27 +32,24 @@ func (s *FavoriteService) SaveFavorite(ctx context.Context, req *domain.Favorite
 	// Generate UUID for the favorite
 	id := uuid.New().String()
 	
+	if req.Threshold <= 0 {
+		return nil, fmt.Errorf("threshold must be greater than 0: %w", domain.ErrInvalidInput)
+	}
+	if req.NotifyEmail == "" {
+		return nil, fmt.Errorf("notify_email is required: %w", domain.ErrInvalidInput)
+	}
+
 	// Get currency information
-	currencyService := NewCurrencyService(s.dynamoDB, s.ExchangeRateAPIKey, s.ExchangeRatesAPIKey)
-	originCurrency, err := currencyService.GetCurrencyInfo(ctx, req.Origin)
+	originCurrency, err := s.currencyService.GetCurrencyInfo(ctx, req.Origin)
 	if err != nil {
-		return nil, fmt.Errorf("invalid origin currency: %w", err)
+		return nil, fmt.Errorf("invalid origin currency %q: %w", req.Origin, domain.ErrInvalidInput)
 	}
 	
-	destCurrency, err := currencyService.GetCurrencyInfo(ctx, req.Destination)
+	destCurrency, err := s.currencyService.GetCurrencyInfo(ctx, req.Destination)
 	if err != nil {
-		return nil, fmt.Errorf("invalid destination currency: %w", err)
+		return nil, fmt.Errorf("invalid destination currency %q: %w", req.Destination, domain.ErrInvalidInput)
 	}
 
-	// Insert into DB
-	_, err = db.DB.Exec(
-		`INSERT INTO favorites (id, origin, destination, threshold, notify_email) VALUES (?, ?, ?, ?, ?)`,
-		id, originCurrency.Code, destCurrency.Code, req.Threshold, req.NotifyEmail,
-	)
-	if err != nil {
-		return nil, fmt.Errorf("db error: %w", err)
-	}
-	
 	// Create favorite object
 	favorite := &domain.Favorite{
 		ID:          id,
@@ -64,56 +57,98 @@ func (s *FavoriteService) SaveFavorite(ctx context.Context, req *domain.Favorite
 		Destination: *destCurrency,
 		Threshold:   req.Threshold,
 		NotifyEmail: req.NotifyEmail,
+		OwnerID:     req.OwnerID,
 		CreatedAt:   time.Now().UTC(),
 	}
-	
-	// TODO: Save to DynamoDB
-	// This would involve:
-	// 1. Creating a DynamoDB item with the favorite data
-	// 2. Using PutItem or UpdateItem to store it
-	// 3. Handling conditional writes to prevent duplicates
-	
-	// For now, just return the favorite (mock implementation)
+
+	// The repository rejects a duplicate of another favorite with ErrAlreadyExists
+	if err := s.favorites.Create(ctx, favorite); err != nil {
+		return nil, err
+	}
+
 	return favorite, nil
 }
 
 // GetAllFavorites returns all saved favorites
 func (s *FavoriteService) GetAllFavorites(ctx context.Context) ([]domain.Favorite, error) {
-	// TODO: Implement DynamoDB scan or query
-	// This would involve:
-	// 1. Using Scan or Query operation to get all favorites
-	// 2. Unmarshaling the results into domain.Favorite objects
-	// 3. Handling pagination if there are many favorites
-	
-	favorites := make([]domain.Favorite, 0)
-	favorites = append(favorites, domain.Favorite{
-		ID: "abcd",
-		Origin: domain.Currency{Code: "USD", Country: "EE.UU"},
-		Destination: domain.Currency{Code: "COP", Country: "Colombia"},
-		Threshold: 3000,
-		NotifyEmail: "test@gmail.com",
-	})
+	return s.ListFavorites(ctx, "")
+}
+
+// ListFavorites returns the favorites of a user, or all of them when ownerID is empty
+func (s *FavoriteService) ListFavorites(ctx context.Context, ownerID string) ([]domain.Favorite, error) {
+	var favorites []domain.Favorite
+	var err error
+	if ownerID == "" {
+		favorites, err = s.favorites.List(ctx)
+	} else {
+		favorites, err = s.favorites.ListByOwner(ctx, ownerID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range favorites {
+		s.fillCurrencies(ctx, &favorites[i])
+	}
+
 	return favorites, nil
 }
 
+// GetFavorite returns a favorite, hiding the ones owned by another user as not found
+func (s *FavoriteService) GetFavorite(ctx context.Context, id, ownerID string) (*domain.Favorite, error) {
+	favorite, err := s.favorites.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if ownerID != "" && favorite.OwnerID != ownerID {
+		return nil, fmt.Errorf("favorite %s: %w", id, domain.ErrNotFound)
+	}
+
+	s.fillCurrencies(ctx, favorite)
+	return favorite, nil
+}
+
+// DeleteFavorite removes a favorite and its alert state after checking it belongs to ownerID
+func (s *FavoriteService) DeleteFavorite(ctx context.Context, id, ownerID string) error {
+	if _, err := s.GetFavorite(ctx, id, ownerID); err != nil {
+		return err
+	}
+	if err := s.favorites.Delete(ctx, id); err != nil {
+		return err
+	}
+	if err := s.alerts.Delete(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete alert state: %w", err)
+	}
+	return nil
+}
+
+// fillCurrencies fills in the country names, repositories only store currency codes
+func (s *FavoriteService) fillCurrencies(ctx context.Context, favorite *domain.Favorite) {
+	if currency, err := s.currencyService.GetCurrencyInfo(ctx, favorite.Origin.Code); err == nil {
+		favorite.Origin = *currency
+	}
+	if currency, err := s.currencyService.GetCurrencyInfo(ctx, favorite.Destination.Code); err == nil {
+		favorite.Destination = *currency
+	}
+}
+
 // CheckFavorites checks all favorites against current rates
 func (s *FavoriteService) CheckFavorites(ctx context.Context) (*domain.FavoriteCheckResponse, error) {
+	defer metrics.ObserveFavoriteCheck(time.Now())
+
 	// Get all favorites
 	favorites, err := s.GetAllFavorites(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get favorites: %w", err)
 	}
 	
-	// Initialize currency service for rate checking
-	currencyService := NewCurrencyService(s.dynamoDB, s.ExchangeRateAPIKey, s.ExchangeRatesAPIKey)
-	
 	var results []domain.FavoriteCheckResult
 	today := time.Now().Format("2006-01-02")
 	
 	// Check each favorite
 	for _, favorite := range favorites {
 		// Get current rate
-		currentRate, source, err := currencyService.GetExchangeRate(
+		currentRate, source, err := s.currencyService.GetExchangeRate(
 			ctx, 
 			favorite.Origin.Code, 
 			favorite.Destination.Code,
@@ -123,6 +158,15 @@ func (s *FavoriteService) CheckFavorites(ctx context.Context) (*domain.FavoriteC
 			continue
 		}
 		
+		// Load the previous state to keep track of the last check and notification
+		state, err := s.alerts.Get(ctx, favorite.ID)
+		if errors.Is(err, domain.ErrNotFound) {
+			state = &domain.AlertState{FavoriteID: favorite.ID}
+		} else if err != nil {
+			// Skip this favorite if we can't read its alert state
+			continue
+		}
+
 		// Check if threshold is exceeded
 		exceeded := currentRate >= favorite.Threshold
 		
@@ -130,9 +174,22 @@ func (s *FavoriteService) CheckFavorites(ctx context.Context) (*domain.FavoriteC
 		// This would involve calling the notification service
 		notified := false
 		if exceeded {
+			metrics.AlertTriggered(favorite.Origin.Code, favorite.Destination.Code)
+
 			// Mock notification sending
 			notified = true
 		}
+
+		now := time.Now().UTC()
+		state.Exceeded = exceeded
+		state.LastRate = currentRate
+		state.LastCheckedAt = now
+		if notified {
+			state.LastNotifiedAt = &now
+		}
+		if err := s.alerts.Save(ctx, state); err != nil {
+			return nil, fmt.Errorf("failed to save alert state: %w", err)
+		}
 		
 		result := domain.FavoriteCheckResult{
 			FavoriteID:        favorite.ID,
@@ -0,0 +1,191 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/joy-currency-conversion-private/domain"
+	"github.com/joy-currency-conversion-private/infrastructure/memory"
+)
+
+// stubCurrencyService serves fixed current rates, the favorite service only
+// needs GetCurrencyInfo and GetExchangeRate
+type stubCurrencyService struct {
+	*CurrencyService
+	rates map[string]float64
+}
+
+func (s stubCurrencyService) GetExchangeRate(ctx context.Context, origin, destination string) (float64, string, error) {
+	rate, ok := s.rates[origin+destination]
+	if !ok {
+		return 0, "", errors.New("no rate")
+	}
+	return rate, "stub", nil
+}
+
+func newTestFavoriteService(rates map[string]float64) (*FavoriteService, *memory.FavoriteRepository, *memory.AlertStateRepository) {
+	favorites := memory.NewFavoriteRepository()
+	alerts := memory.NewAlertStateRepository()
+	currencies := stubCurrencyService{
+		CurrencyService: NewCurrencyService(memory.NewRateRepository(), memory.NewForecastRepository(), nil, "", ""),
+		rates:           rates,
+	}
+	return NewFavoriteService(favorites, alerts, currencies), favorites, alerts
+}
+
+func TestSaveFavorite(t *testing.T) {
+	service, _, _ := newTestFavoriteService(nil)
+	ctx := context.Background()
+
+	saved, err := service.SaveFavorite(ctx, &domain.FavoriteRequest{
+		Origin: "EUR", Destination: "USD", Threshold: 1.2, NotifyEmail: "ana@example.com", OwnerID: "u1",
+	})
+	if err != nil {
+		t.Fatalf("SaveFavorite: %v", err)
+	}
+	if saved.ID == "" || saved.Destination.Country != "United States" || saved.OwnerID != "u1" {
+		t.Errorf("saved favorite = %+v", saved)
+	}
+
+	tests := []struct {
+		name    string
+		req     domain.FavoriteRequest
+		wantErr error
+	}{
+		{"unknown origin", domain.FavoriteRequest{Origin: "XXX", Destination: "USD", Threshold: 1, NotifyEmail: "ana@example.com"}, domain.ErrInvalidInput},
+		{"unknown destination", domain.FavoriteRequest{Origin: "EUR", Destination: "XXX", Threshold: 1, NotifyEmail: "ana@example.com"}, domain.ErrInvalidInput},
+		{"zero threshold", domain.FavoriteRequest{Origin: "EUR", Destination: "USD", NotifyEmail: "ana@example.com"}, domain.ErrInvalidInput},
+		{"missing email", domain.FavoriteRequest{Origin: "EUR", Destination: "USD", Threshold: 1}, domain.ErrInvalidInput},
+		{"same owner and pair", domain.FavoriteRequest{Origin: "EUR", Destination: "USD", Threshold: 1.5, NotifyEmail: "ana@example.com", OwnerID: "u1"}, domain.ErrAlreadyExists},
+		{"same owner, other pair", domain.FavoriteRequest{Origin: "EUR", Destination: "COP", Threshold: 4000, NotifyEmail: "ana@example.com", OwnerID: "u1"}, nil},
+		{"other owner, same pair", domain.FavoriteRequest{Origin: "EUR", Destination: "USD", Threshold: 1.2, NotifyEmail: "bo@example.com", OwnerID: "u2"}, nil},
+		{"unowned", domain.FavoriteRequest{Origin: "EUR", Destination: "USD", Threshold: 1.2, NotifyEmail: "cy@example.com"}, nil},
+		{"unowned, same email and pair", domain.FavoriteRequest{Origin: "EUR", Destination: "USD", Threshold: 1.3, NotifyEmail: "cy@example.com"}, domain.ErrAlreadyExists},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.SaveFavorite(ctx, &tt.req)
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("SaveFavorite: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SaveFavorite error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFavoriteOwnership(t *testing.T) {
+	service, _, _ := newTestFavoriteService(nil)
+	ctx := context.Background()
+
+	mine, err := service.SaveFavorite(ctx, &domain.FavoriteRequest{Origin: "EUR", Destination: "USD", Threshold: 1.2, NotifyEmail: "ana@example.com", OwnerID: "u1"})
+	if err != nil {
+		t.Fatalf("SaveFavorite: %v", err)
+	}
+	if _, err := service.SaveFavorite(ctx, &domain.FavoriteRequest{Origin: "EUR", Destination: "USD", Threshold: 1.2, NotifyEmail: "bo@example.com", OwnerID: "u2"}); err != nil {
+		t.Fatalf("SaveFavorite: %v", err)
+	}
+
+	if list, err := service.ListFavorites(ctx, "u1"); err != nil || len(list) != 1 || list[0].ID != mine.ID {
+		t.Errorf("ListFavorites(u1) = %v, %v; want only %s", list, err, mine.ID)
+	}
+	if list, err := service.ListFavorites(ctx, ""); err != nil || len(list) != 2 {
+		t.Errorf("ListFavorites(all) = %d favorites, %v; want 2", len(list), err)
+	}
+	if _, err := service.GetFavorite(ctx, mine.ID, "u2"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("GetFavorite by another user error = %v, want ErrNotFound", err)
+	}
+	if err := service.DeleteFavorite(ctx, mine.ID, "u2"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("DeleteFavorite by another user error = %v, want ErrNotFound", err)
+	}
+	if err := service.DeleteFavorite(ctx, mine.ID, "u1"); err != nil {
+		t.Errorf("DeleteFavorite by its owner: %v", err)
+	}
+	if _, err := service.GetFavorite(ctx, mine.ID, ""); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("GetFavorite after delete error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestDeleteFavoriteRemovesAlertState(t *testing.T) {
+	service, _, alerts := newTestFavoriteService(map[string]float64{"EURUSD": 1.3})
+	ctx := context.Background()
+
+	favorite, err := service.SaveFavorite(ctx, &domain.FavoriteRequest{Origin: "EUR", Destination: "USD", Threshold: 1.2, NotifyEmail: "ana@example.com"})
+	if err != nil {
+		t.Fatalf("SaveFavorite: %v", err)
+	}
+	if _, err := service.CheckFavorites(ctx); err != nil {
+		t.Fatalf("CheckFavorites: %v", err)
+	}
+	if _, err := alerts.Get(ctx, favorite.ID); err != nil {
+		t.Fatalf("alert state after check: %v", err)
+	}
+
+	if err := service.DeleteFavorite(ctx, favorite.ID, ""); err != nil {
+		t.Fatalf("DeleteFavorite: %v", err)
+	}
+	if _, err := alerts.Get(ctx, favorite.ID); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("alert state after delete error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestCheckFavoritesReportsEveryExceeded(t *testing.T) {
+	rates := map[string]float64{"EURUSD": 1.1}
+	service, _, alerts := newTestFavoriteService(rates)
+	ctx := context.Background()
+
+	favorite, err := service.SaveFavorite(ctx, &domain.FavoriteRequest{Origin: "EUR", Destination: "USD", Threshold: 1.2, NotifyEmail: "ana@example.com"})
+	if err != nil {
+		t.Fatalf("SaveFavorite: %v", err)
+	}
+
+	// Below, crossing, still above, back below: every check over the threshold notifies
+	steps := []struct {
+		rate         float64
+		wantExceeded bool
+		wantNotified bool
+	}{
+		{1.1, false, false},
+		{1.25, true, true},
+		{1.3, true, true},
+		{1.15, false, false},
+	}
+	for i, step := range steps {
+		rates["EURUSD"] = step.rate
+		response, err := service.CheckFavorites(ctx)
+		if err != nil {
+			t.Fatalf("step %d: CheckFavorites: %v", i, err)
+		}
+		if len(response.Results) != 1 {
+			t.Fatalf("step %d: %d results, want 1", i, len(response.Results))
+		}
+		result := response.Results[0]
+		if result.Exceeded != step.wantExceeded || result.Notified != step.wantNotified {
+			t.Errorf("step %d: rate %v exceeded=%v notified=%v, want %v %v", i, step.rate, result.Exceeded, result.Notified, step.wantExceeded, step.wantNotified)
+		}
+	}
+
+	state, err := alerts.Get(ctx, favorite.ID)
+	if err != nil {
+		t.Fatalf("alert state: %v", err)
+	}
+	if state.LastRate != 1.15 || state.Exceeded || state.LastNotifiedAt == nil || state.LastCheckedAt.Before(time.Now().Add(-time.Minute)) {
+		t.Errorf("alert state = %+v", state)
+	}
+}
+
+func TestCheckFavoritesSkipsUnavailableRates(t *testing.T) {
+	service, _, _ := newTestFavoriteService(map[string]float64{})
+	ctx := context.Background()
+
+	if _, err := service.SaveFavorite(ctx, &domain.FavoriteRequest{Origin: "EUR", Destination: "USD", Threshold: 1.2, NotifyEmail: "ana@example.com"}); err != nil {
+		t.Fatalf("SaveFavorite: %v", err)
+	}
+	response, err := service.CheckFavorites(ctx)
+	if err != nil || len(response.Results) != 0 {
+		t.Errorf("CheckFavorites = %+v, %v; want no results", response, err)
+	}
+}
@@ -0,0 +1,78 @@
+package forecast
+
+import "math"
+
+// maxPhi keeps the autoregressive coefficient inside the stationary region
+const maxPhi = 0.99
+
+// ARIMA is an ARIMA(1,1,0) model: the daily changes follow an AR(1) process
+// d(t) = c + phi*d(t-1), estimated by least squares
+type ARIMA struct{}
+
+// Name returns "arima"
+func (ARIMA) Name() string { return "arima" }
+
+// Version returns 1
+func (ARIMA) Version() string { return "1" }
+
+// MinPoints returns 4, giving three changes and two lagged pairs
+func (ARIMA) MinPoints() int { return 4 }
+
+// Forecast predicts the last rate plus the expected changes of each step
+func (m ARIMA) Forecast(rates []float64, horizon int) (*Result, error) {
+	if err := check(m, rates, horizon); err != nil {
+		return nil, err
+	}
+
+	changes := make([]float64, len(rates)-1)
+	for i := range changes {
+		changes[i] = rates[i+1] - rates[i]
+	}
+
+	// Regress each change on the previous one
+	previous, current := changes[:len(changes)-1], changes[1:]
+	meanPrevious, meanCurrent := mean(previous), mean(current)
+	var sxy, sxx, squares float64
+	for i := range previous {
+		dx := previous[i] - meanPrevious
+		sxy += dx * (current[i] - meanCurrent)
+		sxx += dx * dx
+		squares += previous[i] * previous[i]
+	}
+	// Changes that only differ by rounding carry no autocorrelation
+	var phi float64
+	if sxx > 1e-12*squares {
+		phi = math.Max(-maxPhi, math.Min(sxy/sxx, maxPhi))
+	}
+	constant := meanCurrent - phi*meanPrevious
+
+	residuals := make([]float64, len(current))
+	for i := range current {
+		residuals[i] = current[i] - (constant + phi*previous[i])
+	}
+
+	predicted := make([]float64, horizon)
+	rate, change := rates[len(rates)-1], changes[len(changes)-1]
+	for h := range predicted {
+		change = constant + phi*change
+		rate += change
+		predicted[h] = rate
+	}
+
+	// An error on the change of one day carries over to every later rate,
+	// weighted by 1 + phi + ... + phi^j after j days
+	scale := func(h int) float64 {
+		var variance, weight, power float64 = 0, 0, 1
+		for j := 0; j < h; j++ {
+			weight += power
+			power *= phi
+			variance += weight * weight
+		}
+		return math.Sqrt(variance)
+	}
+
+	return withStdErrors(predicted, map[string]float64{
+		"phi":      phi,
+		"constant": constant,
+	}, rates, residuals, 2, scale), nil
+}
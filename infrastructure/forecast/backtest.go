@@ -0,0 +1,127 @@
+package forecast
+
+import (
+	"fmt"
+	"math"
+)
+
+// Metrics measures one-step-ahead predictions against the actual values
+type Metrics struct {
+	MAE  float64
+	RMSE float64
+
+	// MAPE is the mean absolute error relative to the actual value, as a fraction
+	MAPE float64
+
+	// DirectionalAccuracy is the share of days the predicted move had the sign of
+	// the actual one, days without a move left out. Nil for the naive baseline,
+	// which never predicts a move.
+	DirectionalAccuracy *float64
+
+	// Coverage is the share of actual values inside the interval of each of
+	// IntervalLevels, nil for the naive baseline
+	Coverage []float64
+}
+
+// Backtest is the outcome of replaying a model over a series
+type Backtest struct {
+	// Forecasts is how many days were predicted
+	Forecasts int
+
+	Model Metrics
+
+	// Baseline is the naive forecast: tomorrow equals today
+	Baseline Metrics
+}
+
+// Run replays f over rates with a rolling window: each value after the
+// first window is predicted from the window days before it
+func Run(f Forecaster, rates []float64, window int) (*Backtest, error) {
+	if window < f.MinPoints() {
+		return nil, fmt.Errorf("window of %d days is shorter than the %d the %s model needs: %w", window, f.MinPoints(), f.Name(), ErrInsufficientData)
+	}
+	if len(rates) <= window {
+		return nil, fmt.Errorf("backtest needs more than %d days, got %d: %w", window, len(rates), ErrInsufficientData)
+	}
+
+	model := accumulator{covered: make([]int, len(IntervalLevels))}
+	baseline := accumulator{naive: true}
+	for i := window; i < len(rates); i++ {
+		result, err := f.Forecast(rates[i-window:i], 1)
+		if err != nil {
+			return nil, err
+		}
+		step, actual, previous := result.Steps[0], rates[i], rates[i-1]
+
+		model.add(step.Predicted, actual, previous)
+		for j, level := range IntervalLevels {
+			if lower, upper := step.Interval(level); actual >= lower && actual <= upper {
+				model.covered[j]++
+			}
+		}
+		baseline.add(previous, actual, previous)
+	}
+
+	return &Backtest{
+		Forecasts: len(rates) - window,
+		Model:     model.metrics(),
+		Baseline:  baseline.metrics(),
+	}, nil
+}
+
+// accumulator sums the errors of a series of predictions
+type accumulator struct {
+	// naive predictions never move and have no intervals
+	naive bool
+
+	count        int
+	absolute     float64
+	squared      float64
+	percentage   float64
+	percentCount int
+	moves        int
+	hits         int
+	covered      []int
+}
+
+func (a *accumulator) add(predicted, actual, previous float64) {
+	diff := predicted - actual
+	a.count++
+	a.absolute += math.Abs(diff)
+	a.squared += diff * diff
+	if actual != 0 {
+		a.percentage += math.Abs(diff / actual)
+		a.percentCount++
+	}
+
+	if actual != previous && predicted != previous {
+		a.moves++
+		if (actual > previous) == (predicted > previous) {
+			a.hits++
+		}
+	} else if actual != previous {
+		// Predicting no move on a day that moved is a miss
+		a.moves++
+	}
+}
+
+func (a *accumulator) metrics() Metrics {
+	m := Metrics{
+		MAE:  a.absolute / float64(a.count),
+		RMSE: math.Sqrt(a.squared / float64(a.count)),
+	}
+	if a.percentCount > 0 {
+		m.MAPE = a.percentage / float64(a.percentCount)
+	}
+	if !a.naive {
+		if a.moves > 0 {
+			accuracy := float64(a.hits) / float64(a.moves)
+			m.DirectionalAccuracy = &accuracy
+		}
+		m.Coverage = make([]float64, len(a.covered))
+		for i, covered := range a.covered {
+			m.Coverage[i] = float64(covered) / float64(a.count)
+		}
+	}
+	return m
+}
@@ -0,0 +1,114 @@
+package forecast
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestRunGoldenValues(t *testing.T) {
+	accuracy := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name          string
+		model         Forecaster
+		rates         []float64
+		window        int
+		wantForecasts int
+		wantModel     Metrics
+		wantBaseline  Metrics
+	}{
+		{
+			// The line predicts 4, 5 and 6 exactly, the baseline is one behind each day
+			name:          "linear on a line",
+			model:         Linear{},
+			rates:         []float64{1, 2, 3, 4, 5, 6},
+			window:        3,
+			wantForecasts: 3,
+			wantModel:     Metrics{DirectionalAccuracy: accuracy(1), Coverage: []float64{1, 1}},
+			wantBaseline:  Metrics{MAE: 1, RMSE: 1, MAPE: (1.0/4 + 1.0/5 + 1.0/6) / 3},
+		},
+		{
+			// Predictions 1, 1 and 5/3 for 1, 2 and 1: errors 0, 1 and 2/3. The
+			// flat windows have zero intervals, which miss the jump to 2; the
+			// day moving without a predicted move is a directional miss.
+			name:          "heuristic by hand",
+			model:         Heuristic{},
+			rates:         []float64{1, 1, 1, 1, 2, 1},
+			window:        3,
+			wantForecasts: 3,
+			wantModel: Metrics{
+				MAE:                 5.0 / 9,
+				RMSE:                math.Sqrt(13.0 / 27),
+				MAPE:                7.0 / 18,
+				DirectionalAccuracy: accuracy(0.5),
+				Coverage:            []float64{2.0 / 3, 2.0 / 3},
+			},
+			wantBaseline: Metrics{MAE: 2.0 / 3, RMSE: math.Sqrt(2.0 / 3), MAPE: 0.5},
+		},
+		{
+			// Flat rates never move, so there is no direction to be right about
+			name:          "flat",
+			model:         Heuristic{},
+			rates:         []float64{1, 1, 1, 1},
+			window:        3,
+			wantForecasts: 1,
+			wantModel:     Metrics{Coverage: []float64{1, 1}},
+			wantBaseline:  Metrics{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backtest, err := Run(tt.model, tt.rates, tt.window)
+			if err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			if backtest.Forecasts != tt.wantForecasts {
+				t.Errorf("forecasts = %d, want %d", backtest.Forecasts, tt.wantForecasts)
+			}
+			checkMetrics(t, "model", backtest.Model, tt.wantModel)
+			checkMetrics(t, "baseline", backtest.Baseline, tt.wantBaseline)
+		})
+	}
+}
+
+func checkMetrics(t *testing.T, name string, got, want Metrics) {
+	t.Helper()
+	if !near(got.MAE, want.MAE) || !near(got.RMSE, want.RMSE) || !near(got.MAPE, want.MAPE) {
+		t.Errorf("%s MAE, RMSE, MAPE = %v, %v, %v; want %v, %v, %v", name, got.MAE, got.RMSE, got.MAPE, want.MAE, want.RMSE, want.MAPE)
+	}
+	switch {
+	case (got.DirectionalAccuracy == nil) != (want.DirectionalAccuracy == nil):
+		t.Errorf("%s directional accuracy = %v, want %v", name, got.DirectionalAccuracy, want.DirectionalAccuracy)
+	case got.DirectionalAccuracy != nil && !near(*got.DirectionalAccuracy, *want.DirectionalAccuracy):
+		t.Errorf("%s directional accuracy = %v, want %v", name, *got.DirectionalAccuracy, *want.DirectionalAccuracy)
+	}
+	if !slices.EqualFunc(got.Coverage, want.Coverage, func(a, b float64) bool { return near(a, b) }) {
+		t.Errorf("%s coverage = %v, want %v", name, got.Coverage, want.Coverage)
+	}
+}
+
+func TestRunChecksWindow(t *testing.T) {
+	tests := []struct {
+		name   string
+		model  Forecaster
+		rates  []float64
+		window int
+	}{
+		{"window shorter than the model needs", ARIMA{}, noisy, 3},
+		{"no day after the window", Linear{}, noisy[:3], 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Run(tt.model, tt.rates, tt.window); !errors.Is(err, ErrInsufficientData) {
+				t.Errorf("Run error = %v, want ErrInsufficientData", err)
+			}
+		})
+	}
+
+	// The minimum window with a single day after it is enough
+	if backtest, err := Run(ARIMA{}, noisy[:5], 4); err != nil || backtest.Forecasts != 1 {
+		t.Errorf("Run(arima, 5 days, window 4) = %+v, %v; want 1 forecast", backtest, err)
+	}
+}
@@ -0,0 +1,122 @@
+package forecast
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// DefaultModel is used when the request names no model
+const DefaultModel = "heuristic"
+
+// MaxHorizon is the furthest step ahead a forecast may reach
+const MaxHorizon = 30
+
+// DefaultWindow is how many days a forecast is fitted on, MaxWindow the most it may be
+const (
+	DefaultWindow = 30
+	MaxWindow     = 365
+)
+
+var (
+	// ErrUnknownModel is returned when asking for a model that doesn't exist
+	ErrUnknownModel = errors.New("unknown forecast model")
+
+	// ErrInsufficientData is returned when a series is too short for a model
+	ErrInsufficientData = errors.New("insufficient data for forecast")
+
+	// ErrInvalidHorizon is returned for horizons outside 1..MaxHorizon
+	ErrInvalidHorizon = errors.New("invalid forecast horizon")
+)
+
+// Step is the value predicted for one step ahead
+type Step struct {
+	Predicted float64
+
+	// StdError is the standard deviation of the prediction error, it grows with every step
+	StdError float64
+}
+
+// Result holds the values predicted by a model for the next steps, with the
+// parameters it estimated from the series
+type Result struct {
+	Steps      []Step
+	Parameters map[string]float64
+
+	// Method tells how the standard errors were estimated
+	Method string
+}
+
+// Forecaster predicts the next value of a daily rate series
+type Forecaster interface {
+	// Name identifies the model in the model= query parameter
+	Name() string
+
+	// Version changes whenever the model predicts differently on the same
+	// series, so forecasts stored by an older version are recomputed
+	Version() string
+
+	// MinPoints is the shortest series the model can be estimated on
+	MinPoints() int
+
+	// Forecast estimates the model on rates, ordered oldest first, and
+	// predicts the next horizon values
+	Forecast(rates []float64, horizon int) (*Result, error)
+}
+
+var models = map[string]Forecaster{}
+
+func register(f Forecaster) {
+	models[f.Name()] = f
+}
+
+func init() {
+	register(Heuristic{})
+	register(Linear{})
+	register(Holt{})
+	register(ARIMA{})
+}
+
+// Get returns the model called name, the default one when name is empty
+func Get(name string) (Forecaster, error) {
+	if name == "" {
+		name = DefaultModel
+	}
+	f, ok := models[name]
+	if !ok {
+		return nil, fmt.Errorf("%q (use %s): %w", name, strings.Join(Names(), ", "), ErrUnknownModel)
+	}
+	return f, nil
+}
+
+// Names lists the available models in alphabetical order
+func Names() []string {
+	names := make([]string, 0, len(models))
+	for name := range models {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// check returns ErrInvalidHorizon for a horizon out of range and
+// ErrInsufficientData when rates is shorter than f needs
+func check(f Forecaster, rates []float64, horizon int) error {
+	if horizon < 1 || horizon > MaxHorizon {
+		return fmt.Errorf("horizon %d must be between 1 and %d: %w", horizon, MaxHorizon, ErrInvalidHorizon)
+	}
+	if len(rates) < f.MinPoints() {
+		return fmt.Errorf("%s model needs at least %d days, got %d: %w", f.Name(), f.MinPoints(), len(rates), ErrInsufficientData)
+	}
+	return nil
+}
+
+// mean returns the arithmetic mean of values
+func mean(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
@@ -0,0 +1,186 @@
+package forecast
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"testing"
+)
+
+// tolerance absorbs the rounding of values computed in another order
+const tolerance = 1e-9
+
+func near(got, want float64) bool {
+	return math.Abs(got-want) <= tolerance*math.Max(1, math.Abs(want))
+}
+
+// noisy is an upward drifting series, its golden values were computed
+// independently from the formulas each model documents
+var noisy = []float64{1.10, 1.12, 1.11, 1.14, 1.13, 1.16, 1.15, 1.18}
+
+func TestGet(t *testing.T) {
+	if got := Names(); !slices.Equal(got, []string{"arima", "heuristic", "holt", "linear"}) {
+		t.Errorf("Names() = %v", got)
+	}
+	if f, err := Get(""); err != nil || f.Name() != DefaultModel {
+		t.Errorf("Get(\"\") = %v, %v; want the %s model", f, err, DefaultModel)
+	}
+	if _, err := Get("prophet"); !errors.Is(err, ErrUnknownModel) {
+		t.Errorf("Get(prophet) error = %v, want ErrUnknownModel", err)
+	}
+}
+
+func TestForecastChecks(t *testing.T) {
+	for _, name := range Names() {
+		f, _ := Get(name)
+		series := func(n int) []float64 { return noisy[:n] }
+
+		tests := []struct {
+			name    string
+			rates   []float64
+			horizon int
+			wantErr error
+		}{
+			{"one point short", series(f.MinPoints() - 1), 1, ErrInsufficientData},
+			{"minimum points", series(f.MinPoints()), 1, nil},
+			{"horizon 0", noisy, 0, ErrInvalidHorizon},
+			{"horizon 1", noisy, 1, nil},
+			{"max horizon", noisy, MaxHorizon, nil},
+			{"past max horizon", noisy, MaxHorizon + 1, ErrInvalidHorizon},
+		}
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				result, err := f.Forecast(tt.rates, tt.horizon)
+				if tt.wantErr != nil {
+					if !errors.Is(err, tt.wantErr) {
+						t.Fatalf("error = %v, want %v", err, tt.wantErr)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("Forecast: %v", err)
+				}
+				if len(result.Steps) != tt.horizon {
+					t.Errorf("%d steps, want %d", len(result.Steps), tt.horizon)
+				}
+			})
+		}
+	}
+}
+
+func TestForecastGoldenValues(t *testing.T) {
+	tests := []struct {
+		name           string
+		model          Forecaster
+		rates          []float64
+		wantPredicted  []float64
+		wantStdErrors  []float64
+		wantMethod     string
+		wantParameters map[string]float64
+	}{
+		{
+			// Average 1.5, halves 1 and 2: a trend of 1, half of it per step. The log
+			// returns 0, ln 2, 0 have a sample standard deviation of 0.40019
+			name:           "heuristic step",
+			model:          Heuristic{},
+			rates:          []float64{1, 1, 2, 2},
+			wantPredicted:  []float64{2.25, 3},
+			wantStdErrors:  []float64{2.25 * 0.4001887112843146, 3 * 0.4001887112843146 * math.Sqrt2},
+			wantMethod:     MethodLogReturns,
+			wantParameters: map[string]float64{"average": 1.5, "trend": 1},
+		},
+		{
+			// Slope 4/5 and intercept 1.3; residuals -0.3, 0.9, -0.9, 0.3 leave 2
+			// degrees of freedom, too few, so the log return volatility is used
+			name:           "linear by hand",
+			model:          Linear{},
+			rates:          []float64{1, 3, 2, 4},
+			wantPredicted:  []float64{4.5, 5.3},
+			wantStdErrors:  []float64{3.5019136324903513, 5.832889976140789},
+			wantMethod:     MethodLogReturns,
+			wantParameters: map[string]float64{"intercept": 1.3, "slope": 0.8, "r_squared": 0.64},
+		},
+		{
+			name:           "linear noisy",
+			model:          Linear{},
+			rates:          noisy,
+			wantPredicted:  []float64{1.181785714285714, 1.1919047619047616, 1.2020238095238092},
+			wantStdErrors:  []float64{0.013564377867737818, 0.014534458105178423, 0.015619226068473435},
+			wantMethod:     MethodResiduals,
+			wantParameters: map[string]float64{"intercept": 1.1008333333333333, "slope": 0.010119047619047588},
+		},
+		{
+			// Every smoothing factor fits a line exactly, the first of the grid is kept
+			name:           "holt line",
+			model:          Holt{},
+			rates:          []float64{1, 2, 3, 4, 5, 6, 7},
+			wantPredicted:  []float64{8, 9},
+			wantStdErrors:  []float64{0, 0},
+			wantMethod:     MethodResiduals,
+			wantParameters: map[string]float64{"alpha": 0.1, "beta": 0.1, "level": 7, "trend": 1},
+		},
+		{
+			name:           "holt noisy",
+			model:          Holt{},
+			rates:          noisy,
+			wantPredicted:  []float64{1.1803287458611196, 1.1915433104998394, 1.2027578751385593},
+			wantStdErrors:  []float64{0.021952208608374192, 0.027050252203881978, 0.035396872789026194},
+			wantMethod:     MethodResiduals,
+			wantParameters: map[string]float64{"alpha": 0.4, "beta": 0.8, "level": 1.1691141812223997, "trend": 0.01121456463871987},
+		},
+		{
+			// Constant changes carry no autocorrelation: phi 0, one step of 1 per day
+			name:           "arima constant change",
+			model:          ARIMA{},
+			rates:          []float64{1, 2, 3, 4},
+			wantPredicted:  []float64{5, 6},
+			wantStdErrors:  []float64{5 * 0.20858082853406817, 6 * 0.20858082853406817 * math.Sqrt2},
+			wantMethod:     MethodLogReturns,
+			wantParameters: map[string]float64{"phi": 0, "constant": 1},
+		},
+		{
+			// Alternating changes regress with phi -1, clipped to -maxPhi;
+			// constant 0.1 + 0.99*0.4, then 0.496 + 0.99*0.5 and 0.496 - 0.99*0.991
+			name:           "arima alternating",
+			model:          ARIMA{},
+			rates:          []float64{1, 2, 1.5, 2.5, 2, 3, 2.5},
+			wantPredicted:  []float64{3.491, 3.00591},
+			wantStdErrors:  []float64{0.009486832980505145, 0.009487307310296221},
+			wantMethod:     MethodResiduals,
+			wantParameters: map[string]float64{"phi": -0.99, "constant": 0.496},
+		},
+		{
+			name:           "arima noisy",
+			model:          ARIMA{},
+			rates:          noisy,
+			wantPredicted:  []float64{1.1685499999999998, 1.1981354999999998, 1.1870958549999997},
+			wantStdErrors:  []float64{0.004633438248212623, 0.0046336699143335255, 0.006520480793406789},
+			wantMethod:     MethodResiduals,
+			wantParameters: map[string]float64{"phi": -0.99, "constant": 0.018249999999999943},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tt.model.Forecast(tt.rates, len(tt.wantPredicted))
+			if err != nil {
+				t.Fatalf("Forecast: %v", err)
+			}
+			if result.Method != tt.wantMethod {
+				t.Errorf("method = %q, want %q", result.Method, tt.wantMethod)
+			}
+			for i, step := range result.Steps {
+				if !near(step.Predicted, tt.wantPredicted[i]) {
+					t.Errorf("step %d predicted = %v, want %v", i+1, step.Predicted, tt.wantPredicted[i])
+				}
+				if !near(step.StdError, tt.wantStdErrors[i]) {
+					t.Errorf("step %d std error = %v, want %v", i+1, step.StdError, tt.wantStdErrors[i])
+				}
+			}
+			for name, want := range tt.wantParameters {
+				if got, ok := result.Parameters[name]; !ok || !near(got, want) {
+					t.Errorf("parameter %s = %v, want %v", name, got, want)
+				}
+			}
+		})
+	}
+}
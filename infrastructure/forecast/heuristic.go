@@ -0,0 +1,48 @@
+package forecast
+
+// Heuristic is the original model: the average of the series adjusted by half
+// the relative change between its first and second halves, once per step
+type Heuristic struct{}
+
+// Name returns "heuristic"
+func (Heuristic) Name() string { return "heuristic" }
+
+// Version returns 1
+func (Heuristic) Version() string { return "1" }
+
+// MinPoints returns 3
+func (Heuristic) MinPoints() int { return 3 }
+
+// Forecast predicts average + average*trend/2 for each step ahead
+func (m Heuristic) Forecast(rates []float64, horizon int) (*Result, error) {
+	if err := check(m, rates, horizon); err != nil {
+		return nil, err
+	}
+
+	average := mean(rates)
+
+	// Simple trend analysis: compare first half vs second half
+	midPoint := len(rates) / 2
+	firstHalfAvg := mean(rates[:midPoint])
+	secondHalfAvg := mean(rates[midPoint:])
+
+	// Calculate trend (positive = increasing, negative = decreasing)
+	trend := (secondHalfAvg - firstHalfAvg) / firstHalfAvg
+
+	predicted := make([]float64, horizon)
+	for h := range predicted {
+		// Use a conservative approach: 50% of the trend + 50% of the average
+		predicted[h] = average + average*trend*0.5*float64(h+1)
+
+		// Ensure predicted rate is positive
+		if predicted[h] <= 0 {
+			predicted[h] = average
+		}
+	}
+
+	// The heuristic isn't fitted to the data, so it has no residuals to learn from
+	return withStdErrors(predicted, map[string]float64{
+		"average": average,
+		"trend":   trend,
+	}, rates, nil, 0, nil), nil
+}
@@ -0,0 +1,86 @@
+package forecast
+
+import "math"
+
+// Holt is double exponential smoothing, tracking a level and a trend. The
+// smoothing factors are chosen by grid search to minimize the squared
+// one-step-ahead errors over the series.
+type Holt struct{}
+
+// Name returns "holt"
+func (Holt) Name() string { return "holt" }
+
+// Version returns 2, 1 counted the exactly predicted second value as a residual
+func (Holt) Version() string { return "2" }
+
+// MinPoints returns 3, two points initialize the level and trend
+func (Holt) MinPoints() int { return 3 }
+
+// Forecast predicts level + h*trend after smoothing the whole series
+func (m Holt) Forecast(rates []float64, horizon int) (*Result, error) {
+	if err := check(m, rates, horizon); err != nil {
+		return nil, err
+	}
+
+	best := math.Inf(1)
+	var alpha, beta, level, trend float64
+	var errors []float64
+	for a := 0.1; a < 0.95; a += 0.1 {
+		for b := 0.1; b < 0.95; b += 0.1 {
+			l, t, e := smooth(rates, a, b)
+			if sse := sumSquares(e); sse < best {
+				best = sse
+				alpha, beta, level, trend, errors = a, b, l, t, e
+			}
+		}
+	}
+
+	predicted := make([]float64, horizon)
+	for h := range predicted {
+		predicted[h] = level + float64(h+1)*trend
+	}
+
+	// Variance of the h-step error of the equivalent state space model, where
+	// the trend responds to errors with alpha*beta
+	ab := alpha * beta
+	scale := func(h int) float64 {
+		k := float64(h)
+		return math.Sqrt(1 + (k-1)*(alpha*alpha+alpha*ab*k+ab*ab*k*(2*k-1)/6))
+	}
+
+	return withStdErrors(predicted, map[string]float64{
+		"alpha": math.Round(alpha*10) / 10,
+		"beta":  math.Round(beta*10) / 10,
+		"level": level,
+		"trend": trend,
+	}, rates, errors, 2, scale), nil
+}
+
+// smooth runs Holt's recursions over values and returns the final level and
+// trend together with the one-step-ahead errors. The second value is left out
+// of the errors: the initial trend is taken from it, so it is always predicted
+// exactly.
+func smooth(values []float64, alpha, beta float64) (level, trend float64, errors []float64) {
+	level = values[0]
+	trend = values[1] - values[0]
+	errors = make([]float64, 0, len(values)-2)
+	for i, v := range values[1:] {
+		if i > 0 {
+			errors = append(errors, v-(level+trend))
+		}
+
+		previous := level
+		level = alpha*v + (1-alpha)*(level+trend)
+		trend = beta*(level-previous) + (1-beta)*trend
+	}
+	return level, trend, errors
+}
+
+// sumSquares returns the sum of the squares of values
+func sumSquares(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v * v
+	}
+	return sum
+}
@@ -0,0 +1,106 @@
+package forecast
+
+import "math"
+
+// Methods used to estimate the uncertainty of a prediction
+const (
+	// MethodResiduals derives it from the one-step errors of the model on the window
+	MethodResiduals = "residuals"
+
+	// MethodLogReturns derives it from the volatility of the daily log returns,
+	// used when the model leaves too few degrees of freedom for its residuals
+	MethodLogReturns = "log_returns"
+)
+
+// minDegreesOfFreedom is the fewest residuals beyond the model parameters
+// needed to trust their spread, below it the log return volatility is used
+const minDegreesOfFreedom = 3
+
+// IntervalLevels are the coverage levels of the prediction intervals in every forecast
+var IntervalLevels = []float64{0.80, 0.95}
+
+// Interval returns the bounds expected to contain the value with probability
+// level, assuming normally distributed errors. The lower bound never goes
+// below zero.
+func (s Step) Interval(level float64) (lower, upper float64) {
+	margin := quantile(0.5+level/2) * s.StdError
+	return math.Max(s.Predicted-margin, 0), s.Predicted + margin
+}
+
+// ProbabilityAbove returns the probability that the value exceeds threshold
+func (s Step) ProbabilityAbove(threshold float64) float64 {
+	if s.StdError <= 0 {
+		if s.Predicted > threshold {
+			return 1
+		}
+		return 0
+	}
+	return 0.5 * math.Erfc((threshold-s.Predicted)/(s.StdError*math.Sqrt2))
+}
+
+// quantile returns the standard normal quantile of p
+func quantile(p float64) float64 {
+	return math.Sqrt2 * math.Erfinv(2*p-1)
+}
+
+// residualStdError returns the standard error of residuals left by a model
+// with params estimated parameters, false when too few degrees of freedom are left
+func residualStdError(residuals []float64, params int) (float64, bool) {
+	dof := len(residuals) - params
+	if dof < minDegreesOfFreedom {
+		return 0, false
+	}
+	var sse float64
+	for _, r := range residuals {
+		sse += r * r
+	}
+	return math.Sqrt(sse / float64(dof)), true
+}
+
+// volatility returns the sample standard deviation of the daily log returns
+// of rates, which needs 3 rates
+func volatility(rates []float64) float64 {
+	returns := make([]float64, 0, len(rates)-1)
+	for i := 1; i < len(rates); i++ {
+		if rates[i-1] > 0 && rates[i] > 0 {
+			returns = append(returns, math.Log(rates[i]/rates[i-1]))
+		}
+	}
+	if len(returns) < 2 {
+		return 0
+	}
+
+	avg := mean(returns)
+	var variance float64
+	for _, r := range returns {
+		variance += (r - avg) * (r - avg)
+	}
+	return math.Sqrt(variance / float64(len(returns)-1))
+}
+
+// withStdErrors builds the result of a model predicting values. The errors
+// come from the model residuals, scale(h) being how much the one-step error
+// grows after h steps; without enough residuals they come from the log
+// return volatility of rates, growing like a random walk.
+func withStdErrors(predicted []float64, parameters map[string]float64, rates, residuals []float64, params int, scale func(h int) float64) *Result {
+	result := &Result{
+		Steps:      make([]Step, len(predicted)),
+		Parameters: parameters,
+	}
+
+	if se, ok := residualStdError(residuals, params); ok {
+		result.Method = MethodResiduals
+		for i, p := range predicted {
+			result.Steps[i] = Step{Predicted: p, StdError: se * scale(i+1)}
+		}
+		return result
+	}
+
+	// Log return volatility is relative to the level of each prediction
+	result.Method = MethodLogReturns
+	vol := volatility(rates)
+	for i, p := range predicted {
+		result.Steps[i] = Step{Predicted: p, StdError: p * vol * math.Sqrt(float64(i+1))}
+	}
+	return result
+}
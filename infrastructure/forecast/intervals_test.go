@@ -0,0 +1,150 @@
+package forecast
+
+import (
+	"math"
+	"testing"
+)
+
+// Standard normal quantiles of the 80% and 95% two-sided intervals
+const (
+	z80 = 1.2815515655446008
+	z95 = 1.9599639845400536
+)
+
+func TestStepInterval(t *testing.T) {
+	tests := []struct {
+		name      string
+		step      Step
+		level     float64
+		wantLower float64
+		wantUpper float64
+	}{
+		{"80%", Step{Predicted: 1.2, StdError: 0.01}, 0.80, 1.2 - z80*0.01, 1.2 + z80*0.01},
+		{"95%", Step{Predicted: 1.2, StdError: 0.01}, 0.95, 1.2 - z95*0.01, 1.2 + z95*0.01},
+		{"no error", Step{Predicted: 1.2}, 0.95, 1.2, 1.2},
+		{"lower bound clamped at zero", Step{Predicted: 0.01, StdError: 0.1}, 0.95, 0, 0.01 + z95*0.1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lower, upper := tt.step.Interval(tt.level)
+			if !near(lower, tt.wantLower) || !near(upper, tt.wantUpper) {
+				t.Errorf("Interval(%v) = [%v, %v], want [%v, %v]", tt.level, lower, upper, tt.wantLower, tt.wantUpper)
+			}
+		})
+	}
+}
+
+func TestProbabilityAbove(t *testing.T) {
+	step := Step{Predicted: 1.2, StdError: 0.01}
+	tests := []struct {
+		name      string
+		step      Step
+		threshold float64
+		want      float64
+	}{
+		{"at the prediction", step, 1.2, 0.5},
+		{"one std error above", step, 1.21, 0.15865525393145707},
+		{"two std errors below", step, 1.18, 0.9772498680518208},
+		{"no error, below", Step{Predicted: 1.2}, 1.1, 1},
+		{"no error, at the prediction", Step{Predicted: 1.2}, 1.2, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.step.ProbabilityAbove(tt.threshold); !near(got, tt.want) {
+				t.Errorf("ProbabilityAbove(%v) = %v, want %v", tt.threshold, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestStdErrorGrowth checks how each model widens its intervals with the
+// horizon, as the ratio of the h-step standard error to the one-step one
+func TestStdErrorGrowth(t *testing.T) {
+	// Linear on 8 days: 1 + 1/n + (x - 3.5)^2/42 for x = 8, 9, 10
+	linear := func(x float64) float64 { return 1 + 1.0/8 + (x-3.5)*(x-3.5)/42 }
+
+	tests := []struct {
+		name   string
+		model  Forecaster
+		rates  []float64
+		ratios []float64
+	}{
+		// alpha 0.4, beta 0.8: 1 + (h-1)(0.16 + 0.128h + 0.1024h(2h-1)/6)
+		{"holt", Holt{}, noisy, []float64{1, math.Sqrt(1.5184), math.Sqrt(2.6)}},
+		{"linear", Linear{}, noisy, []float64{1, math.Sqrt(linear(9) / linear(8)), math.Sqrt(linear(10) / linear(8))}},
+		// phi -0.99: cumulative weights 1, 0.01, 0.9901
+		{"arima", ARIMA{}, []float64{1, 2, 1.5, 2.5, 2, 3, 2.5}, []float64{1, math.Sqrt(1.0001), math.Sqrt(1.98039801)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tt.model.Forecast(tt.rates, len(tt.ratios))
+			if err != nil {
+				t.Fatalf("Forecast: %v", err)
+			}
+			for i, want := range tt.ratios {
+				if got := result.Steps[i].StdError / result.Steps[0].StdError; !near(got, want) {
+					t.Errorf("step %d grows by %v, want %v", i+1, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLogReturnStdErrors(t *testing.T) {
+	// A random walk relative to the level of each step. The log returns ln 2
+	// and -ln 2 have a sample standard deviation of sqrt(2)*ln 2
+	result := withStdErrors([]float64{2, 4}, nil, []float64{1, 2, 1}, nil, 0, nil)
+	vol := math.Sqrt2 * math.Ln2
+
+	if result.Method != MethodLogReturns {
+		t.Errorf("method = %q, want %q", result.Method, MethodLogReturns)
+	}
+	for i, want := range []float64{2 * vol, 4 * vol * math.Sqrt2} {
+		if got := result.Steps[i].StdError; !near(got, want) {
+			t.Errorf("step %d std error = %v, want %v", i+1, got, want)
+		}
+	}
+}
+
+func TestResidualStdError(t *testing.T) {
+	tests := []struct {
+		name      string
+		residuals []float64
+		params    int
+		want      float64
+		wantOK    bool
+	}{
+		{"three degrees of freedom", []float64{1, -1, 2, -2, 1}, 2, math.Sqrt(11.0 / 3), true},
+		{"two degrees of freedom", []float64{1, -1, 2, -2}, 2, 0, false},
+		{"no parameters", []float64{3, 4, 0}, 0, math.Sqrt(25.0 / 3), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := residualStdError(tt.residuals, tt.params)
+			if ok != tt.wantOK || !near(got, tt.want) {
+				t.Errorf("residualStdError = %v, %v; want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestVolatility(t *testing.T) {
+	tests := []struct {
+		name  string
+		rates []float64
+		want  float64
+	}{
+		{"flat", []float64{1, 1, 1}, 0},
+		{"two returns", []float64{1, 2, 1}, math.Sqrt2 * math.Ln2},
+		{"one return", []float64{1, 2}, 0},
+		// The return around the zero rate is skipped, leaving ln 2 and -ln 2
+		{"non-positive rate skipped", []float64{1, 2, 0, 2, 1}, math.Sqrt2 * math.Ln2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := volatility(tt.rates); !near(got, tt.want) {
+				t.Errorf("volatility(%v) = %v, want %v", tt.rates, got, tt.want)
+			}
+		})
+	}
+}
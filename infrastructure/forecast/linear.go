@@ -0,0 +1,77 @@
+package forecast
+
+import "math"
+
+// Linear fits a least squares line through the series against the day index
+// and extends it
+type Linear struct{}
+
+// Name returns "linear"
+func (Linear) Name() string { return "linear" }
+
+// Version returns 1
+func (Linear) Version() string { return "1" }
+
+// MinPoints returns 3, two points always fit a line exactly
+func (Linear) MinPoints() int { return 3 }
+
+// Forecast predicts intercept + slope*(n-1+h) for a series of n days
+func (m Linear) Forecast(rates []float64, horizon int) (*Result, error) {
+	if err := check(m, rates, horizon); err != nil {
+		return nil, err
+	}
+
+	intercept, slope := fitLine(rates)
+
+	// Share of the variance explained by the line
+	average := mean(rates)
+	residuals := make([]float64, len(rates))
+	var residual, total float64
+	for i, rate := range rates {
+		residuals[i] = rate - (intercept + slope*float64(i))
+		residual += residuals[i] * residuals[i]
+		total += (rate - average) * (rate - average)
+	}
+	rSquared := 1.0
+	if total > 0 {
+		rSquared = 1 - residual/total
+	}
+
+	n := float64(len(rates))
+	predicted := make([]float64, horizon)
+	for h := range predicted {
+		predicted[h] = intercept + slope*(n+float64(h))
+	}
+
+	// Extrapolating adds the uncertainty of the line itself, growing away from the window center
+	meanX := (n - 1) / 2
+	sxx := n * (n*n - 1) / 12
+	scale := func(h int) float64 {
+		x := n - 1 + float64(h)
+		return math.Sqrt(1 + 1/n + (x-meanX)*(x-meanX)/sxx)
+	}
+
+	return withStdErrors(predicted, map[string]float64{
+		"intercept": intercept,
+		"slope":     slope,
+		"r_squared": rSquared,
+	}, rates, residuals, 2, scale), nil
+}
+
+// fitLine returns the ordinary least squares line of values against their index
+func fitLine(values []float64) (intercept, slope float64) {
+	n := float64(len(values))
+	meanX := (n - 1) / 2
+	meanY := mean(values)
+
+	var sxy, sxx float64
+	for i, v := range values {
+		dx := float64(i) - meanX
+		sxy += dx * (v - meanY)
+		sxx += dx * dx
+	}
+	if sxx > 0 {
+		slope = sxy / sxx
+	}
+	return meanY - slope*meanX, slope
+}
@@ -0,0 +1,154 @@
+package health
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Component statuses reported by the checker
+const (
+	StatusUp       = "up"
+	StatusDown     = "down"
+	StatusDegraded = "degraded"
+
+	// StatusDraining is reported once shutdown has started
+	StatusDraining = "draining"
+)
+
+// CheckFunc verifies one dependency, returning nil when it is healthy
+type CheckFunc func(ctx context.Context) error
+
+// ComponentStatus is the result of a single check
+type ComponentStatus struct {
+	Status    string    `json:"status"`
+	Critical  bool      `json:"critical"`
+	Error     string    `json:"error,omitempty"`
+	LatencyMS int64     `json:"latency_ms"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Report is the aggregated readiness of the service
+type Report struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentStatus `json:"components"`
+	Timestamp  time.Time                  `json:"timestamp"`
+}
+
+// Ready reports whether every critical component is up and the service isn't shutting down
+func (r Report) Ready() bool {
+	return r.Status != StatusDown && r.Status != StatusDraining
+}
+
+type check struct {
+	name     string
+	critical bool
+	fn       CheckFunc
+}
+
+// Checker runs the registered dependency checks concurrently
+type Checker struct {
+	timeout  time.Duration
+	checks   []check
+	draining atomic.Bool
+}
+
+// NewChecker creates a Checker giving each check at most timeout to complete
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Add registers a check. A failing critical check makes the service not ready,
+// a failing non-critical one only degrades it.
+func (c *Checker) Add(name string, critical bool, fn CheckFunc) {
+	c.checks = append(c.checks, check{name: name, critical: critical, fn: fn})
+}
+
+// Drain marks the service as shutting down, every later report is draining so
+// the load balancer stops routing new requests to it
+func (c *Checker) Drain() {
+	c.draining.Store(true)
+}
+
+// Check runs every registered check and aggregates the results
+func (c *Checker) Check(ctx context.Context) Report {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	components := make(map[string]ComponentStatus, len(c.checks))
+
+	for _, chk := range c.checks {
+		wg.Go(func() {
+			start := time.Now()
+			err := chk.fn(ctx)
+
+			status := ComponentStatus{
+				Status:    StatusUp,
+				Critical:  chk.critical,
+				LatencyMS: time.Since(start).Milliseconds(),
+				CheckedAt: start.UTC(),
+			}
+			if err != nil {
+				status.Status = StatusDown
+				status.Error = err.Error()
+			}
+
+			mu.Lock()
+			components[chk.name] = status
+			mu.Unlock()
+		})
+	}
+	wg.Wait()
+
+	overall := StatusUp
+	names := make([]string, 0, len(components))
+	for name := range components {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		component := components[name]
+		if component.Status != StatusDown {
+			continue
+		}
+		if component.Critical {
+			overall = StatusDown
+		} else if overall == StatusUp {
+			overall = StatusDegraded
+		}
+	}
+
+	if c.draining.Load() {
+		overall = StatusDraining
+	}
+
+	return Report{
+		Status:     overall,
+		Components: components,
+		Timestamp:  time.Now().UTC(),
+	}
+}
+
+// Cached wraps a check so its result is reused for ttl, for dependencies
+// that are slow or rate limited such as the external rate providers
+func Cached(ttl time.Duration, fn CheckFunc) CheckFunc {
+	var mu sync.Mutex
+	var lastErr error
+	var lastRun time.Time
+
+	return func(ctx context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if !lastRun.IsZero() && time.Since(lastRun) < ttl {
+			return lastErr
+		}
+		lastErr = fn(ctx)
+		lastRun = time.Now()
+		return lastErr
+	}
+}
@@ -0,0 +1,77 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func up(ctx context.Context) error   { return nil }
+func down(ctx context.Context) error { return errors.New("unreachable") }
+
+func TestCheckAggregation(t *testing.T) {
+	tests := []struct {
+		name      string
+		critical  CheckFunc
+		optional  CheckFunc
+		drain     bool
+		want      string
+		wantReady bool
+	}{
+		{"all up", up, up, false, StatusUp, true},
+		{"non-critical down", up, down, false, StatusDegraded, true},
+		{"critical down", down, up, false, StatusDown, false},
+		{"both down", down, down, false, StatusDown, false},
+		{"draining", up, up, true, StatusDraining, false},
+		{"draining while degraded", up, down, true, StatusDraining, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := NewChecker(time.Second)
+			checker.Add("database", true, tt.critical)
+			checker.Add("provider", false, tt.optional)
+			if tt.drain {
+				checker.Drain()
+			}
+
+			report := checker.Check(context.Background())
+			if report.Status != tt.want || report.Ready() != tt.wantReady {
+				t.Errorf("status %s, ready %v; want %s, %v", report.Status, report.Ready(), tt.want, tt.wantReady)
+			}
+			if len(report.Components) != 2 {
+				t.Fatalf("%d components, want 2", len(report.Components))
+			}
+			if !report.Components["database"].Critical || report.Components["provider"].Critical {
+				t.Errorf("components = %+v, want only the database critical", report.Components)
+			}
+		})
+	}
+}
+
+func TestCheckTimeout(t *testing.T) {
+	checker := NewChecker(10 * time.Millisecond)
+	checker.Add("slow", true, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if report := checker.Check(context.Background()); report.Ready() {
+		t.Errorf("report = %+v, want a check past the timeout to fail", report)
+	}
+}
+
+func TestCached(t *testing.T) {
+	calls := 0
+	check := Cached(time.Hour, func(ctx context.Context) error {
+		calls++
+		return errors.New("down")
+	})
+	for range 3 {
+		if err := check(context.Background()); err == nil {
+			t.Error("cached check error = nil, want the first result")
+		}
+	}
+	if calls != 1 {
+		t.Errorf("%d calls, want 1 within the ttl", calls)
+	}
+}
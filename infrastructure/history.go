@@ -0,0 +1,185 @@
+package infrastructure
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/joy-currency-conversion-private/domain"
+)
+
+// GetHistory returns the daily rates of a date range, one row per calendar
+// day with the missing ones flagged or filled in, optionally aggregated by week
+// or month. Ranges up to maxFetchDays are completed from the provider, longer
+// ones are served from the rate store alone.
+func (s *CurrencyService) GetHistory(ctx context.Context, req *domain.HistoryRequest) (*domain.HistoryResponse, error) {
+	originCurrency, err := s.GetCurrencyInfo(ctx, req.Origin)
+	if err != nil {
+		return nil, err
+	}
+	// Temporal code because currently the API  only allows EUR origin
+	if req.Origin != "EUR" {
+		originCurrency, _ = s.GetCurrencyInfo(ctx, "EUR")
+	}
+
+	destCurrency, err := s.GetCurrencyInfo(ctx, req.Destination)
+	if err != nil {
+		return nil, err
+	}
+
+	interval, fill := req.Interval, req.Fill
+	if interval == "" {
+		interval = domain.IntervalDay
+	}
+	if fill == "" {
+		fill = domain.FillNone
+	}
+
+	var rates []domain.HistoryRate
+	source := ExchangeRatesAPI
+	if req.EndDate.Sub(req.StartDate).Hours()/24 > maxFetchDays {
+		if req.StartDate.After(time.Now()) || req.EndDate.After(time.Now()) {
+			return nil, fmt.Errorf("the start date and end date must not be greater than the current date")
+		}
+		// The API only serves EUR based rates, so they are stored under the EUR origin
+		stored, err := s.rates.GetRates(ctx, "EUR", req.Destination, req.StartDate, req.EndDate)
+		if err != nil {
+			return nil, fmt.Errorf("error reading stored rates: %w", err)
+		}
+		rates = publishedRates(source, stored)
+	} else {
+		rates, source, err = s.GetHistoricalRates(ctx, req.Origin, req.Destination, req.StartDate, req.EndDate)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	points := dailyPoints(req.StartDate, req.EndDate, rates)
+	switch fill {
+	case domain.FillPrevious:
+		fillPrevious(points)
+	case domain.FillInterpolate:
+		fillInterpolate(points)
+	}
+	if interval != domain.IntervalDay {
+		points = resample(points, interval)
+	}
+
+	response := &domain.HistoryResponse{
+		Origin:      *originCurrency,
+		Destination: *destCurrency,
+		StartDate:   req.StartDate.Format("2006-01-02"),
+		EndDate:     req.EndDate.Format("2006-01-02"),
+		Interval:    interval,
+		Fill:        fill,
+		Rates:       points,
+		Timestamp:   time.Now().UTC(),
+		RatesSource: source,
+		Message:     "I'm sorry, for now the origin must alway be the EUR code",
+	}
+
+	return response, nil
+}
+
+// dailyPoints returns one point per calendar day from start to end, flagged
+// missing when rates has none for it
+func dailyPoints(start, end time.Time, rates []domain.HistoryRate) []domain.HistoryPoint {
+	byDate := make(map[string]float64, len(rates))
+	for _, rate := range rates {
+		byDate[rate.Date] = rate.Rate
+	}
+
+	var points []domain.HistoryPoint
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		point := domain.HistoryPoint{Date: day.Format("2006-01-02")}
+		if rate, ok := byDate[point.Date]; ok {
+			point.Rate = &rate
+		} else {
+			point.Missing = true
+		}
+		points = append(points, point)
+	}
+	return points
+}
+
+// fillPrevious carries the last known rate over the missing days after it
+func fillPrevious(points []domain.HistoryPoint) {
+	var last *float64
+	for i := range points {
+		if !points[i].Missing {
+			last = points[i].Rate
+			continue
+		}
+		if last != nil {
+			rate := *last
+			points[i].Rate, points[i].Missing, points[i].Filled = &rate, false, true
+		}
+	}
+}
+
+// fillInterpolate fills the missing days between two known rates on the
+// straight line joining them. Days before the first or after the last known
+// rate stay missing.
+func fillInterpolate(points []domain.HistoryPoint) {
+	previous := -1
+	for i := range points {
+		if points[i].Missing {
+			continue
+		}
+		if previous >= 0 && i-previous > 1 {
+			from, to := *points[previous].Rate, *points[i].Rate
+			for j := previous + 1; j < i; j++ {
+				rate := from + (to-from)*float64(j-previous)/float64(i-previous)
+				points[j].Rate, points[j].Missing, points[j].Filled = &rate, false, true
+			}
+		}
+		previous = i
+	}
+}
+
+// resample aggregates daily points into the open, high, low and close of every
+// week or month. A period without any rate is flagged missing.
+func resample(points []domain.HistoryPoint, interval string) []domain.HistoryPoint {
+	var periods []domain.HistoryPoint
+	for _, point := range points {
+		day, err := time.Parse("2006-01-02", point.Date)
+		if err != nil {
+			continue
+		}
+		start := periodStart(day, interval).Format("2006-01-02")
+
+		if len(periods) == 0 || periods[len(periods)-1].Date != start {
+			periods = append(periods, domain.HistoryPoint{Date: start, Missing: true})
+		}
+		period := &periods[len(periods)-1]
+
+		if point.Missing {
+			continue
+		}
+		rate := *point.Rate
+		if period.Missing {
+			open, high, low := rate, rate, rate
+			period.Open, period.High, period.Low = &open, &high, &low
+			period.Missing = false
+		}
+		*period.High = max(*period.High, rate)
+		*period.Low = min(*period.Low, rate)
+		closeRate := rate
+		period.Close, period.Rate = &closeRate, &closeRate
+		if point.Filled {
+			period.Filled = true
+		} else {
+			period.Days++
+		}
+	}
+	return periods
+}
+
+// periodStart returns the Monday of the week or the first day of the month of day
+func periodStart(day time.Time, interval string) time.Time {
+	if interval == domain.IntervalMonth {
+		return time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, day.Location())
+	}
+	daysSinceMonday := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -daysSinceMonday)
+}
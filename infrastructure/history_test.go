@@ -0,0 +1,269 @@
+package infrastructure
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/joy-currency-conversion-private/domain"
+)
+
+// twoWeeks holds the weekdays published from Monday 2025-09-01 to Sunday
+// 2025-09-14: Wednesday the 3rd, the weekends and the second week after
+// Tuesday the 9th are missing
+var twoWeeks = []domain.HistoryRate{
+	{Date: "2025-09-01", Rate: 1.10},
+	{Date: "2025-09-02", Rate: 1.12},
+	{Date: "2025-09-04", Rate: 1.08},
+	{Date: "2025-09-05", Rate: 1.11},
+	{Date: "2025-09-09", Rate: 1.20},
+}
+
+// day is the expected rate of a day, NaN when it is missing
+type day struct {
+	rate   float64
+	filled bool
+}
+
+// checkDays compares daily points from 2025-09-01 on with the expected rates
+func checkDays(t *testing.T, points []domain.HistoryPoint, want []day) {
+	t.Helper()
+	if len(points) != len(want) {
+		t.Fatalf("%d points, want %d", len(points), len(want))
+	}
+	for i, w := range want {
+		p := points[i]
+		if wantDate := date("2025-09-01").AddDate(0, 0, i).Format("2006-01-02"); p.Date != wantDate {
+			t.Errorf("point %d date = %s, want %s", i, p.Date, wantDate)
+		}
+		missing := math.IsNaN(w.rate)
+		if p.Missing != missing || p.Filled != w.filled || (p.Rate == nil) != missing {
+			t.Errorf("%s: missing %v filled %v, want missing %v filled %v", p.Date, p.Missing, p.Filled, missing, w.filled)
+			continue
+		}
+		if !missing && !nearly(*p.Rate, w.rate) {
+			t.Errorf("%s: rate %v, want %v", p.Date, *p.Rate, w.rate)
+		}
+	}
+}
+
+func TestDailyPointsAndFill(t *testing.T) {
+	nan := math.NaN()
+	published := func(rate float64) day { return day{rate: rate} }
+	filled := func(rate float64) day { return day{rate: rate, filled: true} }
+	missing := day{rate: nan}
+
+	tests := []struct {
+		name string
+		fill func([]domain.HistoryPoint)
+		want []day
+	}{
+		{
+			name: "none",
+			want: []day{
+				published(1.10), published(1.12), missing, published(1.08), published(1.11), missing, missing,
+				missing, published(1.20), missing, missing, missing, missing, missing,
+			},
+		},
+		{
+			// The trailing days carry the last rate too
+			name: "previous",
+			fill: fillPrevious,
+			want: []day{
+				published(1.10), published(1.12), filled(1.12), published(1.08), published(1.11), filled(1.11), filled(1.11),
+				filled(1.11), published(1.20), filled(1.20), filled(1.20), filled(1.20), filled(1.20), filled(1.20),
+			},
+		},
+		{
+			// Wednesday halfway between 1.12 and 1.08, the weekend and Monday in
+			// quarters from 1.11 to 1.20; nothing follows the last rate
+			name: "interpolate",
+			fill: fillInterpolate,
+			want: []day{
+				published(1.10), published(1.12), filled(1.10), published(1.08), published(1.11), filled(1.1325), filled(1.155),
+				filled(1.1775), published(1.20), missing, missing, missing, missing, missing,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			points := dailyPoints(date("2025-09-01"), date("2025-09-14"), twoWeeks)
+			if tt.fill != nil {
+				tt.fill(points)
+			}
+			checkDays(t, points, tt.want)
+		})
+	}
+}
+
+func TestFillLeadingGap(t *testing.T) {
+	// Nothing precedes the first rate, so neither fill can fill the days before it
+	rates := []domain.HistoryRate{{Date: "2025-09-03", Rate: 1.1}, {Date: "2025-09-04", Rate: 1.2}}
+	for name, fill := range map[string]func([]domain.HistoryPoint){"previous": fillPrevious, "interpolate": fillInterpolate} {
+		t.Run(name, func(t *testing.T) {
+			points := dailyPoints(date("2025-09-01"), date("2025-09-04"), rates)
+			fill(points)
+			checkDays(t, points, []day{{rate: math.NaN()}, {rate: math.NaN()}, {rate: 1.1}, {rate: 1.2}})
+		})
+	}
+}
+
+func TestDailyPointsBounds(t *testing.T) {
+	// Rates outside the range are ignored, a one day range has one point
+	points := dailyPoints(date("2025-09-02"), date("2025-09-02"), twoWeeks)
+	if len(points) != 1 || points[0].Date != "2025-09-02" || points[0].Rate == nil || *points[0].Rate != 1.12 {
+		t.Errorf("dailyPoints(one day) = %+v", points)
+	}
+	if points := dailyPoints(date("2025-09-03"), date("2025-09-02"), twoWeeks); len(points) != 0 {
+		t.Errorf("dailyPoints(reversed) = %+v, want none", points)
+	}
+}
+
+// period is the expected OHLC of a week or month, all NaN when it is missing
+type period struct {
+	date                   string
+	open, high, low, close float64
+	days                   int
+	filled                 bool
+}
+
+func TestResampleGoldenValues(t *testing.T) {
+	nan := math.NaN()
+
+	tests := []struct {
+		name     string
+		start    string
+		end      string
+		fill     func([]domain.HistoryPoint)
+		interval string
+		want     []period
+	}{
+		{
+			name: "weeks", start: "2025-09-01", end: "2025-09-14", interval: domain.IntervalWeek,
+			want: []period{
+				{"2025-09-01", 1.10, 1.12, 1.08, 1.11, 4, false},
+				{"2025-09-08", 1.20, 1.20, 1.20, 1.20, 1, false},
+			},
+		},
+		{
+			// Filled days shape the OHLC but only published ones are counted
+			name: "weeks filled with the previous rate", start: "2025-09-01", end: "2025-09-14", fill: fillPrevious, interval: domain.IntervalWeek,
+			want: []period{
+				{"2025-09-01", 1.10, 1.12, 1.08, 1.11, 4, true},
+				{"2025-09-08", 1.11, 1.20, 1.11, 1.20, 1, true},
+			},
+		},
+		{
+			// The interpolated weekend closes the first week higher than any published day
+			name: "weeks interpolated", start: "2025-09-01", end: "2025-09-14", fill: fillInterpolate, interval: domain.IntervalWeek,
+			want: []period{
+				{"2025-09-01", 1.10, 1.155, 1.08, 1.155, 4, true},
+				{"2025-09-08", 1.1775, 1.20, 1.1775, 1.20, 1, true},
+			},
+		},
+		{
+			// A range starting on a Sunday opens with the week of the Monday
+			// before, a week without rates is missing
+			name: "partial and empty weeks", start: "2025-08-31", end: "2025-09-21", interval: domain.IntervalWeek,
+			want: []period{
+				{"2025-08-25", nan, nan, nan, nan, 0, false},
+				{"2025-09-01", 1.10, 1.12, 1.08, 1.11, 4, false},
+				{"2025-09-08", 1.20, 1.20, 1.20, 1.20, 1, false},
+				{"2025-09-15", nan, nan, nan, nan, 0, false},
+			},
+		},
+		{
+			name: "months", start: "2025-08-30", end: "2025-09-14", interval: domain.IntervalMonth,
+			want: []period{
+				{"2025-08-01", nan, nan, nan, nan, 0, false},
+				{"2025-09-01", 1.10, 1.20, 1.08, 1.20, 5, false},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			points := dailyPoints(date(tt.start), date(tt.end), twoWeeks)
+			if tt.fill != nil {
+				tt.fill(points)
+			}
+			checkPeriods(t, resample(points, tt.interval), tt.want)
+		})
+	}
+}
+
+func checkPeriods(t *testing.T, periods []domain.HistoryPoint, want []period) {
+	t.Helper()
+	if len(periods) != len(want) {
+		t.Fatalf("%d periods, want %d: %+v", len(periods), len(want), periods)
+	}
+	for i, w := range want {
+		p := periods[i]
+		if p.Date != w.date || p.Days != w.days || p.Filled != w.filled {
+			t.Errorf("period %d = %s, %d days, filled %v; want %s, %d days, filled %v", i, p.Date, p.Days, p.Filled, w.date, w.days, w.filled)
+		}
+		if math.IsNaN(w.open) {
+			if !p.Missing || p.Rate != nil || p.Open != nil || p.Close != nil {
+				t.Errorf("%s: %+v, want a missing period", p.Date, p)
+			}
+			continue
+		}
+		if p.Missing || p.Open == nil || p.Rate != p.Close {
+			t.Errorf("%s: %+v, want an OHLC with the close as its rate", p.Date, p)
+			continue
+		}
+		if !nearly(*p.Open, w.open) || !nearly(*p.High, w.high) || !nearly(*p.Low, w.low) || !nearly(*p.Close, w.close) {
+			t.Errorf("%s: OHLC %v %v %v %v, want %v %v %v %v", p.Date, *p.Open, *p.High, *p.Low, *p.Close, w.open, w.high, w.low, w.close)
+		}
+	}
+}
+
+func TestPeriodStart(t *testing.T) {
+	tests := []struct {
+		day      string
+		interval string
+		want     string
+	}{
+		{"2025-09-01", domain.IntervalWeek, "2025-09-01"}, // Monday
+		{"2025-09-07", domain.IntervalWeek, "2025-09-01"}, // Sunday
+		{"2025-10-01", domain.IntervalWeek, "2025-09-29"}, // across a month
+		{"2026-01-01", domain.IntervalWeek, "2025-12-29"}, // across a year
+		{"2025-09-30", domain.IntervalMonth, "2025-09-01"},
+		{"2025-09-01", domain.IntervalMonth, "2025-09-01"},
+	}
+	for _, tt := range tests {
+		if got := periodStart(date(tt.day), tt.interval).Format("2006-01-02"); got != tt.want {
+			t.Errorf("periodStart(%s, %s) = %s, want %s", tt.day, tt.interval, got, tt.want)
+		}
+	}
+}
+
+func TestGetHistoryFromStore(t *testing.T) {
+	// Longer than maxFetchDays, so it is served from the rate store alone
+	service := newStoreOnlyCurrencyService(t, append([]domain.HistoryRate{{Date: "2025-09-06", Rate: 9}}, twoWeeks...))
+
+	response, err := service.GetHistory(context.Background(), &domain.HistoryRequest{
+		Origin: "EUR", Destination: "USD", StartDate: date("2025-09-01"), EndDate: date("2025-09-14"),
+		Interval: domain.IntervalWeek, Fill: domain.FillPrevious,
+	})
+	if err != nil {
+		t.Fatalf("GetHistory: %v", err)
+	}
+	if response.Interval != domain.IntervalWeek || response.Fill != domain.FillPrevious {
+		t.Errorf("interval, fill = %s, %s", response.Interval, response.Fill)
+	}
+	// The stored Saturday isn't published by the provider and is filled like the rest of the weekend
+	checkPeriods(t, response.Rates, []period{
+		{"2025-09-01", 1.10, 1.12, 1.08, 1.11, 4, true},
+		{"2025-09-08", 1.11, 1.20, 1.11, 1.20, 1, true},
+	})
+
+	daily, err := service.GetHistory(context.Background(), &domain.HistoryRequest{
+		Origin: "EUR", Destination: "USD", StartDate: date("2025-09-01"), EndDate: date("2025-09-14"),
+	})
+	if err != nil {
+		t.Fatalf("GetHistory: %v", err)
+	}
+	if daily.Interval != domain.IntervalDay || daily.Fill != domain.FillNone || len(daily.Rates) != 14 || !daily.Rates[5].Missing {
+		t.Errorf("default history = %s, %s, %d rates, Saturday %+v", daily.Interval, daily.Fill, len(daily.Rates), daily.Rates[5])
+	}
+}
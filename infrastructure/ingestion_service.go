@@ -0,0 +1,89 @@
+package infrastructure
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/joy-currency-conversion-private/domain"
+	"github.com/joy-currency-conversion-private/infrastructure/forecast"
+	"github.com/joy-currency-conversion-private/infrastructure/metrics"
+)
+
+// IngestionService implements domain.IngestionService: it stores the latest
+// daily rates of the pairs referenced by favorites, then precomputes their
+// forecasts so /forecast is served from the forecast store
+type IngestionService struct {
+	favorites       domain.FavoriteRepository
+	currencyService *CurrencyService
+}
+
+// NewIngestionService creates a new IngestionService
+func NewIngestionService(favorites domain.FavoriteRepository, currencyService *CurrencyService) *IngestionService {
+	return &IngestionService{
+		favorites:       favorites,
+		currencyService: currencyService,
+	}
+}
+
+// IngestRates fetches the last 5 days of every favorite pair missing from the
+// rate store and stores a forecast of every model with the default horizon and
+// lookback. A failing pair is reported without stopping the others.
+func (s *IngestionService) IngestRates(ctx context.Context) (*domain.IngestionResponse, error) {
+	defer metrics.ObserveIngestion(time.Now())
+
+	favorites, err := s.favorites.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get favorites: %w", err)
+	}
+
+	// The API only serves EUR based rates, so favorites with the same destination share them
+	seen := map[string]bool{}
+	var destinations []string
+	for _, favorite := range favorites {
+		if !seen[favorite.Destination.Code] {
+			seen[favorite.Destination.Code] = true
+			destinations = append(destinations, favorite.Destination.Code)
+		}
+	}
+
+	endDate := time.Now().AddDate(0, 0, -1) // Yesterday (since today's data might not be available)
+	results := make([]domain.IngestionResult, 0, len(destinations))
+	for _, destination := range destinations {
+		result := domain.IngestionResult{Origin: "EUR", Destination: destination}
+
+		rates, _, err := s.currencyService.GetHistoricalRates(ctx, "EUR", destination, endDate.AddDate(0, 0, -4), endDate)
+		if err != nil {
+			slog.WarnContext(ctx, "ingesting rates failed", "destination", destination, "error", err)
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+		result.Rates = len(rates)
+
+		// Forecasts still fresh are left as they are
+		for _, model := range forecast.Names() {
+			_, err := s.currencyService.GetForecast(ctx, &domain.ForecastRequest{
+				Origin:      "EUR",
+				Destination: destination,
+				Model:       model,
+			})
+			if err != nil {
+				slog.WarnContext(ctx, "precomputing forecast failed", "destination", destination, "model", model, "error", err)
+				result.Error = err.Error()
+				continue
+			}
+			result.Forecasts++
+		}
+
+		results = append(results, result)
+	}
+
+	slog.InfoContext(ctx, "rate ingestion finished", "pairs", len(results))
+
+	return &domain.IngestionResponse{
+		Results:   results,
+		Timestamp: time.Now().UTC(),
+	}, nil
+}
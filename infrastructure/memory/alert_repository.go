@@ -0,0 +1,52 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/joy-currency-conversion-private/domain"
+)
+
+// AlertStateRepository implements domain.AlertStateRepository in memory
+type AlertStateRepository struct {
+	mu     sync.RWMutex
+	states map[string]domain.AlertState
+}
+
+// NewAlertStateRepository creates a new empty AlertStateRepository
+func NewAlertStateRepository() *AlertStateRepository {
+	return &AlertStateRepository{
+		states: map[string]domain.AlertState{},
+	}
+}
+
+// Get returns the alert state of a favorite
+func (r *AlertStateRepository) Get(ctx context.Context, favoriteID string) (*domain.AlertState, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	state, exists := r.states[favoriteID]
+	if !exists {
+		return nil, fmt.Errorf("alert state %s: %w", favoriteID, domain.ErrNotFound)
+	}
+	return &state, nil
+}
+
+// Save stores the alert state of a favorite
+func (r *AlertStateRepository) Save(ctx context.Context, state *domain.AlertState) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.states[state.FavoriteID] = *state
+	return nil
+}
+
+// Delete removes the alert state of a favorite
+func (r *AlertStateRepository) Delete(ctx context.Context, favoriteID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.states, favoriteID)
+	return nil
+}
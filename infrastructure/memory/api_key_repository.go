@@ -0,0 +1,83 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/joy-currency-conversion-private/domain"
+)
+
+// APIKeyRepository implements domain.APIKeyRepository in memory
+type APIKeyRepository struct {
+	mu   sync.RWMutex
+	keys map[string]domain.APIKey
+}
+
+// NewAPIKeyRepository creates a new empty APIKeyRepository
+func NewAPIKeyRepository() *APIKeyRepository {
+	return &APIKeyRepository{
+		keys: map[string]domain.APIKey{},
+	}
+}
+
+// Create stores a new API key
+func (r *APIKeyRepository) Create(ctx context.Context, key *domain.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.keys {
+		if existing.ID == key.ID || existing.Hash == key.Hash {
+			return fmt.Errorf("api key %s: %w", key.ID, domain.ErrAlreadyExists)
+		}
+	}
+	r.keys[key.ID] = *key
+	return nil
+}
+
+// GetByHash returns the API key with the given hash
+func (r *APIKeyRepository) GetByHash(ctx context.Context, hash string) (*domain.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, key := range r.keys {
+		if key.Hash == hash {
+			return &key, nil
+		}
+	}
+	return nil, fmt.Errorf("api key: %w", domain.ErrNotFound)
+}
+
+// List returns all API keys ordered by creation time
+func (r *APIKeyRepository) List(ctx context.Context) ([]domain.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := make([]domain.APIKey, 0, len(r.keys))
+	for _, key := range r.keys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if !keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+		}
+		return keys[i].ID < keys[j].ID
+	})
+	return keys, nil
+}
+
+// Revoke marks the API key with the given ID as revoked
+func (r *APIKeyRepository) Revoke(ctx context.Context, id string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, exists := r.keys[id]
+	if !exists || key.RevokedAt != nil {
+		return fmt.Errorf("api key %s: %w", id, domain.ErrNotFound)
+	}
+	key.RevokedAt = &at
+	r.keys[id] = key
+	return nil
+}
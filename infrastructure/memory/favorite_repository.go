@@ -0,0 +1,117 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/joy-currency-conversion-private/domain"
+)
+
+// FavoriteRepository implements domain.FavoriteRepository in memory
+type FavoriteRepository struct {
+	mu        sync.RWMutex
+	favorites map[string]domain.Favorite
+}
+
+// NewFavoriteRepository creates a new empty FavoriteRepository
+func NewFavoriteRepository() *FavoriteRepository {
+	return &FavoriteRepository{
+		favorites: map[string]domain.Favorite{},
+	}
+}
+
+// Create stores a new favorite, rejecting a taken ID or a duplicate of another favorite
+func (r *FavoriteRepository) Create(ctx context.Context, favorite *domain.Favorite) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.favorites[favorite.ID]; exists {
+		return fmt.Errorf("favorite %s: %w", favorite.ID, domain.ErrAlreadyExists)
+	}
+	key := favorite.UniqueKey()
+	for _, other := range r.favorites {
+		if other.UniqueKey() == key {
+			return fmt.Errorf("favorite %s: %w", other.ID, domain.ErrAlreadyExists)
+		}
+	}
+	r.favorites[favorite.ID] = *favorite
+	return nil
+}
+
+// Get returns the favorite with the given ID
+func (r *FavoriteRepository) Get(ctx context.Context, id string) (*domain.Favorite, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	favorite, exists := r.favorites[id]
+	if !exists {
+		return nil, fmt.Errorf("favorite %s: %w", id, domain.ErrNotFound)
+	}
+	return &favorite, nil
+}
+
+// List returns all stored favorites ordered by creation time
+func (r *FavoriteRepository) List(ctx context.Context) ([]domain.Favorite, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	favorites := make([]domain.Favorite, 0, len(r.favorites))
+	for _, favorite := range r.favorites {
+		favorites = append(favorites, favorite)
+	}
+	sortFavorites(favorites)
+	return favorites, nil
+}
+
+// ListByEmail returns the favorites notifying the given email
+func (r *FavoriteRepository) ListByEmail(ctx context.Context, email string) ([]domain.Favorite, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	favorites := make([]domain.Favorite, 0)
+	for _, favorite := range r.favorites {
+		if favorite.NotifyEmail == email {
+			favorites = append(favorites, favorite)
+		}
+	}
+	sortFavorites(favorites)
+	return favorites, nil
+}
+
+// ListByOwner returns the favorites of the given user
+func (r *FavoriteRepository) ListByOwner(ctx context.Context, ownerID string) ([]domain.Favorite, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	favorites := make([]domain.Favorite, 0)
+	for _, favorite := range r.favorites {
+		if favorite.OwnerID == ownerID {
+			favorites = append(favorites, favorite)
+		}
+	}
+	sortFavorites(favorites)
+	return favorites, nil
+}
+
+func sortFavorites(favorites []domain.Favorite) {
+	sort.Slice(favorites, func(i, j int) bool {
+		if favorites[i].CreatedAt.Equal(favorites[j].CreatedAt) {
+			return favorites[i].ID < favorites[j].ID
+		}
+		return favorites[i].CreatedAt.Before(favorites[j].CreatedAt)
+	})
+}
+
+// Delete removes the favorite with the given ID
+func (r *FavoriteRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.favorites[id]; !exists {
+		return fmt.Errorf("favorite %s: %w", id, domain.ErrNotFound)
+	}
+	delete(r.favorites, id)
+	return nil
+}
@@ -0,0 +1,48 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/joy-currency-conversion-private/domain"
+)
+
+// ForecastRepository implements domain.ForecastRepository in memory
+type ForecastRepository struct {
+	mu        sync.RWMutex
+	forecasts map[string]domain.StoredForecast
+}
+
+// NewForecastRepository creates a new empty ForecastRepository
+func NewForecastRepository() *ForecastRepository {
+	return &ForecastRepository{
+		forecasts: map[string]domain.StoredForecast{},
+	}
+}
+
+// forecastKey joins the fields identifying a stored forecast
+func forecastKey(origin, destination, model string, horizon, lookback int) string {
+	return fmt.Sprintf("%s|%s|%s|%d|%d", origin, destination, model, horizon, lookback)
+}
+
+// Get returns the stored forecast for a pair, model, horizon and lookback
+func (r *ForecastRepository) Get(ctx context.Context, origin, destination, model string, horizon, lookback int) (*domain.StoredForecast, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	forecast, exists := r.forecasts[forecastKey(origin, destination, model, horizon, lookback)]
+	if !exists {
+		return nil, fmt.Errorf("forecast %s/%s %s: %w", origin, destination, model, domain.ErrNotFound)
+	}
+	return &forecast, nil
+}
+
+// Save stores a forecast, replacing the previous one
+func (r *ForecastRepository) Save(ctx context.Context, forecast *domain.StoredForecast) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.forecasts[forecastKey(forecast.Origin, forecast.Destination, forecast.Model, forecast.Horizon, forecast.Lookback)] = *forecast
+	return nil
+}
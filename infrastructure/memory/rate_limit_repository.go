@@ -0,0 +1,51 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/joy-currency-conversion-private/domain"
+)
+
+// RateLimitRepository implements domain.RateLimitRepository in memory, the
+// buckets are local to this instance
+type RateLimitRepository struct {
+	mu      sync.Mutex
+	buckets map[string]domain.RateLimitBucket
+}
+
+// NewRateLimitRepository creates a new empty RateLimitRepository
+func NewRateLimitRepository() *RateLimitRepository {
+	return &RateLimitRepository{
+		buckets: map[string]domain.RateLimitBucket{},
+	}
+}
+
+// Update replaces the bucket stored under key
+func (r *RateLimitRepository) Update(ctx context.Context, key string, fn func(bucket *domain.RateLimitBucket) domain.RateLimitBucket) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var current *domain.RateLimitBucket
+	if bucket, exists := r.buckets[key]; exists {
+		current = &bucket
+	}
+	r.buckets[key] = fn(current)
+	return nil
+}
+
+// DeleteBefore removes the buckets not updated since t
+func (r *RateLimitRepository) DeleteBefore(ctx context.Context, t time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted int64
+	for key, bucket := range r.buckets {
+		if bucket.UpdatedAt.Before(t) {
+			delete(r.buckets, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,58 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/joy-currency-conversion-private/domain"
+)
+
+// RateRepository implements domain.RateRepository in memory
+type RateRepository struct {
+	mu    sync.RWMutex
+	rates map[string]map[string]float64 // "ORIGIN-DEST" -> date -> rate
+}
+
+// NewRateRepository creates a new empty RateRepository
+func NewRateRepository() *RateRepository {
+	return &RateRepository{
+		rates: map[string]map[string]float64{},
+	}
+}
+
+// SaveRates stores daily rates for a currency pair
+func (r *RateRepository) SaveRates(ctx context.Context, origin, destination, source string, rates []domain.HistoryRate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := origin + "-" + destination
+	byDate, exists := r.rates[key]
+	if !exists {
+		byDate = map[string]float64{}
+		r.rates[key] = byDate
+	}
+	for _, rate := range rates {
+		byDate[rate.Date] = rate.Rate
+	}
+	return nil
+}
+
+// GetRates returns the stored daily rates for a currency pair within a date range
+func (r *RateRepository) GetRates(ctx context.Context, origin, destination string, startDate, endDate time.Time) ([]domain.HistoryRate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	start := startDate.Format("2006-01-02")
+	end := endDate.Format("2006-01-02")
+
+	rates := make([]domain.HistoryRate, 0)
+	for date, rate := range r.rates[origin+"-"+destination] {
+		if date >= start && date <= end {
+			rates = append(rates, domain.HistoryRate{Date: date, Rate: rate})
+		}
+	}
+	sort.Slice(rates, func(i, j int) bool { return rates[i].Date < rates[j].Date })
+	return rates, nil
+}
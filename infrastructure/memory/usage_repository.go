@@ -0,0 +1,36 @@
+package memory
+
+import (
+	"context"
+	"sync"
+)
+
+// ProviderUsageRepository implements domain.ProviderUsageRepository in memory
+type ProviderUsageRepository struct {
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+// NewProviderUsageRepository creates a new empty ProviderUsageRepository
+func NewProviderUsageRepository() *ProviderUsageRepository {
+	return &ProviderUsageRepository{
+		calls: map[string]int{},
+	}
+}
+
+// GetCalls returns the calls made to a provider in a period
+func (r *ProviderUsageRepository) GetCalls(ctx context.Context, provider, period string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.calls[provider+"/"+period], nil
+}
+
+// AddCalls increments the counter of a provider in a period and returns the new total
+func (r *ProviderUsageRepository) AddCalls(ctx context.Context, provider, period string, calls int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.calls[provider+"/"+period] += calls
+	return r.calls[provider+"/"+period], nil
+}
@@ -0,0 +1,91 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/joy-currency-conversion-private/domain"
+)
+
+// UserRepository implements domain.UserRepository in memory
+type UserRepository struct {
+	mu    sync.RWMutex
+	users map[string]domain.User
+}
+
+// NewUserRepository creates a new empty UserRepository
+func NewUserRepository() *UserRepository {
+	return &UserRepository{
+		users: map[string]domain.User{},
+	}
+}
+
+// Create stores a new user
+func (r *UserRepository) Create(ctx context.Context, user *domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.users {
+		if existing.ID == user.ID || strings.EqualFold(existing.Email, user.Email) {
+			return fmt.Errorf("user %s: %w", user.Email, domain.ErrAlreadyExists)
+		}
+	}
+	r.users[user.ID] = *user
+	return nil
+}
+
+// Get returns the user with the given ID
+func (r *UserRepository) Get(ctx context.Context, id string) (*domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, exists := r.users[id]
+	if !exists {
+		return nil, fmt.Errorf("user: %w", domain.ErrNotFound)
+	}
+	return &user, nil
+}
+
+// GetByEmail returns the user with the given email
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if strings.EqualFold(user.Email, email) {
+			return &user, nil
+		}
+	}
+	return nil, fmt.Errorf("user: %w", domain.ErrNotFound)
+}
+
+// SetRole changes the role of the user with the given ID
+func (r *UserRepository) SetRole(ctx context.Context, id, role string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, exists := r.users[id]
+	if !exists {
+		return fmt.Errorf("user %s: %w", id, domain.ErrNotFound)
+	}
+	user.Role = role
+	r.users[id] = user
+	return nil
+}
+
+// SetEmailVerified records when the user with the given ID verified their email
+func (r *UserRepository) SetEmailVerified(ctx context.Context, id string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, exists := r.users[id]
+	if !exists {
+		return fmt.Errorf("user %s: %w", id, domain.ErrNotFound)
+	}
+	user.EmailVerifiedAt = &at
+	r.users[id] = user
+	return nil
+}
@@ -0,0 +1,200 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Registry holds every collector exposed on /metrics
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "joy_http_request_duration_seconds",
+		Help:    "HTTP request latency by route, method and status code.",
+		Buckets: []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	}, []string{"route", "method", "status"})
+
+	providerRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "joy_provider_requests_total",
+		Help: "Calls to the external rate providers by provider and outcome.",
+	}, []string{"provider", "outcome"})
+
+	providerRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "joy_provider_request_duration_seconds",
+		Help:    "Latency of calls to the external rate providers.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"provider"})
+
+	rateLimited = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "joy_rate_limited_requests_total",
+		Help: "Requests rejected with 429 by the inbound rate limit, by policy.",
+	}, []string{"policy"})
+
+	providerRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "joy_provider_retries_total",
+		Help: "Provider calls repeated after a network error, 429 or 5xx.",
+	}, []string{"provider"})
+
+	rateCacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "joy_rate_cache_requests_total",
+		Help: "Daily rate lookups served from the rate store (hit) or fetched from a provider (miss).",
+	}, []string{"result"})
+
+	forecastCacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "joy_forecast_cache_requests_total",
+		Help: "Forecasts served from the forecast store (hit) or computed (miss).",
+	}, []string{"result"})
+
+	ingestionDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "joy_ingestion_duration_seconds",
+		Help:    "Duration of a full rate ingestion run, forecast precomputation included.",
+		Buckets: []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+	})
+
+	favoriteCheckDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "joy_favorite_check_duration_seconds",
+		Help:    "Duration of a full favorites check run.",
+		Buckets: []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+	})
+
+	alertsTriggered = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "joy_alerts_triggered_total",
+		Help: "Favorites found over their threshold by a check, by currency pair.",
+	}, []string{"origin", "destination"})
+
+	notifications = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "joy_notifications_total",
+		Help: "Notifications handed to a delivery service, by channel and outcome.",
+	}, []string{"channel", "outcome"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequestDuration,
+		providerRequests,
+		providerRequestDuration,
+		providerRetries,
+		rateLimited,
+		rateCacheRequests,
+		forecastCacheRequests,
+		ingestionDuration,
+		favoriteCheckDuration,
+		alertsTriggered,
+		notifications,
+	)
+}
+
+// Handler serves the registry in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// Middleware records the latency of every request labelled by its chi route
+// pattern, so /origins/EUR/destinations and /origins/USD/destinations share a series.
+// Register it before middleware.Recoverer to see the 500 written for a panic; a
+// panic reaching it is recorded as a 500 and passed on.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		defer func() {
+			if rec := recover(); rec != nil {
+				observeRequest(r, http.StatusInternalServerError, start)
+				panic(rec)
+			}
+		}()
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		observeRequest(r, status, start)
+	})
+}
+
+// observeRequest records the latency of a request answered with status
+func observeRequest(r *http.Request, status int, start time.Time) {
+	route := "unmatched"
+	if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+		route = rctx.RoutePattern()
+	}
+	httpRequestDuration.WithLabelValues(route, r.Method, strconv.Itoa(status)).Observe(time.Since(start).Seconds())
+}
+
+// ObserveProviderCall records one call to an external provider.
+// A call counts as an error when it failed or returned a non 2xx status.
+func ObserveProviderCall(provider string, start time.Time, statusCode int, err error) {
+	outcome := "success"
+	if err != nil || statusCode < 200 || statusCode >= 300 {
+		outcome = "error"
+	}
+	providerRequests.WithLabelValues(provider, outcome).Inc()
+	providerRequestDuration.WithLabelValues(provider).Observe(time.Since(start).Seconds())
+}
+
+// ProviderRetry records a provider call about to be repeated
+func ProviderRetry(provider string) {
+	providerRetries.WithLabelValues(provider).Inc()
+}
+
+// RateLimited records a request rejected by the inbound rate limit
+func RateLimited(policy string) {
+	rateLimited.WithLabelValues(policy).Inc()
+}
+
+// RateCacheHit records a daily rate served from the rate store
+func RateCacheHit() {
+	rateCacheRequests.WithLabelValues("hit").Inc()
+}
+
+// RateCacheMiss records a daily rate that had to be fetched from a provider
+func RateCacheMiss() {
+	rateCacheRequests.WithLabelValues("miss").Inc()
+}
+
+// ForecastCacheHit records a forecast served from the forecast store
+func ForecastCacheHit() {
+	forecastCacheRequests.WithLabelValues("hit").Inc()
+}
+
+// ForecastCacheMiss records a forecast that had to be computed
+func ForecastCacheMiss() {
+	forecastCacheRequests.WithLabelValues("miss").Inc()
+}
+
+// ObserveIngestion records the duration of a rate ingestion run
+func ObserveIngestion(start time.Time) {
+	ingestionDuration.Observe(time.Since(start).Seconds())
+}
+
+// ObserveFavoriteCheck records the duration of a favorites check run
+func ObserveFavoriteCheck(start time.Time) {
+	favoriteCheckDuration.Observe(time.Since(start).Seconds())
+}
+
+// AlertTriggered records a favorite found over its threshold by a check
+func AlertTriggered(origin, destination string) {
+	alertsTriggered.WithLabelValues(origin, destination).Inc()
+}
+
+// NotificationDelivered records the outcome of handing a notification to a
+// delivery service, call it only where one is actually called
+func NotificationDelivered(channel string, err error) {
+	outcome := "sent"
+	if err != nil {
+		outcome = "failed"
+	}
+	notifications.WithLabelValues(channel, outcome).Inc()
+}
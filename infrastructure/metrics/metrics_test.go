@@ -0,0 +1,80 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// requestCount returns how many requests to route were recorded with status
+func requestCount(t *testing.T, route, status string) uint64 {
+	t.Helper()
+	families, err := Registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() != "joy_http_request_duration_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["route"] == route && labels["status"] == status {
+				return metric.GetHistogram().GetSampleCount()
+			}
+		}
+	}
+	return 0
+}
+
+func TestMiddlewareRecordsPanics(t *testing.T) {
+	tests := []struct {
+		name        string
+		route       string
+		middlewares []func(http.Handler) http.Handler
+	}{
+		{"before the recoverer", "/panic/before", []func(http.Handler) http.Handler{Middleware, middleware.Recoverer}},
+		{"after the recoverer", "/panic/after", []func(http.Handler) http.Handler{middleware.Recoverer, Middleware}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := chi.NewRouter()
+			router.Use(tt.middlewares...)
+			router.Get(tt.route, func(w http.ResponseWriter, r *http.Request) { panic("boom") })
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tt.route, nil))
+			if recorder.Code != http.StatusInternalServerError {
+				t.Errorf("status = %d, want 500", recorder.Code)
+			}
+			if got := requestCount(t, tt.route, "500"); got != 1 {
+				t.Errorf("%d requests recorded with 500, want 1", got)
+			}
+		})
+	}
+}
+
+func TestMiddlewareRecordsStatus(t *testing.T) {
+	router := chi.NewRouter()
+	router.Use(Middleware)
+	router.Get("/items/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	router.Post("/items", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusCreated) })
+
+	for _, path := range []string{"/items/1", "/items/2"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/items", nil))
+
+	if got := requestCount(t, "/items/{id}", "200"); got != 2 {
+		t.Errorf("%d requests recorded for /items/{id}, want 2 sharing the route pattern", got)
+	}
+	if got := requestCount(t, "/items", "201"); got != 1 {
+		t.Errorf("%d requests recorded for /items with 201, want 1", got)
+	}
+}
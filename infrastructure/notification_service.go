@@ -2,33 +2,51 @@ package infrastructure
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	/*
 	"time"
 	*/
 
-	/*
 	"github.com/aws/aws-sdk-go/aws"
-	*/
 	"github.com/aws/aws-sdk-go/service/ses"
 	"github.com/aws/aws-sdk-go/service/sqs"
 	"github.com/joy-currency-conversion-private/domain"
+	"github.com/joy-currency-conversion-private/infrastructure/metrics"
 )
 
 // NotificationService implements domain.NotificationService using AWS SES and SQS
 type NotificationService struct {
-	ses *ses.SES
-	sqs *sqs.SQS
+	ses      *ses.SES
+	sqs      *sqs.SQS
+	queueURL string
+	from     string
 }
 
-// NewNotificationService creates a new NotificationService
-func NewNotificationService(sesClient *ses.SES, sqsClient *sqs.SQS) *NotificationService {
+// NewNotificationService creates a new NotificationService sending through the
+// given queue, emails sent through SES come from the address from
+func NewNotificationService(sesClient *ses.SES, sqsClient *sqs.SQS, queueURL, from string) *NotificationService {
 	return &NotificationService{
-		ses: sesClient,
-		sqs: sqsClient,
+		ses:      sesClient,
+		sqs:      sqsClient,
+		queueURL: queueURL,
+		from:     from,
 	}
 }
 
+// PingQueue checks the notification queue exists and is reachable
+func (s *NotificationService) PingQueue(ctx context.Context) error {
+	_, err := s.sqs.GetQueueAttributesWithContext(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       aws.String(s.queueURL),
+		AttributeNames: []*string{aws.String(sqs.QueueAttributeNameQueueArn)},
+	})
+	if err != nil {
+		return fmt.Errorf("sqs error: %w", err)
+	}
+	return nil
+}
+
 // SendEmailNotification sends an email notification
 func (s *NotificationService) SendEmailNotification(ctx context.Context, req *domain.NotificationRequest) (*domain.NotificationResponse, error) {
 	// TODO: Implement email sending using AWS SES
@@ -66,15 +84,14 @@ Project Joy Team
 	
 	// TODO: Send email via SES
 	// For now, just log the email content (mock implementation)
-	fmt.Printf("Email would be sent to %s:\nSubject: %s\nBody: %s\n", 
-		req.NotifyEmail, subject, body)
+	slog.InfoContext(ctx, "email would be sent", "to", req.NotifyEmail, "subject", subject, "body", body)
 	
 	// TODO: Queue email in SQS for async processing
 	// This would involve:
 	// 1. Creating an SQS message with email details
 	// 2. Sending the message to a queue
 	// 3. Having a separate Lambda function process the queue
-	
+
 	response := &domain.NotificationResponse{
 		Message: "Email queued/sent",
 		SentTo:  req.NotifyEmail,
@@ -83,6 +100,27 @@ Project Joy Team
 	return response, nil
 }
 
+// SendVerificationEmail sends the token a user posts to /api/v1/users/verify
+// to verify their email
+func (s *NotificationService) SendVerificationEmail(ctx context.Context, email, token string) error {
+	subject := "Verify your Project Joy email"
+	body := fmt.Sprintf(`
+Welcome to Project Joy!
+
+Verify your email to receive rate alerts by posting this token to
+/api/v1/users/verify within 24 hours:
+
+%s
+
+Best regards,
+Project Joy Team
+`, token)
+
+	// The body is never logged, the token in it proves owning the address
+	slog.InfoContext(ctx, "sending email", "to", email, "subject", subject)
+	return s.SendEmailViaSES(ctx, email, subject, body)
+}
+
 // QueueEmailNotification queues an email notification in SQS for async processing
 func (s *NotificationService) QueueEmailNotification(ctx context.Context, req *domain.NotificationRequest) error {
 	// TODO: Implement SQS queuing
@@ -97,15 +135,10 @@ func (s *NotificationService) QueueEmailNotification(ctx context.Context, req *d
 
 // SendEmailViaSES sends an email directly using AWS SES
 func (s *NotificationService) SendEmailViaSES(ctx context.Context, to, subject, body string) error {
-	// TODO: Implement direct SES email sending
-	// This would involve:
-	// 1. Creating a SendEmailInput with proper parameters
-	// 2. Setting the source email (must be verified in SES)
-	// 3. Handling the response and any errors
-	// 4. Managing bounce and complaint notifications
-	
-	// Example implementation:
-	/*
+	if s.from == "" {
+		return errors.New("no sender address configured for SES emails")
+	}
+
 	input := &ses.SendEmailInput{
 		Destination: &ses.Destination{
 			ToAddresses: []*string{aws.String(to)},
@@ -122,13 +155,13 @@ func (s *NotificationService) SendEmailViaSES(ctx context.Context, to, subject,
 				Charset: aws.String("UTF-8"),
 			},
 		},
-		Source: aws.String("noreply@yourdomain.com"), // Must be verified in SES
+		Source: aws.String(s.from), // Must be verified in SES
 	}
-	
+
 	_, err := s.ses.SendEmailWithContext(ctx, input)
-	return err
-	*/
-	
-	// For now, just return nil (mock implementation)
+	metrics.NotificationDelivered("email", err)
+	if err != nil {
+		return fmt.Errorf("ses error: %w", err)
+	}
 	return nil
 }
@@ -0,0 +1,135 @@
+package infrastructure
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ses"
+	"github.com/joy-currency-conversion-private/domain"
+	"github.com/joy-currency-conversion-private/infrastructure/metrics"
+)
+
+// newTestNotificationService sends SES requests to handler
+func newTestNotificationService(t *testing.T, from string, handler http.HandlerFunc) *NotificationService {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	sess := session.Must(session.NewSession(&aws.Config{
+		Region:      aws.String("us-east-1"),
+		Endpoint:    aws.String(server.URL),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+		MaxRetries:  aws.Int(0),
+	}))
+	return NewNotificationService(ses.New(sess), nil, "", from)
+}
+
+// emailsDelivered returns the joy_notifications_total count of emails with the given outcome
+func emailsDelivered(t *testing.T, outcome string) float64 {
+	t.Helper()
+	families, err := metrics.Registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() != "joy_notifications_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["channel"] == "email" && labels["outcome"] == outcome {
+				return metric.GetCounter().GetValue()
+			}
+		}
+	}
+	return 0
+}
+
+func TestSendVerificationEmail(t *testing.T) {
+	var sent url.Values
+	service := newTestNotificationService(t, "noreply@example.com", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		sent = r.PostForm
+		w.Header().Set("Content-Type", "text/xml")
+		w.Write([]byte(`<SendEmailResponse><SendEmailResult><MessageId>1</MessageId></SendEmailResult></SendEmailResponse>`))
+	})
+
+	var logs bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	before := emailsDelivered(t, "sent")
+	if err := service.SendVerificationEmail(context.Background(), "ana@example.com", "secret-token"); err != nil {
+		t.Fatalf("SendVerificationEmail: %v", err)
+	}
+	if delivered := emailsDelivered(t, "sent") - before; delivered != 1 {
+		t.Errorf("%v emails recorded as sent, want 1", delivered)
+	}
+	if sent.Get("Source") != "noreply@example.com" || sent.Get("Destination.ToAddresses.member.1") != "ana@example.com" {
+		t.Errorf("SES request = %v", sent)
+	}
+	if !strings.Contains(sent.Get("Message.Body.Text.Data"), "secret-token") {
+		t.Error("token missing from the email body")
+	}
+	if strings.Contains(logs.String(), "secret-token") {
+		t.Errorf("token logged: %s", logs.String())
+	}
+	if !strings.Contains(logs.String(), "ana@example.com") {
+		t.Errorf("recipient not logged: %s", logs.String())
+	}
+}
+
+func TestSendVerificationEmailFails(t *testing.T) {
+	tests := []struct {
+		name       string
+		from       string
+		handler    http.HandlerFunc
+		wantFailed float64
+	}{
+		{"no sender address", "", func(w http.ResponseWriter, r *http.Request) {
+			t.Error("SES called without a sender address")
+		}, 0},
+		{"ses rejects", "noreply@example.com", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`<ErrorResponse><Error><Code>MessageRejected</Code><Message>Email address is not verified.</Message></Error></ErrorResponse>`))
+		}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newTestNotificationService(t, tt.from, tt.handler)
+			before := emailsDelivered(t, "failed")
+			if err := service.SendVerificationEmail(context.Background(), "ana@example.com", "token"); err == nil {
+				t.Error("SendVerificationEmail succeeded, want an error")
+			}
+			if failed := emailsDelivered(t, "failed") - before; failed != tt.wantFailed {
+				t.Errorf("%v emails recorded as failed, want %v", failed, tt.wantFailed)
+			}
+		})
+	}
+}
+
+func TestMockAlertEmailNotCounted(t *testing.T) {
+	service := newTestNotificationService(t, "noreply@example.com", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("alert emails are not sent through SES yet")
+	})
+
+	before := emailsDelivered(t, "sent")
+	if _, err := service.SendEmailNotification(context.Background(), &domain.NotificationRequest{NotifyEmail: "ana@example.com"}); err != nil {
+		t.Fatalf("SendEmailNotification: %v", err)
+	}
+	if delivered := emailsDelivered(t, "sent") - before; delivered != 0 {
+		t.Errorf("%v logged alert emails recorded as sent, want 0", delivered)
+	}
+}
@@ -0,0 +1,196 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/joy-currency-conversion-private/domain"
+	"github.com/joy-currency-conversion-private/infrastructure/auth"
+	"github.com/joy-currency-conversion-private/infrastructure/metrics"
+)
+
+// Limit is a token bucket refilled at PerMinute tokens per minute holding at
+// most PerMinute tokens, so a client can make PerMinute requests in a burst
+// and then one every 60/PerMinute seconds. Zero disables the limit.
+type Limit struct {
+	PerMinute int
+}
+
+// Policy holds the limits of one class of endpoints, per client IP and per API key
+type Policy struct {
+	Name   string
+	PerIP  Limit
+	PerKey Limit
+}
+
+// Result is the state of a bucket after taking a token
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+
+	// RetryAfter is how long until the next token, set when not allowed
+	RetryAfter time.Duration
+
+	// Reset is how long until the bucket is full again
+	Reset time.Duration
+}
+
+// Limiter enforces the limits on buckets kept in a repository, in memory for
+// a single instance or in the database to share them between instances
+type Limiter struct {
+	buckets domain.RateLimitRepository
+	now     func() time.Time
+}
+
+// NewLimiter creates a Limiter storing its buckets in buckets
+func NewLimiter(buckets domain.RateLimitRepository) *Limiter {
+	return &Limiter{
+		buckets: buckets,
+		now:     time.Now,
+	}
+}
+
+// Take takes one token from the bucket under key
+func (l *Limiter) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	capacity := float64(limit.PerMinute)
+	perSecond := capacity / 60
+	now := l.now()
+
+	var tokens float64
+	var allowed bool
+	err := l.buckets.Update(ctx, key, func(bucket *domain.RateLimitBucket) domain.RateLimitBucket {
+		tokens = capacity
+		if bucket != nil {
+			elapsed := math.Max(now.Sub(bucket.UpdatedAt).Seconds(), 0)
+			tokens = math.Min(capacity, bucket.Tokens+elapsed*perSecond)
+		}
+		allowed = tokens >= 1
+		if allowed {
+			tokens--
+		}
+		return domain.RateLimitBucket{Tokens: tokens, UpdatedAt: now}
+	})
+	if err != nil {
+		return Result{}, err
+	}
+
+	result := Result{
+		Allowed:   allowed,
+		Limit:     limit.PerMinute,
+		Remaining: int(tokens),
+		Reset:     time.Duration((capacity - tokens) / perSecond * float64(time.Second)),
+	}
+	if !allowed {
+		result.RetryAfter = time.Duration((1 - tokens) / perSecond * float64(time.Second))
+	}
+	return result, nil
+}
+
+// Cleanup removes the buckets idle for longer than idle, which are full again anyway
+func (l *Limiter) Cleanup(ctx context.Context, idle time.Duration) (int64, error) {
+	return l.buckets.DeleteBefore(ctx, l.now().Add(-idle))
+}
+
+// PerIP limits the requests of each client IP, as left by RealIP, to the
+// policy. It runs before authentication so unauthenticated floods never reach
+// the credential checks.
+func (l *Limiter) PerIP(policy Policy) func(http.Handler) http.Handler {
+	return l.middleware(policy, func(r *http.Request) (bucket, bool) {
+		ip := r.RemoteAddr
+		if host, _, err := net.SplitHostPort(ip); err == nil {
+			ip = host
+		}
+		return bucket{key: policy.Name + ":ip:" + ip, limit: policy.PerIP}, true
+	})
+}
+
+// PerKey limits the requests of each user or API key to the policy. It runs
+// after authentication, unauthenticated requests only take from their IP bucket.
+func (l *Limiter) PerKey(policy Policy) func(http.Handler) http.Handler {
+	return l.middleware(policy, func(r *http.Request) (bucket, bool) {
+		principal, ok := auth.PrincipalFromContext(r.Context())
+		if !ok {
+			return bucket{}, false
+		}
+		return bucket{key: policy.Name + ":" + principal.Subject(), limit: policy.PerKey}, true
+	})
+}
+
+// middleware takes a token from the bucket of the client of each request.
+// Requests over the limit get 429 with Retry-After; every response carries the
+// X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset headers of
+// the tightest bucket the request took from.
+func (l *Limiter) middleware(policy Policy, clientBucket func(r *http.Request) (bucket, bool)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			bucket, ok := clientBucket(r)
+			if !ok || bucket.limit.PerMinute <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			result, err := l.Take(r.Context(), bucket.key, bucket.limit)
+			if err != nil {
+				// Fail open: an unavailable store must not take the API down
+				slog.ErrorContext(r.Context(), "rate limit check failed", "policy", policy.Name, "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+			// The buckets taken from earlier in the chain are kept in the context
+			if earlier, ok := r.Context().Value(resultKey{}).(Result); ok && !tighter(result, earlier) {
+				result = earlier
+			}
+
+			header := w.Header()
+			header.Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+			header.Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			header.Set("X-RateLimit-Reset", strconv.Itoa(seconds(result.Reset)))
+
+			if !result.Allowed {
+				metrics.RateLimited(policy.Name)
+				header.Set("Retry-After", strconv.Itoa(seconds(result.RetryAfter)))
+				header.Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusTooManyRequests)
+				json.NewEncoder(w).Encode(map[string]string{
+					"error": "Rate limit exceeded, retry later",
+					"code":  "RATE_LIMITED",
+				})
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), resultKey{}, result)))
+		})
+	}
+}
+
+// resultKey is the context key of the tightest Result taken so far
+type resultKey struct{}
+
+// bucket names a token bucket and its limit
+type bucket struct {
+	key   string
+	limit Limit
+}
+
+// tighter reports whether a is more restrictive than b: refused, or with
+// fewer requests left
+func tighter(a, b Result) bool {
+	if a.Allowed != b.Allowed {
+		return !a.Allowed
+	}
+	if !a.Allowed {
+		return a.RetryAfter > b.RetryAfter
+	}
+	return a.Remaining < b.Remaining
+}
+
+// seconds rounds d up to whole seconds
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,158 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/joy-currency-conversion-private/infrastructure/auth"
+	"github.com/joy-currency-conversion-private/infrastructure/memory"
+)
+
+func TestRealIP(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+	tests := []struct {
+		name           string
+		remoteAddr     string
+		forwardedFor   string
+		realIP         string
+		wantRemoteAddr string
+	}{
+		{"untrusted peer keeps its address", "203.0.113.7:5000", "198.51.100.1", "198.51.100.2", "203.0.113.7:5000"},
+		{"trusted peer reports the client", "10.0.0.2:5000", "198.51.100.1", "", "198.51.100.1"},
+		{"spoofed hops before the client are ignored", "10.0.0.2:5000", "1.2.3.4, 198.51.100.1", "", "198.51.100.1"},
+		{"trusted hops are skipped", "10.0.0.2:5000", "198.51.100.1, 10.0.0.9", "", "198.51.100.1"},
+		{"X-Real-IP without X-Forwarded-For", "10.0.0.2:5000", "", "198.51.100.3", "198.51.100.3"},
+		{"invalid hop is not believed", "10.0.0.2:5000", "nonsense", "", "10.0.0.2:5000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.forwardedFor != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+			if tt.realIP != "" {
+				r.Header.Set("X-Real-IP", tt.realIP)
+			}
+
+			var got string
+			RealIP(trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.RemoteAddr
+			})).ServeHTTP(httptest.NewRecorder(), r)
+
+			if got != tt.wantRemoteAddr {
+				t.Errorf("RemoteAddr = %q, want %q", got, tt.wantRemoteAddr)
+			}
+		})
+	}
+}
+
+func TestMiddlewareSpoofedForwardedFor(t *testing.T) {
+	limiter := NewLimiter(memory.NewRateLimitRepository())
+	handler := RealIP(nil)(limiter.PerIP(Policy{Name: "test", PerIP: Limit{PerMinute: 2}})(okHandler()))
+
+	codes := make([]int, 3)
+	for i := range codes {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = "203.0.113.7:5000"
+		r.Header.Set("X-Forwarded-For", netip.AddrFrom4([4]byte{198, 51, 100, byte(i)}).String())
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		codes[i] = w.Code
+	}
+
+	if codes[2] != http.StatusTooManyRequests {
+		t.Errorf("statuses = %v, want the third request limited", codes)
+	}
+}
+
+func TestMiddlewareAppliesIPAndKeyLimits(t *testing.T) {
+	limiter := NewLimiter(memory.NewRateLimitRepository())
+	policy := Policy{
+		Name:   "test",
+		PerIP:  Limit{PerMinute: 2},
+		PerKey: Limit{PerMinute: 10},
+	}
+	handler := limiter.PerIP(policy)(limiter.PerKey(policy)(okHandler()))
+
+	request := func(userID string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = "203.0.113.7:5000"
+		r = r.WithContext(auth.WithPrincipal(r.Context(), &auth.Principal{UserID: userID}))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	request("alice")
+	if w := request("bob"); w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Limit") != "2" {
+		t.Fatalf("second request: status %d, limit %q, want 200 under the IP limit of 2", w.Code, w.Header().Get("X-RateLimit-Limit"))
+	}
+	// A third user from the same IP is still over the IP limit
+	if w := request("carol"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("third request from the same IP: status %d, want 429", w.Code)
+	}
+}
+
+func TestMiddlewareKeyLimitAcrossIPs(t *testing.T) {
+	limiter := NewLimiter(memory.NewRateLimitRepository())
+	policy := Policy{
+		Name:   "test",
+		PerIP:  Limit{PerMinute: 10},
+		PerKey: Limit{PerMinute: 1},
+	}
+	handler := limiter.PerIP(policy)(limiter.PerKey(policy)(okHandler()))
+
+	codes := make([]int, 2)
+	for i := range codes {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = netip.AddrFrom4([4]byte{203, 0, 113, byte(i)}).String() + ":5000"
+		r = r.WithContext(auth.WithPrincipal(r.Context(), &auth.Principal{APIKeyID: "key-1"}))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		codes[i] = w.Code
+	}
+
+	if codes[1] != http.StatusTooManyRequests {
+		t.Errorf("statuses = %v, want the key limited on its second request", codes)
+	}
+}
+
+func TestIPLimitBeforeAuthentication(t *testing.T) {
+	limiter := NewLimiter(memory.NewRateLimitRepository())
+	policy := Policy{Name: "test", PerIP: Limit{PerMinute: 2}, PerKey: Limit{PerMinute: 10}}
+
+	authenticated := 0
+	authenticate := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authenticated++
+			next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), &auth.Principal{UserID: "alice"})))
+		})
+	}
+	handler := limiter.PerIP(policy)(authenticate(limiter.PerKey(policy)(okHandler())))
+
+	codes := make([]int, 3)
+	for i := range codes {
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		r.RemoteAddr = "203.0.113.7:5000"
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		codes[i] = w.Code
+	}
+
+	if codes[2] != http.StatusTooManyRequests {
+		t.Errorf("statuses = %v, want the third request limited", codes)
+	}
+	if authenticated != 2 {
+		t.Errorf("%d requests authenticated, want the limited one rejected before authentication", authenticated)
+	}
+}
+
+func okHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+}
@@ -0,0 +1,181 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/joy-currency-conversion-private/domain"
+	"github.com/joy-currency-conversion-private/infrastructure/db"
+)
+
+// openMigrated returns an in-memory database with every migration applied
+func openMigrated(t *testing.T) *sql.DB {
+	t.Helper()
+	conn, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	if _, err := db.MigrateUp(context.Background(), conn, "sqlite"); err != nil {
+		t.Fatalf("MigrateUp: %v", err)
+	}
+	return conn
+}
+
+func TestUpserts(t *testing.T) {
+	conn := openMigrated(t)
+	ctx := context.Background()
+
+	rates := db.NewRateRepository(conn, Dialect)
+	day := []domain.HistoryRate{{Date: "2025-09-01", Rate: 1.1}}
+	if err := rates.SaveRates(ctx, "EUR", "USD", "a", day); err != nil {
+		t.Fatalf("SaveRates: %v", err)
+	}
+	day[0].Rate = 1.2
+	if err := rates.SaveRates(ctx, "EUR", "USD", "b", day); err != nil {
+		t.Fatalf("SaveRates again: %v", err)
+	}
+	stored, err := rates.GetRates(ctx, "EUR", "USD", time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 9, 2, 0, 0, 0, 0, time.UTC))
+	if err != nil || len(stored) != 1 || stored[0].Rate != 1.2 {
+		t.Errorf("GetRates = %+v, %v; want the one day replaced by 1.2", stored, err)
+	}
+
+	usage := db.NewProviderUsageRepository(conn, Dialect)
+	for range 2 {
+		if _, err := usage.AddCalls(ctx, "p", "2025-09", 2); err != nil {
+			t.Fatalf("AddCalls: %v", err)
+		}
+	}
+	if calls, err := usage.GetCalls(ctx, "p", "2025-09"); err != nil || calls != 4 {
+		t.Errorf("GetCalls = %d, %v; want 4 added up", calls, err)
+	}
+
+	alerts := db.NewAlertStateRepository(conn, Dialect)
+	favorites := db.NewFavoriteRepository(conn, Dialect)
+	favorite := &domain.Favorite{ID: "f1", Origin: domain.Currency{Code: "EUR"}, Destination: domain.Currency{Code: "USD"}, Threshold: 1.1, NotifyEmail: "a@example.com", CreatedAt: time.Now().UTC()}
+	if err := favorites.Create(ctx, favorite); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	for _, exceeded := range []bool{true, false} {
+		state := &domain.AlertState{FavoriteID: "f1", Exceeded: exceeded, LastRate: 1.2, LastCheckedAt: time.Now().UTC()}
+		if err := alerts.Save(ctx, state); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+	if state, err := alerts.Get(ctx, "f1"); err != nil || state.Exceeded {
+		t.Errorf("alert state = %+v, %v; want the second save", state, err)
+	}
+	if err := alerts.Delete(ctx, "f1"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := alerts.Get(ctx, "f1"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("alert state after delete error = %v, want ErrNotFound", err)
+	}
+
+	limits := db.NewRateLimitRepository(conn, Dialect)
+	for tokens := range 2 {
+		err := limits.Update(ctx, "ip:1", func(bucket *domain.RateLimitBucket) domain.RateLimitBucket {
+			if (bucket == nil) != (tokens == 0) {
+				t.Errorf("update %d got bucket %+v", tokens, bucket)
+			}
+			return domain.RateLimitBucket{Tokens: float64(tokens), UpdatedAt: time.Now()}
+		})
+		if err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+	}
+}
+
+func TestDuplicates(t *testing.T) {
+	conn := openMigrated(t)
+	ctx := context.Background()
+
+	users := db.NewUserRepository(conn, Dialect)
+	user := &domain.User{ID: "u1", Email: "a@example.com", PasswordHash: "x", Role: domain.RoleUser, CreatedAt: time.Now().UTC()}
+	if err := users.Create(ctx, user); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	again := *user
+	again.ID = "u2"
+	if err := users.Create(ctx, &again); !errors.Is(err, domain.ErrAlreadyExists) {
+		t.Errorf("Create(same email) error = %v, want ErrAlreadyExists", err)
+	}
+
+	// Unchanged values and missing users are told apart
+	if err := users.SetRole(ctx, "u1", domain.RoleUser); err != nil {
+		t.Errorf("SetRole(unchanged): %v", err)
+	}
+	if err := users.SetRole(ctx, "missing", domain.RoleAdmin); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("SetRole(missing) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestDuplicateFavorites(t *testing.T) {
+	conn := openMigrated(t)
+	ctx := context.Background()
+	favorites := db.NewFavoriteRepository(conn, Dialect)
+
+	favorite := func(id, owner, email, destination string, threshold float64) *domain.Favorite {
+		return &domain.Favorite{ID: id, Origin: domain.Currency{Code: "EUR"}, Destination: domain.Currency{Code: destination}, Threshold: threshold, NotifyEmail: email, OwnerID: owner, CreatedAt: time.Now().UTC()}
+	}
+	tests := []struct {
+		name     string
+		favorite *domain.Favorite
+		wantErr  error
+	}{
+		{"owned", favorite("f1", "u1", "ana@example.com", "USD", 1.2), nil},
+		{"same owner and pair", favorite("f2", "u1", "ana@example.com", "USD", 1.5), domain.ErrAlreadyExists},
+		{"same owner, other pair", favorite("f3", "u1", "ana@example.com", "COP", 4000), nil},
+		{"other owner, same pair", favorite("f4", "u2", "bo@example.com", "USD", 1.2), nil},
+		{"unowned", favorite("f5", "", "ana@example.com", "USD", 1.2), nil},
+		{"unowned, same email and pair", favorite("f6", "", "ana@example.com", "USD", 1.3), domain.ErrAlreadyExists},
+		{"taken id", favorite("f1", "u3", "cy@example.com", "USD", 1.2), domain.ErrAlreadyExists},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := favorites.Create(ctx, tt.favorite)
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Create error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUniqueFavoritesMigration(t *testing.T) {
+	conn := openMigrated(t)
+	ctx := context.Background()
+
+	// Duplicates saved before the unique index existed
+	if _, err := db.MigrateDown(ctx, conn, "sqlite", 1); err != nil {
+		t.Fatalf("MigrateDown: %v", err)
+	}
+	for _, id := range []string{"f1", "f2"} {
+		if _, err := conn.Exec(`INSERT INTO favorites (id, origin, destination, threshold, notify_email, created_at) VALUES (?, 'EUR', 'USD', 1.2, 'ana@example.com', ?)`, id, time.Now().UTC()); err != nil {
+			t.Fatalf("insert %s: %v", id, err)
+		}
+	}
+	if _, err := db.MigrateUp(ctx, conn, "sqlite"); err != nil {
+		t.Fatalf("MigrateUp over duplicates: %v", err)
+	}
+
+	var keyed int
+	var key string
+	if err := conn.QueryRow(`SELECT COUNT(unique_key), MAX(unique_key) FROM favorites`).Scan(&keyed, &key); err != nil {
+		t.Fatalf("reading unique keys: %v", err)
+	}
+	want := (&domain.Favorite{Origin: domain.Currency{Code: "EUR"}, Destination: domain.Currency{Code: "USD"}, NotifyEmail: "ana@example.com"}).UniqueKey()
+	if keyed != 1 || key != want {
+		t.Errorf("%d favorites keyed with %q, want one keyed with %q", keyed, key, want)
+	}
+
+	again := &domain.Favorite{ID: "f3", Origin: domain.Currency{Code: "EUR"}, Destination: domain.Currency{Code: "USD"}, Threshold: 1.4, NotifyEmail: "ana@example.com", CreatedAt: time.Now().UTC()}
+	if err := db.NewFavoriteRepository(conn, Dialect).Create(ctx, again); !errors.Is(err, domain.ErrAlreadyExists) {
+		t.Errorf("Create(duplicate of a migrated favorite) error = %v, want ErrAlreadyExists", err)
+	}
+}
@@ -0,0 +1,55 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/joy-currency-conversion-private/infrastructure/db"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// Open opens a SQLite database at the given path, ":memory:" keeps it in memory.
+// The schema is created by the same migrations used for MySQL (db.MigrateUp).
+func Open(path string) (*sql.DB, error) {
+	conn, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite allows a single writer, and every connection to ":memory:"
+	// would get its own empty database, so keep one shared connection
+	conn.SetMaxOpenConns(1)
+
+	if _, err := conn.Exec(`PRAGMA foreign_keys = ON; PRAGMA busy_timeout = 5000`); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("configuring sqlite: %w", err)
+	}
+	if err := conn.Ping(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("could not open sqlite db: %w", err)
+	}
+
+	return conn, nil
+}
+
+// Dialect is the SQL dialect of SQLite, for the repositories of package db.
+// SQLite allows a single writer, so rows read in a transaction need no lock.
+var Dialect = db.Dialect{
+	Name:        "sqlite",
+	OnConflict:  func(keys ...string) string { return "ON CONFLICT (" + strings.Join(keys, ", ") + ") DO UPDATE SET" },
+	Inserted:    func(column string) string { return "excluded." + column },
+	IsDuplicate: isUniqueViolation,
+}
+
+// isUniqueViolation reports whether err is a primary key or unique constraint violation
+func isUniqueViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	return sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY ||
+		sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+}
@@ -0,0 +1,280 @@
+package upstream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/joy-currency-conversion-private/domain"
+	"github.com/joy-currency-conversion-private/infrastructure/metrics"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
+)
+
+var tracer = otel.Tracer("github.com/joy-currency-conversion-private/infrastructure/upstream")
+
+// Policy controls how calls to one provider are made
+type Policy struct {
+	// Timeout bounds a single attempt, including reading the body
+	Timeout time.Duration
+
+	// MaxRetries is how many times a failed attempt is repeated, zero disables retries
+	MaxRetries int
+
+	// BaseBackoff is the first retry delay, doubled on every attempt up to MaxBackoff
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+
+	// RatePerSecond and Burst size the token bucket spacing the calls, zero disables it
+	RatePerSecond float64
+	Burst         int
+
+	// MonthlyQuota is how many calls the plan allows per calendar month (UTC), zero means unlimited
+	MonthlyQuota int
+
+	// QuotaReserve is the fraction of MonthlyQuota kept unused, calls are refused once only the reserve is left
+	QuotaReserve float64
+}
+
+// DefaultPolicy is used for providers without their own policy
+var DefaultPolicy = Policy{
+	Timeout:     10 * time.Second,
+	MaxRetries:  2,
+	BaseBackoff: 500 * time.Millisecond,
+	MaxBackoff:  5 * time.Second,
+}
+
+// maxRetryAfter is the longest Retry-After the client waits for, longer
+// waits return the response to the caller instead
+const maxRetryAfter = 30 * time.Second
+
+// Client calls the rate providers over a shared transport. Requests follow
+// the caller's context, attempts that fail with a network error, 429 or 5xx
+// are retried with jittered exponential backoff honoring Retry-After. Every
+// attempt waits for the provider's token bucket and, once admitted, counts
+// against the monthly quota, since the provider bills every request it gets.
+type Client struct {
+	transport http.RoundTripper
+	policies  map[string]Policy
+	limiters  map[string]*rate.Limiter
+	quotas    *quotas
+}
+
+// NewClient creates a Client with a policy per provider name, persisting the
+// quota counters in usage
+func NewClient(policies map[string]Policy, usage domain.ProviderUsageRepository) *Client {
+	limiters := map[string]*rate.Limiter{}
+	for provider, policy := range policies {
+		if policy.RatePerSecond > 0 {
+			limiters[provider] = rate.NewLimiter(rate.Limit(policy.RatePerSecond), max(policy.Burst, 1))
+		}
+	}
+
+	return &Client{
+		transport: http.DefaultTransport,
+		policies:  policies,
+		limiters:  limiters,
+		quotas:    &quotas{usage: usage, counters: map[string]*counter{}},
+	}
+}
+
+// Usage reports the quota usage of every configured provider, ordered by name
+func (c *Client) Usage(ctx context.Context) ([]ProviderUsage, error) {
+	providers := slices.Sorted(maps.Keys(c.policies))
+	usages := make([]ProviderUsage, 0, len(providers))
+	for _, provider := range providers {
+		u, err := c.quotas.usageOf(ctx, provider, c.policies[provider])
+		if err != nil {
+			return nil, fmt.Errorf("reading usage of %s: %w", provider, err)
+		}
+		usages = append(usages, u)
+	}
+	return usages, nil
+}
+
+// policy returns the policy of a provider, falling back to DefaultPolicy
+func (c *Client) policy(provider string) Policy {
+	if p, ok := c.policies[provider]; ok {
+		return p
+	}
+	return DefaultPolicy
+}
+
+// Get fetches url from provider. The response of the last attempt is
+// returned whatever its status, the caller must close its body. The URL is
+// left out of logs and span attributes since it may contain an API key.
+func (c *Client) Get(ctx context.Context, provider, rawURL string) (*http.Response, error) {
+	ctx, span := tracer.Start(ctx, "provider "+provider, trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+	span.SetAttributes(attribute.String("provider", provider), attribute.String("http.request.method", http.MethodGet))
+
+	policy := c.policy(provider)
+	client := &http.Client{Transport: c.transport, Timeout: policy.Timeout}
+
+	for attempt := 0; ; attempt++ {
+		if err := c.wait(ctx, provider, policy); err != nil {
+			c.finish(span, nil, err, attempt)
+			return nil, err
+		}
+
+		resp, err := c.attempt(ctx, client, provider, rawURL)
+		if attempt >= policy.MaxRetries || !retryable(resp, err) || ctx.Err() != nil {
+			c.finish(span, resp, err, attempt)
+			return resp, err
+		}
+
+		wait := backoff(policy, attempt)
+		if resp != nil {
+			if after, ok := retryAfter(resp); ok {
+				wait = after
+			}
+			// Hand the response back rather than waiting past what the caller allows
+			if deadline, ok := ctx.Deadline(); wait > maxRetryAfter || ok && time.Until(deadline) < wait {
+				c.finish(span, resp, err, attempt)
+				return resp, err
+			}
+			// Drain so the connection can be reused
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		metrics.ProviderRetry(provider)
+		attrs := []any{"provider", provider, "attempt", attempt + 1, "status", statusOf(resp), "wait_ms", wait.Milliseconds()}
+		if err != nil {
+			attrs = append(attrs, "error", err)
+		}
+		slog.WarnContext(ctx, "retrying provider call", attrs...)
+		span.AddEvent("retry", trace.WithAttributes(attribute.Int("attempt", attempt+1)))
+
+		if err := Sleep(ctx, wait); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return nil, err
+		}
+	}
+}
+
+// wait waits for the provider's token bucket and then takes the attempt from
+// its quota, so an attempt cancelled while waiting isn't counted
+func (c *Client) wait(ctx context.Context, provider string, policy Policy) error {
+	if limiter, ok := c.limiters[provider]; ok {
+		if err := limiter.Wait(ctx); err != nil {
+			return fmt.Errorf("waiting for %s rate limit: %w", provider, err)
+		}
+	}
+	return c.quotas.take(ctx, provider, policy)
+}
+
+// attempt sends one request and records its metrics and log line
+func (c *Client) attempt(ctx context.Context, client *http.Client, provider, rawURL string) (*http.Response, error) {
+	start := time.Now()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		// The error embeds the URL, keep only the cause
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+	}
+	metrics.ObserveProviderCall(provider, start, statusOf(resp), err)
+
+	attrs := []any{"provider", provider, "status", statusOf(resp), "duration_ms", time.Since(start).Milliseconds()}
+	if err != nil {
+		slog.ErrorContext(ctx, "provider call failed", append(attrs, "error", err)...)
+	} else {
+		slog.DebugContext(ctx, "provider call", attrs...)
+	}
+	return resp, err
+}
+
+// finish records the outcome of the last attempt on the span
+func (c *Client) finish(span trace.Span, resp *http.Response, err error, retries int) {
+	span.SetAttributes(attribute.Int("retries", retries))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+	}
+}
+
+// retryable reports whether an attempt may succeed if repeated
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled)
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+}
+
+// backoff returns a random delay up to BaseBackoff*2^attempt, capped at MaxBackoff
+func backoff(policy Policy, attempt int) time.Duration {
+	limit := policy.BaseBackoff << attempt
+	if limit <= 0 || limit > policy.MaxBackoff {
+		limit = policy.MaxBackoff
+	}
+	if limit <= 0 {
+		return 0
+	}
+	// Half fixed, half random so retries from concurrent requests spread out
+	return limit/2 + rand.N(limit/2+1)
+}
+
+// retryAfter parses the Retry-After header, given either in seconds or as an HTTP date
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0), true
+	}
+	return 0, false
+}
+
+// statusOf returns the status code of resp, zero when there is no response
+func statusOf(resp *http.Response) int {
+	if resp == nil {
+		return 0
+	}
+	return resp.StatusCode
+}
+
+// Sleep waits for d or until ctx is done, returning the context error in the latter case
+func Sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
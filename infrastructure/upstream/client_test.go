@@ -0,0 +1,144 @@
+package upstream
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/joy-currency-conversion-private/domain"
+	"github.com/joy-currency-conversion-private/infrastructure/memory"
+)
+
+// newProvider serves the given statuses in turn, then 200, counting the requests
+func newProvider(t *testing.T, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if i := int(hits.Add(1)) - 1; i < len(statuses) {
+			w.WriteHeader(statuses[i])
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &hits
+}
+
+// get calls the provider and closes the response
+func get(ctx context.Context, client *Client, provider, url string) error {
+	resp, err := client.Get(ctx, provider, url)
+	if resp != nil {
+		resp.Body.Close()
+	}
+	return err
+}
+
+func storedCalls(t *testing.T, usage domain.ProviderUsageRepository, provider string) int {
+	t.Helper()
+	calls, err := usage.GetCalls(context.Background(), provider, period(time.Now()))
+	if err != nil {
+		t.Fatalf("GetCalls: %v", err)
+	}
+	return calls
+}
+
+func TestRetriesCountEveryAttempt(t *testing.T) {
+	server, hits := newProvider(t, http.StatusServiceUnavailable, http.StatusTooManyRequests)
+	usage := memory.NewProviderUsageRepository()
+	client := NewClient(map[string]Policy{"p": {MaxRetries: 2, BaseBackoff: time.Millisecond, MaxBackoff: time.Millisecond}}, usage)
+
+	if err := get(context.Background(), client, "p", server.URL); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if hits.Load() != 3 {
+		t.Errorf("%d requests, want 3", hits.Load())
+	}
+	if calls := storedCalls(t, usage, "p"); calls != 3 {
+		t.Errorf("%d calls counted, want 3 for a call tried 3 times", calls)
+	}
+}
+
+func TestCancelledWaitNotCounted(t *testing.T) {
+	server, hits := newProvider(t)
+	usage := memory.NewProviderUsageRepository()
+	client := NewClient(map[string]Policy{"p": {RatePerSecond: 0.001, Burst: 1}}, usage)
+
+	// The first call uses the only token, the second gives up waiting for the next one
+	if err := get(context.Background(), client, "p", server.URL); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := get(ctx, client, "p", server.URL); err == nil {
+		t.Fatal("Get while rate limited = nil, want an error")
+	}
+	if hits.Load() != 1 {
+		t.Errorf("%d requests, want 1", hits.Load())
+	}
+	if calls := storedCalls(t, usage, "p"); calls != 1 {
+		t.Errorf("%d calls counted, want only the one admitted", calls)
+	}
+}
+
+func TestQuotaExhausted(t *testing.T) {
+	server, hits := newProvider(t)
+	usage := memory.NewProviderUsageRepository()
+	// Calls of a previous instance are loaded from the store
+	usage.AddCalls(context.Background(), "p", period(time.Now()), 7)
+	client := NewClient(map[string]Policy{"p": {MonthlyQuota: 10, QuotaReserve: 0.2}}, usage)
+
+	if err := get(context.Background(), client, "p", server.URL); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if err := get(context.Background(), client, "p", server.URL); !errors.Is(err, domain.ErrQuotaExhausted) {
+		t.Fatalf("Get past the reserve error = %v, want ErrQuotaExhausted", err)
+	}
+	if hits.Load() != 1 || storedCalls(t, usage, "p") != 8 {
+		t.Errorf("%d requests and %d calls counted, want 1 and 8", hits.Load(), storedCalls(t, usage, "p"))
+	}
+}
+
+// blockingUsage holds GetCalls of one provider until release is closed,
+// signalling loading once it is reached
+type blockingUsage struct {
+	*memory.ProviderUsageRepository
+	provider string
+	loading  chan struct{}
+	release  chan struct{}
+}
+
+func (u *blockingUsage) GetCalls(ctx context.Context, provider, period string) (int, error) {
+	if provider == u.provider {
+		close(u.loading)
+		<-u.release
+	}
+	return u.ProviderUsageRepository.GetCalls(ctx, provider, period)
+}
+
+func TestSlowUsageLoadDoesNotBlockOtherProviders(t *testing.T) {
+	server, _ := newProvider(t)
+	usage := &blockingUsage{ProviderUsageRepository: memory.NewProviderUsageRepository(), provider: "slow", loading: make(chan struct{}), release: make(chan struct{})}
+	client := NewClient(map[string]Policy{"slow": {}, "fast": {}}, usage)
+
+	slow := make(chan error, 1)
+	go func() { slow <- get(context.Background(), client, "slow", server.URL) }()
+	<-usage.loading
+
+	fast := make(chan error, 1)
+	go func() { fast <- get(context.Background(), client, "fast", server.URL) }()
+	select {
+	case err := <-fast:
+		if err != nil {
+			t.Errorf("Get(fast): %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the call to fast waited for the usage of slow")
+	}
+
+	close(usage.release)
+	if err := <-slow; err != nil {
+		t.Errorf("Get(slow): %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+package upstream
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/joy-currency-conversion-private/domain"
+)
+
+// ProviderUsage reports the quota and rate limit of a provider for the current month
+type ProviderUsage struct {
+	Provider      string  `json:"provider"`
+	Period        string  `json:"period"`
+	Calls         int     `json:"calls"`
+	MonthlyQuota  int     `json:"monthly_quota"` // 0 means unlimited
+	Reserve       int     `json:"reserve"`
+	Remaining     *int    `json:"remaining,omitempty"`
+	Exhausted     bool    `json:"exhausted"`
+	RatePerSecond float64 `json:"rate_per_second"`
+	Burst         int     `json:"burst"`
+}
+
+// quotas counts the calls made to every provider in the current month. The
+// counters are kept in memory and every call is persisted, so they survive
+// restarts and converge when several instances share the database.
+type quotas struct {
+	usage domain.ProviderUsageRepository
+
+	mu       sync.Mutex
+	counters map[string]*counter
+}
+
+type counter struct {
+	period string
+	calls  int
+}
+
+// period returns the quota period (calendar month in UTC) of t
+func period(t time.Time) string {
+	return t.UTC().Format("2006-01")
+}
+
+// reserve returns how many calls of the monthly quota are kept unused
+func (p Policy) reserve() int {
+	return int(math.Ceil(float64(p.MonthlyQuota) * p.QuotaReserve))
+}
+
+// take records a call to provider, refusing it with domain.ErrQuotaExhausted
+// when only the reserve of the monthly quota is left
+func (q *quotas) take(ctx context.Context, provider string, policy Policy) error {
+	current := period(time.Now())
+	q.load(ctx, provider, current)
+
+	q.mu.Lock()
+	c := q.counters[provider]
+	if policy.MonthlyQuota > 0 && c.calls >= policy.MonthlyQuota-policy.reserve() {
+		q.mu.Unlock()
+		return fmt.Errorf("%s: %w", provider, domain.ErrQuotaExhausted)
+	}
+	c.calls++
+	q.mu.Unlock()
+
+	// Count the call even if the request is cancelled meanwhile
+	total, err := q.usage.AddCalls(context.WithoutCancel(ctx), provider, current, 1)
+	if err != nil {
+		slog.WarnContext(ctx, "recording provider usage failed", "provider", provider, "error", err)
+		return nil
+	}
+
+	// Other instances may have made calls too
+	q.mu.Lock()
+	if c.period == current && total > c.calls {
+		c.calls = total
+	}
+	q.mu.Unlock()
+	return nil
+}
+
+// load reads the stored counter of provider when its period is not the
+// current one yet. The database is read without holding the lock, so a slow
+// query doesn't hold up the calls to other providers.
+func (q *quotas) load(ctx context.Context, provider, current string) {
+	q.mu.Lock()
+	c, ok := q.counters[provider]
+	loaded := ok && c.period == current
+	q.mu.Unlock()
+	if loaded {
+		return
+	}
+
+	calls, err := q.usage.GetCalls(ctx, provider, current)
+	if err != nil {
+		// Keep counting locally rather than blocking every provider call
+		slog.WarnContext(ctx, "loading provider usage failed", "provider", provider, "error", err)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	c, ok = q.counters[provider]
+	if !ok {
+		c = &counter{}
+		q.counters[provider] = c
+	}
+	if c.period != current {
+		c.period, c.calls = current, calls
+	} else if calls > c.calls {
+		// Loaded concurrently by another call
+		c.calls = calls
+	}
+}
+
+// usageOf reads the stored counter of provider and reports it against policy
+func (q *quotas) usageOf(ctx context.Context, provider string, policy Policy) (ProviderUsage, error) {
+	current := period(time.Now())
+	calls, err := q.usage.GetCalls(ctx, provider, current)
+	if err != nil {
+		return ProviderUsage{}, err
+	}
+
+	u := ProviderUsage{
+		Provider:      provider,
+		Period:        current,
+		Calls:         calls,
+		MonthlyQuota:  policy.MonthlyQuota,
+		RatePerSecond: policy.RatePerSecond,
+		Burst:         policy.Burst,
+	}
+	if policy.MonthlyQuota > 0 {
+		u.Reserve = policy.reserve()
+		remaining := max(policy.MonthlyQuota-u.Reserve-calls, 0)
+		u.Remaining = &remaining
+		u.Exhausted = remaining == 0
+	}
+	return u, nil
+}
@@ -1,42 +1,190 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
 	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/joy-currency-conversion-private/config"
+	"github.com/joy-currency-conversion-private/domain"
 	"github.com/joy-currency-conversion-private/handlers"
 	"github.com/joy-currency-conversion-private/infrastructure"
+	"github.com/joy-currency-conversion-private/infrastructure/auth"
 	"github.com/joy-currency-conversion-private/infrastructure/db"
+	"github.com/joy-currency-conversion-private/infrastructure/health"
+	"github.com/joy-currency-conversion-private/infrastructure/logging"
+	"github.com/joy-currency-conversion-private/infrastructure/memory"
+	"github.com/joy-currency-conversion-private/infrastructure/metrics"
+	"github.com/joy-currency-conversion-private/infrastructure/ratelimit"
+	"github.com/joy-currency-conversion-private/infrastructure/tracing"
+	"github.com/joy-currency-conversion-private/infrastructure/upstream"
 )
 
 func main() {
+	// Subcommand: apply or revert schema migrations and exit
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:]); err != nil {
+			fatal("migrate failed", err)
+		}
+		return
+	}
+
+	// Subcommand: manage API keys, e.g. to create the first admin key
+	if len(os.Args) > 1 && os.Args[1] == "apikey" {
+		if err := runAPIKey(os.Args[2:]); err != nil {
+			fatal("apikey failed", err)
+		}
+		return
+	}
+
+	// Subcommand: change user roles or verify emails, e.g. to promote the first admin
+	if len(os.Args) > 1 && os.Args[1] == "user" {
+		if err := runUser(os.Args[2:]); err != nil {
+			fatal("user failed", err)
+		}
+		return
+	}
+
+	// Cancelled on SIGINT/SIGTERM to start the graceful shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	configuratios, err := config.LoadConfig()
 	if err != nil {
-		log.Fatal("Failed to load config:", err)
+		fatal("failed to load config", err)
 	}
 
-	if err := db.Connect(); err != nil {
-		log.Fatalf("db connect: %v", err)
+	if err := logging.Setup(os.Stdout, configuratios.Log.Format, configuratios.Log.Level); err != nil {
+		fatal("failed to set up logging", err)
+	}
+
+	shutdownTracing, err := tracing.Setup(ctx, configuratios.Tracing.Exporter, configuratios.Tracing.Endpoint, configuratios.Tracing.SampleRatio)
+	if err != nil {
+		fatal("failed to set up tracing", err)
 	}
 
+	store, err := openStorage(configuratios)
+	if err != nil {
+		fatal("db connect failed", err)
+	}
+
+	// Apply pending migrations unless disabled (DB_AUTO_MIGRATE=false)
+	if configuratios.Database.AutoMigrate {
+		applied, err := db.MigrateUp(ctx, store.conn, configuratios.Database.Driver)
+		if err != nil {
+			fatal("db migrate failed", err)
+		}
+		slog.Info("migrations applied", "count", applied)
+	}
+
+	// Shared client for the rate providers, each with its own timeout, rate limit and monthly quota
+	upstreamClient := upstream.NewClient(map[string]upstream.Policy{
+		infrastructure.ExchangeRateAPI:  providerPolicy(configuratios.Providers.ExchangeRateAPI, configuratios.Providers),
+		infrastructure.ExchangeRatesAPI: providerPolicy(configuratios.Providers.ExchangeRatesAPI, configuratios.Providers),
+	}, store.usage)
+
 	// Initialize AWS services
-	awsServices := infrastructure.NewAWSServices(configuratios.KyeEchangeRateAPI, configuratios.KyeEchangeRatesAPI)
+	awsServices := infrastructure.NewAWSServices(configuratios.AWSRegion, upstreamClient, configuratios.KyeEchangeRateAPI, configuratios.KyeEchangeRatesAPI, configuratios.Notifications.SQSQueueURL, configuratios.Notifications.EmailFrom, store.favorites, store.rates, store.forecasts, store.alerts)
+
+	// Refresh rotated secrets periodically and on SIGHUP
+	reloader := config.NewReloader(configuratios)
+	reloader.OnReload(func(cfg *config.Config) {
+		awsServices.UpdateAPIKeys(cfg.KyeEchangeRateAPI, cfg.KyeEchangeRatesAPI)
+		if err := logging.SetLevel(cfg.Log.Level); err != nil {
+			slog.Error("invalid log level", "error", err)
+		}
+	})
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	// Background workers stop when ctx is cancelled
+	var background workers
+	background.Go("config reloader", func() { reloader.Run(ctx, hup) })
+
+	// Inbound rate limits, buckets are per instance unless shared through the database
+	var rateLimits domain.RateLimitRepository = memory.NewRateLimitRepository()
+	if configuratios.RateLimit.Store == "sql" {
+		rateLimits = store.rateLimits
+	}
+	limiter := ratelimit.NewLimiter(rateLimits)
+	background.Go("rate limit cleanup", func() { cleanupRateLimits(ctx, limiter) })
+
+	// Daily rate ingestion, followed by the forecasts of the favorite pairs
+	if configuratios.Ingestion.Enabled {
+		background.Go("rate ingestion", func() { ingestDaily(ctx, awsServices.IngestionService, configuratios.Ingestion.At) })
+	}
+
+	// Readiness checks: the database and DynamoDB favorites are critical, the
+	// notification queue, providers and workers only degrade the service
+	providerClient := &http.Client{Timeout: 3 * time.Second}
+	checker := health.NewChecker(5 * time.Second)
+	checker.Add("database", true, health.DatabaseCheck(store.conn))
+	if store.dynamoFavorites != nil {
+		checker.Add("dynamodb favorites", true, store.dynamoFavorites.Ping)
+	}
+	if configuratios.Notifications.SQSQueueURL != "" {
+		checker.Add("notification queue", false, health.Cached(time.Minute, awsServices.PingQueue))
+	}
+	checker.Add(infrastructure.ExchangeRateAPI, false, health.Cached(time.Minute, health.ReachableCheck(providerClient, infrastructure.ExchangeRateAPIBaseURL)))
+	checker.Add(infrastructure.ExchangeRatesAPI, false, health.Cached(time.Minute, health.ReachableCheck(providerClient, infrastructure.ExchangeRatesAPIBaseURL)))
+	checker.Add("config reloader", false, background.Check("config reloader"))
+	checker.Add("rate limit cleanup", false, background.Check("rate limit cleanup"))
+	if configuratios.Ingestion.Enabled {
+		checker.Add("rate ingestion", false, background.Check("rate ingestion"))
+	}
 
 	// Initialize handlers
-	currencyHandler := handlers.NewCurrencyHandler(awsServices)
+	currencyHandler := handlers.NewCurrencyHandler(awsServices, configuratios.Auth.AnonymousFavorites)
+	healthHandler := handlers.NewHealthHandler(checker)
+	apiKeys := auth.NewKeys(store.apiKeys)
+	// Without a sender address no verification email is sent, users are verified with `user verify`
+	var verificationSender auth.VerificationSender
+	if configuratios.Notifications.EmailFrom != "" {
+		verificationSender = awsServices.NotificationService
+	}
+	users := auth.NewUsers(store.users, jwtSecret(configuratios.Auth), configuratios.Auth.TokenTTL, verificationSender)
+	authenticator := auth.NewAuthenticator(apiKeys, users)
+	adminHandler := handlers.NewAdminHandler(upstreamClient, apiKeys)
+	userHandler := handlers.NewUserHandler(users)
+
+	// Rotated JWT secrets and MySQL credentials apply without a restart
+	reloader.OnReload(func(cfg *config.Config) {
+		if cfg.Auth.JWTSecret != "" && users.RotateSecret([]byte(cfg.Auth.JWTSecret)) {
+			slog.Info("JWT secret rotated, tokens signed with the previous one are accepted until they expire")
+		}
+		if store.connector == nil {
+			return
+		}
+		rotateCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+		database := cfg.Database
+		rotated, err := store.connector.Rotate(rotateCtx, store.conn, database.User, database.Password, database.Host, database.Port)
+		if err != nil {
+			slog.Error("database credentials rotation failed, keeping the current ones", "error", err)
+		} else if rotated {
+			slog.Info("database credentials rotated, idle connections closed")
+		}
+	})
 
 	// Setup Chi router
 	router := chi.NewRouter()
 
 	// Add middleware
-	router.Use(middleware.Logger)
-	router.Use(middleware.Recoverer)
 	router.Use(middleware.RequestID)
-	router.Use(middleware.RealIP)
+	router.Use(ratelimit.RealIP(configuratios.Server.TrustedProxies))
+	router.Use(tracing.Middleware)
+	router.Use(logging.Middleware)
+	// Before the recoverer so the 500 written for a panic is counted
+	router.Use(metrics.Middleware)
+	router.Use(middleware.Recoverer)
 
 	// Health check
 	router.Get("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -44,32 +192,223 @@ func main() {
 		json.NewEncoder(w).Encode(map[string]string{"status": "healthy"})
 	})
 
+	// Prometheus metrics
+	router.Handle("/metrics", metrics.Handler())
+
+	// Liveness and readiness probes for the load balancer
+	router.Get("/livez", healthHandler.Livez)
+	router.Get("/readyz", healthHandler.Readyz)
+
+	// Request limits per client: history and forecast call the providers once per day of data,
+	// logins have their own bucket so password guesses don't use up the cheap one
+	cheap, expensive, login := rateLimitPolicies(configuratios.RateLimit, limiter)
+
+	require := func(scope string) func(http.Handler) http.Handler {
+		if !configuratios.Auth.Enabled {
+			return func(next http.Handler) http.Handler { return next }
+		}
+		return auth.Require(scope)
+	}
+
+	// guard takes a token from the per-IP bucket before checking credentials, so
+	// floods are turned away before hashing passwords or looking up keys, and from
+	// the per-key bucket once the caller is known. The caller is identified even
+	// with auth disabled, so signed-in users still own their favorites. Scopes are
+	// checked before the per-key limit so refused calls take no key tokens.
+	guard := func(limit rateLimit, scope string) chi.Middlewares {
+		middlewares := chi.Chain(limit.perIP, authenticator.Middleware)
+		if scope != "" {
+			middlewares = append(middlewares, require(scope))
+		}
+		return append(middlewares, limit.perKey)
+	}
+
 	// API v1 routes
 	router.Route("/api/v1", func(r chi.Router) {
+		// Accounts: anyone may register and log in, favorites belong to the signed-in user
+		r.With(guard(cheap, "")...).Post("/users", userHandler.Register)
+		r.With(guard(login, "")...).Post("/auth/login", userHandler.Login)
+		r.With(guard(cheap, "")...).Get("/users/me", userHandler.Me)
+		r.With(guard(cheap, "")...).Post("/users/verify", userHandler.VerifyEmail)
+		r.With(guard(cheap, "")...).Post("/users/me/verification", userHandler.ResendVerification)
+
 		// Endpoint 1: Currency Conversion
-		r.Get("/convert", currencyHandler.Convert)
+		r.With(guard(cheap, domain.ScopeRatesRead)...).Get("/convert", currencyHandler.Convert)
 
 		// Endpoint 2: Daily Historic Values
-		r.Get("/history", currencyHandler.History)
+		r.With(guard(expensive, domain.ScopeRatesRead)...).Get("/history", currencyHandler.History)
 
 		// Endpoint 3: Probability Forecast
-		r.Get("/forecast", currencyHandler.Forecast)
+		r.With(guard(expensive, domain.ScopeRatesRead)...).Get("/forecast", currencyHandler.Forecast)
+
+		// Forecast accuracy over stored history, it never calls the providers
+		r.With(guard(cheap, domain.ScopeRatesRead)...).Get("/forecast/backtest", currencyHandler.Backtest)
+
+		// Indicators over stored history, it never calls the providers
+		r.With(guard(cheap, domain.ScopeRatesRead)...).Get("/analytics", currencyHandler.Analytics)
 
 		// Endpoint 4: Available Destination Currencies
-		r.Get("/origins/{origin}/destinations", currencyHandler.GetDestinations)
+		r.With(guard(cheap, domain.ScopeRatesRead)...).Get("/origins/{origin}/destinations", currencyHandler.GetDestinations)
+
+		// Endpoint 5: Save a Favorite Conversion, and manage your own favorites
+		r.With(guard(cheap, domain.ScopeFavorites)...).Post("/favorites", currencyHandler.SaveFavorite)
+		r.With(guard(cheap, domain.ScopeFavorites)...).Get("/favorites", currencyHandler.ListFavorites)
+		r.With(guard(cheap, domain.ScopeFavorites)...).Get("/favorites/{id}", currencyHandler.GetFavorite)
+		r.With(guard(cheap, domain.ScopeFavorites)...).Delete("/favorites/{id}", currencyHandler.DeleteFavorite)
 
-		// Endpoint 5: Save a Favorite Conversion
-		r.Post("/favorites", currencyHandler.SaveFavorite)
+		// Endpoint 6: Daily Favorite Check, run by the scheduler
+		r.With(guard(cheap, domain.ScopeAdmin)...).Post("/favorites/check", currencyHandler.CheckFavorites)
 
-		// Endpoint 6: Daily Favorite Check
-		r.Post("/favorites/check", currencyHandler.CheckFavorites)
+		// Daily rate ingestion and forecast precomputation, run by the scheduler or the ingestion worker
+		r.With(guard(expensive, domain.ScopeAdmin)...).Post("/rates/ingest", currencyHandler.IngestRates)
 
 		// Endpoint 7: Email Notification
-		r.Post("/notifications/email", currencyHandler.SendNotification)
+		r.With(guard(cheap, domain.ScopeNotificationsSend)...).Post("/notifications/email", currencyHandler.SendNotification)
+
+		// Administration: provider usage and API keys
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(guard(cheap, domain.ScopeAdmin)...)
+			r.Get("/providers", adminHandler.Providers)
+			r.Post("/api-keys", adminHandler.CreateAPIKey)
+			r.Get("/api-keys", adminHandler.ListAPIKeys)
+			r.Delete("/api-keys/{id}", adminHandler.RevokeAPIKey)
+		})
+	})
+
+	slog.Info("starting Project Joy API server", "port", configuratios.Port, "profile", configuratios.Profile)
+	serveErr := serve(ctx, newServer(configuratios, router), configuratios, checker.Drain)
+
+	// Stop background workers before closing the resources they use
+	stop()
+	background.Wait()
+
+	if err := store.conn.Close(); err != nil {
+		slog.Error("closing db failed", "error", err)
+	}
+
+	// Flush the spans still buffered with a fresh deadline, ctx is already cancelled
+	flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if err := shutdownTracing(flushCtx); err != nil {
+		slog.Error("flushing traces failed", "error", err)
+	}
+	cancel()
+
+	if serveErr != nil {
+		fatal("server failed", serveErr)
+	}
+	slog.Info("server stopped")
+}
+
+// rateLimit takes a token from the bucket of the client IP before
+// authentication and from the one of the user or API key after it
+type rateLimit struct {
+	perIP  func(http.Handler) http.Handler
+	perKey func(http.Handler) http.Handler
+}
+
+// rateLimitPolicies returns the request limits of the cheap and expensive
+// endpoints and of logins, or no limits when rate limiting is disabled
+func rateLimitPolicies(cfg config.RateLimitConfig, limiter *ratelimit.Limiter) (cheap, expensive, login rateLimit) {
+	if !cfg.Enabled {
+		noLimit := func(next http.Handler) http.Handler { return next }
+		return rateLimit{noLimit, noLimit}, rateLimit{noLimit, noLimit}, rateLimit{noLimit, noLimit}
+	}
+
+	policy := func(policy ratelimit.Policy) rateLimit {
+		return rateLimit{perIP: limiter.PerIP(policy), perKey: limiter.PerKey(policy)}
+	}
+	cheap = policy(ratelimit.Policy{
+		Name:   "cheap",
+		PerIP:  ratelimit.Limit{PerMinute: cfg.IPCheap},
+		PerKey: ratelimit.Limit{PerMinute: cfg.KeyCheap},
+	})
+	expensive = policy(ratelimit.Policy{
+		Name:   "expensive",
+		PerIP:  ratelimit.Limit{PerMinute: cfg.IPExpensive},
+		PerKey: ratelimit.Limit{PerMinute: cfg.KeyExpensive},
+	})
+	// Logins come before a key exists, only the per-IP limit applies
+	login = policy(ratelimit.Policy{
+		Name:  "login",
+		PerIP: ratelimit.Limit{PerMinute: cfg.IPLogin},
 	})
+	return cheap, expensive, login
+}
+
+// cleanupRateLimits drops idle rate limit buckets until ctx is done. A bucket
+// idle for a minute is full again, so removing it changes nothing.
+func cleanupRateLimits(ctx context.Context, limiter *ratelimit.Limiter) {
+	ticker := time.NewTicker(10 * time.Minute)
+	defer ticker.Stop()
 
-	log.Println("Starting Project Joy API server on :8080")
-	if err := http.ListenAndServe(":8080", router); err != nil {
-		log.Fatal("Failed to start server:", err)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := limiter.Cleanup(ctx, 10*time.Minute)
+			if err != nil {
+				slog.Error("rate limit cleanup failed", "error", err)
+				continue
+			}
+			slog.Debug("rate limit buckets removed", "count", deleted)
+		}
 	}
 }
+
+// ingestDaily runs the rate ingestion every day at the given time of day (UTC)
+// until ctx is done. Failing pairs are only logged, the next run retries them.
+func ingestDaily(ctx context.Context, ingestion domain.IngestionService, at time.Duration) {
+	for {
+		now := time.Now().UTC()
+		next := now.Truncate(24 * time.Hour).Add(at)
+		if !next.After(now) {
+			next = next.Add(24 * time.Hour)
+		}
+		slog.Debug("next rate ingestion scheduled", "at", next)
+
+		timer := time.NewTimer(next.Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			if _, err := ingestion.IngestRates(ctx); err != nil {
+				slog.Error("rate ingestion failed", "error", err)
+			}
+		}
+	}
+}
+
+// jwtSecret returns the secret signing user tokens. Without one configured
+// (only allowed with auth disabled) a random secret is used, so tokens stop
+// working on restart.
+func jwtSecret(cfg config.AuthConfig) []byte {
+	if cfg.JWTSecret != "" {
+		return []byte(cfg.JWTSecret)
+	}
+	slog.Warn("no JWT secret configured (JWT_SECRET), user tokens won't survive a restart")
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		fatal("generating JWT secret failed", err)
+	}
+	return secret
+}
+
+// providerPolicy builds the upstream policy of a provider from its configuration
+func providerPolicy(provider config.ProviderConfig, providers config.ProvidersConfig) upstream.Policy {
+	policy := upstream.DefaultPolicy
+	policy.Timeout = provider.Timeout
+	policy.MaxRetries = providers.MaxRetries
+	policy.RatePerSecond = provider.Rate
+	policy.Burst = 1
+	policy.MonthlyQuota = provider.MonthlyQuota
+	policy.QuotaReserve = providers.QuotaReserve
+	return policy
+}
+
+// fatal logs an error and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/joy-currency-conversion-private/config"
+	"github.com/joy-currency-conversion-private/infrastructure/db"
+)
+
+// runMigrate handles the `migrate` subcommand
+// Usage: main migrate [up | down [steps] | status]
+func runMigrate(args []string) error {
+	cfg, err := config.LoadDatabaseConfig()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+
+	store, err := openStorage(cfg)
+	if err != nil {
+		return fmt.Errorf("db connect: %w", err)
+	}
+	defer store.conn.Close()
+
+	ctx := context.Background()
+
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	switch command {
+	case "up":
+		count, err := db.MigrateUp(ctx, store.conn, cfg.Database.Driver)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Applied %d migration(s)\n", count)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n <= 0 {
+				return fmt.Errorf("invalid number of steps: %s", args[1])
+			}
+			steps = n
+		}
+		count, err := db.MigrateDown(ctx, store.conn, cfg.Database.Driver, steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Reverted %d migration(s)\n", count)
+	case "status":
+		states, err := db.MigrationStatus(ctx, store.conn, cfg.Database.Driver)
+		if err != nil {
+			return err
+		}
+		for _, state := range states {
+			applied := "pending"
+			if state.AppliedAt != nil {
+				applied = state.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%s\t%s\n", state.Version, state.Name, applied)
+		}
+	default:
+		return fmt.Errorf("unknown migrate command %q (use up, down or status)", command)
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/joy-currency-conversion-private/config"
+	"github.com/joy-currency-conversion-private/infrastructure/health"
+)
+
+// newServer builds the HTTP server with the configured timeouts
+func newServer(cfg *config.Config, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:         ":" + cfg.Port,
+		Handler:      handler,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
+	}
+}
+
+// serve runs the server until ctx is done. It then calls drain, keeps serving
+// for cfg.Server.DrainDelay so the load balancer sees /readyz fail, stops
+// accepting connections and waits up to cfg.Server.ShutdownTimeout for
+// in-flight requests
+func serve(ctx context.Context, server *http.Server, cfg *config.Config, drain func()) error {
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		return fmt.Errorf("failed to start server: %w", err)
+	case <-ctx.Done():
+	}
+
+	drain()
+	if cfg.Server.DrainDelay > 0 {
+		slog.Info("draining, readiness now fails", "delay", cfg.Server.DrainDelay.String())
+		select {
+		case err := <-serverErr:
+			return fmt.Errorf("server stopped while draining: %w", err)
+		case <-time.After(cfg.Server.DrainDelay):
+		}
+	}
+
+	slog.Info("shutting down, waiting for in-flight requests", "timeout", cfg.Server.ShutdownTimeout.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		// Drain timed out, drop the remaining connections
+		server.Close()
+		return fmt.Errorf("graceful shutdown: %w", err)
+	}
+	if err := <-serverErr; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// workers tracks background goroutines (config reloader, schedulers, queue
+// consumers) so shutdown can wait for them after cancelling their context
+type workers struct {
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	running map[string]bool
+}
+
+// Go runs fn in a new goroutine, fn must return once its context is done
+func (w *workers) Go(name string, fn func()) {
+	w.mu.Lock()
+	if w.running == nil {
+		w.running = map[string]bool{}
+	}
+	w.running[name] = true
+	w.mu.Unlock()
+
+	w.wg.Go(func() {
+		fn()
+		w.mu.Lock()
+		w.running[name] = false
+		w.mu.Unlock()
+		slog.Info("worker stopped", "worker", name)
+	})
+}
+
+// Check reports an error if the named worker is no longer running
+func (w *workers) Check(name string) health.CheckFunc {
+	return func(ctx context.Context) error {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if !w.running[name] {
+			return fmt.Errorf("%s is not running", name)
+		}
+		return nil
+	}
+}
+
+// Wait blocks until every worker has returned
+func (w *workers) Wait() {
+	w.wg.Wait()
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/joy-currency-conversion-private/config"
+	"github.com/joy-currency-conversion-private/domain"
+	"github.com/joy-currency-conversion-private/infrastructure/db"
+	"github.com/joy-currency-conversion-private/infrastructure/dynamo"
+	"github.com/joy-currency-conversion-private/infrastructure/sqlite"
+)
+
+// storage groups the database connection and the repositories built on it
+type storage struct {
+	conn      *sql.DB
+	connector *db.Connector // MySQL only, rotates the credentials of conn
+	favorites domain.FavoriteRepository
+	rates     domain.RateRepository
+	forecasts domain.ForecastRepository
+	alerts    domain.AlertStateRepository
+	usage     domain.ProviderUsageRepository
+	apiKeys   domain.APIKeyRepository
+	users     domain.UserRepository
+
+	// dynamoFavorites is set when favorites are kept in DynamoDB
+	dynamoFavorites *dynamo.FavoriteRepository
+
+	// rateLimits is used when the rate limit buckets are shared through the database
+	rateLimits domain.RateLimitRepository
+}
+
+// openStorage opens the configured SQL database (MySQL or SQLite) and, when
+// favorites are kept in DynamoDB, replaces the SQL favorites repository
+func openStorage(cfg *config.Config) (*storage, error) {
+	store, err := openDatabase(cfg.Database)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Favorites.Store == "dynamodb" {
+		favorites, err := openDynamoFavorites(cfg.AWSRegion, cfg.Favorites)
+		if err != nil {
+			store.conn.Close()
+			return nil, err
+		}
+		store.favorites = favorites
+		store.dynamoFavorites = favorites
+	}
+
+	return store, nil
+}
+
+// openDynamoFavorites builds the DynamoDB favorites repository, optionally
+// pointed at DynamoDB Local and creating the table when it is missing
+func openDynamoFavorites(region string, cfg config.FavoritesConfig) (*dynamo.FavoriteRepository, error) {
+	awsConfig := &aws.Config{Region: aws.String(region)}
+	if cfg.DynamoDBEndpoint != "" {
+		awsConfig.Endpoint = aws.String(cfg.DynamoDBEndpoint)
+	}
+	sess, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("aws session: %w", err)
+	}
+
+	favorites := dynamo.NewFavoriteRepository(dynamodb.New(sess), cfg.DynamoDBTable)
+
+	if cfg.DynamoDBCreateTable {
+		if err := favorites.EnsureTable(context.Background()); err != nil {
+			return nil, fmt.Errorf("creating dynamodb table %s: %w", cfg.DynamoDBTable, err)
+		}
+	}
+
+	return favorites, nil
+}
+
+func openDatabase(cfg config.DatabaseConfig) (*storage, error) {
+	switch cfg.Driver {
+	case "mysql":
+		connector := db.NewConnector(cfg.User, cfg.Password, cfg.Host, cfg.Port, cfg.Name)
+		conn, err := db.Connect(connector)
+		if err != nil {
+			return nil, err
+		}
+		store := newStorage(conn, db.MySQL)
+		store.connector = connector
+		return store, nil
+	case "sqlite":
+		conn, err := sqlite.Open(cfg.Path)
+		if err != nil {
+			return nil, err
+		}
+		return newStorage(conn, sqlite.Dialect), nil
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", cfg.Driver)
+	}
+}
+
+// newStorage builds the SQL repositories on conn, the statements that differ follow dialect
+func newStorage(conn *sql.DB, dialect db.Dialect) *storage {
+	return &storage{
+		conn:       conn,
+		favorites:  db.NewFavoriteRepository(conn, dialect),
+		rates:      db.NewRateRepository(conn, dialect),
+		forecasts:  db.NewForecastRepository(conn, dialect),
+		alerts:     db.NewAlertStateRepository(conn, dialect),
+		usage:      db.NewProviderUsageRepository(conn, dialect),
+		apiKeys:    db.NewAPIKeyRepository(conn, dialect),
+		users:      db.NewUserRepository(conn, dialect),
+		rateLimits: db.NewRateLimitRepository(conn, dialect),
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/joy-currency-conversion-private/config"
+	"github.com/joy-currency-conversion-private/domain"
+	"github.com/joy-currency-conversion-private/infrastructure/auth"
+)
+
+// runUser handles the `user` subcommand
+// Usage: main user [promote <email> | demote <email> | verify <email>]
+func runUser(args []string) error {
+	cfg, err := config.LoadDatabaseConfig()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+
+	store, err := openStorage(cfg)
+	if err != nil {
+		return fmt.Errorf("db connect: %w", err)
+	}
+	defer store.conn.Close()
+
+	// Only roles and verification are managed here, no token is signed or sent
+	users := auth.NewUsers(store.users, nil, 0, nil)
+
+	if len(args) != 2 {
+		return fmt.Errorf("usage: user [promote | demote | verify] <email>")
+	}
+
+	if args[0] == "verify" {
+		user, err := users.MarkVerified(context.Background(), args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("User %s (%s) verified at %s\n", user.Email, user.ID, user.EmailVerifiedAt.Format(time.RFC3339))
+		return nil
+	}
+
+	role := domain.RoleAdmin
+	switch args[0] {
+	case "promote":
+	case "demote":
+		role = domain.RoleUser
+	default:
+		return fmt.Errorf("unknown user command %q (use promote, demote or verify)", args[0])
+	}
+
+	user, err := users.SetRole(context.Background(), args[1], role)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("User %s (%s) is now %s\n", user.Email, user.ID, user.Role)
+	return nil
+}